@@ -0,0 +1,70 @@
+package sensonetEbus
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+const (
+	VRC720_ADDRESS = 0x15   // slave address of the VRC720 controller (circuit ctlv2)
+	B524_PBSB      = 0xb524 // primary and secondary command of the register access of the VRC720
+
+	B524_GROUP_GENERAL     = 0x00
+	B524_GROUP_HOTWATER    = 0x01
+	B524_GROUP_HEATCIRCUIT = 0x02 // instance 0 is Hc1
+	B524_GROUP_ZONE        = 0x03 // instance 0 is z1
+
+	B524_OPCODE_REGISTER = 0x02
+	B524_READ            = 0x00
+	B524_WRITE           = 0x01
+	B524_REPLY_HEADER    = 4 // bytes preceding the value in the reply to a read request
+)
+
+// B524Register is a register of the VRC720 controller. The controller is read and written with telegrams
+// of the form "15 B524 NN 02 <00 read|01 write> <group> <instance> <register low> <register high> [value]".
+type B524Register struct {
+	Group    byte
+	Instance byte
+	Register uint16
+}
+
+func (r B524Register) String() string {
+	return fmt.Sprintf("B524 group %02x instance %02x register %04x", r.Group, r.Instance, r.Register)
+}
+
+// ReadRequest returns the data of the request reading the register
+func (r B524Register) ReadRequest() []byte {
+	return []byte{B524_OPCODE_REGISTER, B524_READ, r.Group, r.Instance, byte(r.Register), byte(r.Register >> 8)}
+}
+
+// WriteRequest returns the data of the request writing value (already encoded) to the register
+func (r B524Register) WriteRequest(value []byte) []byte {
+	return append([]byte{B524_OPCODE_REGISTER, B524_WRITE, r.Group, r.Instance, byte(r.Register), byte(r.Register >> 8)}, value...)
+}
+
+// ParseB524Register parses the ID of a B524 message definition (including the bytes of the default line),
+// e.g. "020003000800" for z1QuickVetoTemp
+func ParseB524Register(id string) (B524Register, error) {
+	data, err := hex.DecodeString(id)
+	if err != nil || len(data) != 6 || data[0] != B524_OPCODE_REGISTER || data[1] > B524_WRITE {
+		return B524Register{}, fmt.Errorf("%w: %q is no B524 register ID", ErrInvalidParameter, id)
+	}
+	return B524Register{Group: data[2], Instance: data[3], Register: uint16(data[4]) | uint16(data[5])<<8}, nil
+}
+
+// hexTelegram returns the master part of a telegram to slave zz as hex for the command "hex" of ebusd
+func hexTelegram(zz byte, pbsb uint16, data []byte) string {
+	return hex.EncodeToString(append([]byte{zz, byte(pbsb >> 8), byte(pbsb), byte(len(data))}, data...))
+}
+
+// parseHexReply returns the data of the slave response returned by the command "hex" of ebusd (NN data)
+func parseHexReply(reply string) ([]byte, error) {
+	data, err := hex.DecodeString(reply)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reply %q of ebusd: %w", reply, err)
+	}
+	if len(data) == 0 || int(data[0]) != len(data)-1 {
+		return nil, fmt.Errorf("invalid length of the reply %q of ebusd", reply)
+	}
+	return data[1:], nil
+}
@@ -0,0 +1,181 @@
+package sensonetEbus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type ConfigVerdict string
+
+const (
+	CONFIG_VERDICT_OK       ConfigVerdict = "ok"       // all elements can be read
+	CONFIG_VERDICT_DEGRADED ConfigVerdict = "degraded" // all required elements can be read, some optional ones not
+	CONFIG_VERDICT_FAILED   ConfigVerdict = "failed"   // some required elements cannot be read
+
+	CONFIG_GROUP_HOTWATER    = "hotwater"
+	CONFIG_GROUP_STATUS      = "status"
+	CONFIG_GROUP_POWER       = "power"
+	CONFIG_GROUP_ZONE        = "zone"
+	CONFIG_GROUP_HEATCIRCUIT = "heatcircuit"
+	CONFIG_GROUP_HOLIDAY     = "holiday"
+)
+
+// ConfigReport is the result of CheckEbusdConfigReport(): one entry per element the package depends on
+type ConfigReport struct {
+	Time     time.Time       `json:"time"`
+	Verdict  ConfigVerdict   `json:"verdict"`
+	Summary  string          `json:"summary"` // e.g. "58 of 60 elements ok, 2 optional elements failed"
+	Elements []ConfigElement `json:"elements"`
+}
+
+// ConfigElement is the check result of a single element of the ebusd configuration
+type ConfigElement struct {
+	Name     string `json:"name"`
+	Group    string `json:"group"`           // one of the CONFIG_GROUP_* constants
+	Index    int    `json:"index,omitempty"` // zone or heat circuit the element belongs to
+	Circuit  string `json:"circuit"`         // circuit defining the element, empty if it was not found
+	Required bool   `json:"required"`        // false for elements that are only defined in custom configuration files, e.g. the power elements
+	Found    bool   `json:"found"`           // false, if ebusd answered "element not found"
+	ReadOK   bool   `json:"readok"`
+	Reply    string `json:"reply"` // value read or ERR: reply of ebusd
+	Error    string `json:"error,omitempty"`
+
+	err error
+}
+
+// JSON renders the report as indented JSON
+func (r ConfigReport) JSON() ([]byte, error) {
+	return json.MarshalIndent(r, "", "  ")
+}
+
+// Failed returns the elements that could not be read
+func (r ConfigReport) Failed() []ConfigElement {
+	var failed []ConfigElement
+	for _, element := range r.Elements {
+		if !element.ReadOK {
+			failed = append(failed, element)
+		}
+	}
+	return failed
+}
+
+// configElementGroup is a group of elements checked by checkConfig()
+type configElementGroup struct {
+	group    string
+	index    int
+	prefix   string
+	names    []string
+	required bool
+}
+
+// configElementGroups returns the elements the package depends on for the configured zones and heat circuits.
+// The caller must hold c.mu.
+func (c *EbusConnection) configElementGroups() []configElementGroup {
+	groups := []configElementGroup{
+		{group: CONFIG_GROUP_HOTWATER, required: true, names: []string{EBUSDREAD_HOTWATER_OPMODE, EBUSDREAD_HOTWATER_TEMPDESIRED,
+			EBUSDREAD_HOTWATER_STORAGETEMP, EBUSDREAD_HOTWATER_SFMODE}},
+		{group: CONFIG_GROUP_STATUS, required: true, names: []string{EBUSDREAD_STATUS_TIME, EBUSDREAD_STATUS_OUTSIDETEMPERATURE,
+			EBUSDREAD_STATUS_SYSTEMFLOWTEMPERATUE, EBUSDREAD_STATUS_WATERPRESSURE, EBUSDREAD_STATUS_STATUS01, EBUSDREAD_STATUS_STATE}},
+		// the power elements are only defined in the custom configuration files 15.ctlv2.csv and 76.vwz00.csv
+		{group: CONFIG_GROUP_POWER, required: false, names: []string{EBUSDREAD_STATUS_CURRENTCONSUMEDPOWER, EBUSDREAD_STATUS_IMMERSIONHEATERPOWER}},
+		// the holiday periods are only needed for the Holiday section of the system data and SetHoliday()
+		{group: CONFIG_GROUP_HOLIDAY, required: false, names: []string{EBUSDREAD_HOTWATER_HOLIDAYSTARTPERIOD, EBUSDREAD_HOTWATER_HOLIDAYENDPERIOD}},
+	}
+	zoneElements := []string{EBUSDREAD_ZONE_OPMODE, EBUSDREAD_ZONE_SFMODE, EBUSDREAD_ZONE_ACTUALROOMTEMPDESIRED, EBUSDREAD_ZONE_ROOMTEMP,
+		EBUSDREAD_ZONE_QUICKVETOTEMP, EBUSDREAD_ZONE_QUICKVETOENDTIME, EBUSDREAD_ZONE_QUICKVETOENDDATE, EBUSDREAD_ZONE_QUICKVETODURATION,
+		EBUSDREAD_ZONE_NAME1, EBUSDREAD_ZONE_NAME2, EBUSDREAD_ZONE_SHORTNAME, EBUSDREAD_ZONE_DAYTEMP, EBUSDREAD_ZONE_NIGHTTEMP}
+	for _, zone := range c.zones {
+		groups = append(groups, configElementGroup{group: CONFIG_GROUP_ZONE, index: zone, prefix: fmt.Sprintf("z%01d", zone),
+			required: true, names: zoneElements})
+	}
+	zoneHolidayElements := []string{EBUSDREAD_ZONE_HOLIDAYSTARTPERIOD, EBUSDREAD_ZONE_HOLIDAYENDPERIOD, EBUSDREAD_ZONE_HOLIDAYTEMP}
+	for _, zone := range c.zones {
+		groups = append(groups, configElementGroup{group: CONFIG_GROUP_HOLIDAY, index: zone, prefix: fmt.Sprintf("z%01d", zone),
+			required: false, names: zoneHolidayElements})
+	}
+	hcElements := []string{EBUSDREAD_HC_CIRCUITTYPE, EBUSDREAD_HC_ACTUALFLOWTEMPDESIRED, EBUSDREAD_HC_FLOWTEMP, EBUSDREAD_HC_STATUS,
+		EBUSDREAD_HC_PUMPSTATUS, EBUSDREAD_HC_HEATCURVE, EBUSDREAD_HC_SUMMERTEMPLIMIT, EBUSDREAD_HC_MINFLOWTEMPDESIRED, EBUSDREAD_HC_MAXFLOWTEMPDESIRED}
+	for _, hc := range c.heatCircuits {
+		groups = append(groups, configElementGroup{group: CONFIG_GROUP_HEATCIRCUIT, index: hc, prefix: fmt.Sprintf("Hc%01d", hc),
+			required: true, names: hcElements})
+	}
+	return groups
+}
+
+// checkConfig tries to read all elements that are used in the package and looks up the circuits defining them
+func (c *EbusConnection) checkConfig(ctx context.Context) (ConfigReport, error) {
+	err := c.mu.Lock(ctx)
+	if err != nil {
+		return ConfigReport{}, err
+	}
+	defer c.mu.Unlock()
+
+	report := ConfigReport{Time: time.Now()}
+	var refs []ElementRef
+	for _, group := range c.configElementGroups() {
+		for _, name := range group.names {
+			refs = append(refs, ElementRef{Name: group.prefix + name, MaxAge: -1})
+			report.Elements = append(report.Elements, ConfigElement{Name: group.prefix + name, Group: group.group, Index: group.index,
+				Required: group.required})
+		}
+	}
+	results, err := c.ebusdReadMany(ctx, refs)
+	if err != nil {
+		c.debug(fmt.Sprintf("Reading from ebusd aborted: %s", err))
+		return ConfigReport{}, err
+	}
+	for i := range report.Elements {
+		element := &report.Elements[i]
+		element.Reply, element.err = results.get(element.Name)
+		element.ReadOK = element.err == nil
+		element.Found = !errors.Is(element.err, ErrElementNotFound)
+		var ebusdErr *EbusdError
+		if errors.As(element.err, &ebusdErr) {
+			element.Reply = ebusdErr.Reply
+		}
+		if element.err != nil {
+			element.Error = element.err.Error()
+		}
+		if !element.Found || (element.err != nil && !isEbusdError(element.err)) {
+			continue
+		}
+		circuits, err := c.transport.Find(ctx, element.Name)
+		if err != nil && !isEbusdError(err) && !errors.Is(err, ErrElementNotFound) {
+			c.debug(fmt.Sprintf("Looking up the circuit of %s aborted: %s", element.Name, err))
+			return ConfigReport{}, err
+		} else if err != nil {
+			c.debug(fmt.Sprintf("Circuit of %s not found: %s", element.Name, err))
+			continue
+		}
+		if len(circuits) > 0 {
+			element.Circuit = circuits[0]
+		}
+	}
+	report.Verdict = CONFIG_VERDICT_OK
+	var ok, failedRequired, failedOptional int
+	for _, element := range report.Elements {
+		switch {
+		case element.ReadOK:
+			ok++
+		case element.Required:
+			failedRequired++
+			report.Verdict = CONFIG_VERDICT_FAILED
+		default:
+			failedOptional++
+			if report.Verdict == CONFIG_VERDICT_OK {
+				report.Verdict = CONFIG_VERDICT_DEGRADED
+			}
+		}
+	}
+	report.Summary = fmt.Sprintf("%d of %d elements ok", ok, len(report.Elements))
+	if failedRequired > 0 {
+		report.Summary += fmt.Sprintf(", %d required elements failed", failedRequired)
+	}
+	if failedOptional > 0 {
+		report.Summary += fmt.Sprintf(", %d optional elements failed", failedOptional)
+	}
+	return report, nil
+}
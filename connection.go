@@ -1,24 +1,49 @@
 package sensonetEbus
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"net/http"
+	"sync"
 	"time"
+
+	"golang.org/x/exp/slices"
 )
 
-// Connection is the SensonetEbus connection
+// Connection is the SensonetEbus connection. It is safe for concurrent use by multiple goroutines.
 type Connection struct {
+	mu                 ctxMutex // guards the quick mode state and relData
 	logger             Logger
+	transport          Transport
+	httpAPI            bool
+	httpClient         *http.Client
+	adapter            bool
+	adapterMaster      byte
+	recorder           io.Writer
+	retryPolicy        *RetryPolicy
+	noNativeFallback   bool
+	retry              *retryTransport
 	ebusdConn          *EbusConnection
 	currentQuickmode   string
 	quickmodeStarted   time.Time
 	quickmodeStopped   time.Time
 	quickModeExpiresAt string
 	relData            VaillantRelData
+	subMu              sync.Mutex // guards subscribers and stopListen
+	subscribers        map[chan Update]struct{}
+	stopListen         context.CancelFunc
 }
 
 // NewConnection creates a new Sensonet device connection.
 func NewConnection(ebusdAddress string, opts ...ConnOption) (*Connection, error) {
+	return NewConnectionCtx(context.Background(), ebusdAddress, opts...)
+}
+
+// NewConnectionCtx creates a new Sensonet device connection. ctx bounds the initial connect to ebusd.
+func NewConnectionCtx(ctx context.Context, ebusdAddress string, opts ...ConnOption) (*Connection, error) {
 	conn := &Connection{}
+	conn.mu = newCtxMutex()
 	conn.currentQuickmode = ""
 	conn.quickmodeStarted = time.Now()
 	conn.quickModeExpiresAt = ""
@@ -27,11 +52,38 @@ func NewConnection(ebusdAddress string, opts ...ConnOption) (*Connection, error)
 		opt(conn)
 	}
 
+	transport := conn.transport
+	if transport == nil && conn.adapter {
+		adapterTransport, err := newAdapterTransport(ebusdAddress, conn.adapterMaster, conn.logger)
+		if err != nil {
+			return conn, err
+		}
+		transport = adapterTransport
+	}
+	if transport == nil && conn.httpAPI {
+		transport = newHTTPTransport(ebusdAddress, conn.httpClient, conn.logger)
+	}
+	if transport == nil {
+		transport = newTCPTransport(ebusdAddress, conn.logger)
+	}
+	if conn.recorder != nil {
+		transport = NewRecordingTransport(transport, conn.recorder)
+	}
+	if !conn.noNativeFallback {
+		transport = newNativeTransport(transport, conn.logger)
+	}
+	policy := DefaultRetryPolicy()
+	if conn.retryPolicy != nil {
+		policy = *conn.retryPolicy
+	}
+	conn.retry = newRetryTransport(transport, policy, conn.logger)
+	transport = conn.retry
+
 	var err error
 	if conn.logger != nil {
-		conn.ebusdConn, err = newEbusConnection(ebusdAddress, withConnLogger(conn.logger))
+		conn.ebusdConn, err = newEbusConnection(ctx, transport, withConnLogger(conn.logger))
 	} else {
-		conn.ebusdConn, err = newEbusConnection(ebusdAddress)
+		conn.ebusdConn, err = newEbusConnection(ctx, transport)
 	}
 	return conn, err
 }
@@ -42,29 +94,267 @@ func (c *Connection) debug(fmt string, arg ...any) {
 	}
 }
 
+// Close closes the session to ebusd and the listen session of the subscriptions
+func (c *Connection) Close() error {
+	c.subMu.Lock()
+	if c.stopListen != nil {
+		c.stopListen()
+		c.stopListen = nil
+	}
+	c.subMu.Unlock()
+	return c.ebusdConn.Close()
+}
+
 func (c *Connection) GetCurrentQuickMode() string {
+	_ = c.mu.Lock(context.Background())
+	defer c.mu.Unlock()
 	return c.currentQuickmode
 }
 
 func (c *Connection) GetQuickModeExpiresAt() string {
+	_ = c.mu.Lock(context.Background())
+	defer c.mu.Unlock()
 	return c.quickModeExpiresAt
 }
 
 func (c *Connection) GetSystem(refresh bool) (VaillantRelData, error) {
-	err := c.ebusdConn.getSystem(&c.relData, refresh)
+	return c.GetSystemCtx(context.Background(), refresh)
+}
+
+// GetSystemCtx is like GetSystem, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) GetSystemCtx(ctx context.Context, refresh bool) (VaillantRelData, error) {
+	err := c.mu.Lock(ctx)
+	if err != nil {
+		return VaillantRelData{}, err
+	}
+	defer c.mu.Unlock()
+	err = c.ebusdConn.getSystem(ctx, &c.relData, refresh)
 	c.refreshCurrentQuickMode()
 	return c.relData, err
 }
 
 func (c *Connection) CheckEbusdConfig() (string, error) {
-	details, err := c.ebusdConn.checkEbusdConfig()
+	return c.CheckEbusdConfigCtx(context.Background())
+}
+
+// CheckEbusdConfigCtx is like CheckEbusdConfig, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) CheckEbusdConfigCtx(ctx context.Context) (string, error) {
+	details, err := c.ebusdConn.checkEbusdConfig(ctx)
 	return details, err
 }
 
+// CheckEbusdConfigReport checks every element the package depends on and returns the results as a report,
+// e.g. to show a checklist of the ebusd configuration to the user
+func (c *Connection) CheckEbusdConfigReport() (ConfigReport, error) {
+	return c.CheckEbusdConfigReportCtx(context.Background())
+}
+
+// CheckEbusdConfigReportCtx is like CheckEbusdConfigReport, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) CheckEbusdConfigReportCtx(ctx context.Context) (ConfigReport, error) {
+	return c.ebusdConn.checkConfig(ctx)
+}
+
+// VerifyDefinitions compares the message definitions of the configuration files bundled in ebusd-config-files
+// with the ones loaded by ebusd and reports missing, extra and differently encoded messages per file.
+// Not supported with the HTTP transport.
+func (c *Connection) VerifyDefinitions() (DefinitionReport, error) {
+	return c.VerifyDefinitionsCtx(context.Background())
+}
+
+// VerifyDefinitionsCtx is like VerifyDefinitions, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) VerifyDefinitionsCtx(ctx context.Context) (DefinitionReport, error) {
+	return c.ebusdConn.verifyDefinitions(ctx)
+}
+
+// EbusdInfo returns the health of ebusd: version, adapter, bus signal, symbol rate and the addresses seen on the bus
+func (c *Connection) EbusdInfo() (EbusdInfo, error) {
+	return c.EbusdInfoCtx(context.Background())
+}
+
+// EbusdInfoCtx is like EbusdInfo, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) EbusdInfoCtx(ctx context.Context) (EbusdInfo, error) {
+	return c.ebusdConn.getEbusdInfo(ctx)
+}
+
+// Devices returns the devices on the bus found by the scan of ebusd, e.g. the controller (ID "CTLV2"),
+// the heat pump (ID "HMU00") and the VR921 gateway, with the circuit names ebusd uses for them.
+// The devices are determined when connecting to ebusd.
+func (c *Connection) Devices() []BusDevice {
+	return c.ebusdConn.getDevices()
+}
+
+// Hex sends a raw telegram with data to the slave zz and returns the data of the response (without length byte).
+// The command "hex" has to be enabled with the option --enablehex of ebusd. Not supported with the HTTP transport.
+func (c *Connection) Hex(zz byte, pbsb uint16, data []byte) ([]byte, error) {
+	return c.HexCtx(context.Background(), zz, pbsb, data)
+}
+
+// HexCtx is like Hex, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) HexCtx(ctx context.Context, zz byte, pbsb uint16, data []byte) ([]byte, error) {
+	reply, err := c.retry.Hex(ctx, hexTelegram(zz, pbsb, data))
+	if err != nil {
+		return nil, err
+	}
+	return parseHexReply(reply)
+}
+
+// ReadB524 reads a register of the VRC720 controller and returns its raw value
+func (c *Connection) ReadB524(register B524Register) ([]byte, error) {
+	return c.ReadB524Ctx(context.Background(), register)
+}
+
+// ReadB524Ctx is like ReadB524, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) ReadB524Ctx(ctx context.Context, register B524Register) ([]byte, error) {
+	data, err := c.HexCtx(ctx, VRC720_ADDRESS, B524_PBSB, register.ReadRequest())
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < B524_REPLY_HEADER {
+		return nil, fmt.Errorf("%w: reply %x to %s too short", ErrInvalidPosition, data, register)
+	}
+	return data[B524_REPLY_HEADER:], nil
+}
+
+// WriteB524 writes the raw value to a register of the VRC720 controller
+func (c *Connection) WriteB524(register B524Register, value []byte) error {
+	return c.WriteB524Ctx(context.Background(), register, value)
+}
+
+// WriteB524Ctx is like WriteB524, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) WriteB524Ctx(ctx context.Context, register B524Register, value []byte) error {
+	_, err := c.HexCtx(ctx, VRC720_ADDRESS, B524_PBSB, register.WriteRequest(value))
+	return err
+}
+
+// GetTimerProgram reads the time program of target (TIMER_HOTWATER, TIMER_CIRCULATION or ZoneTimer(zone))
+// from the VRC720
+func (c *Connection) GetTimerProgram(target TimerTarget) (WeeklySchedule, error) {
+	return c.GetTimerProgramCtx(context.Background(), target)
+}
+
+// GetTimerProgramCtx is like GetTimerProgram, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) GetTimerProgramCtx(ctx context.Context, target TimerTarget) (WeeklySchedule, error) {
+	return c.ebusdConn.getTimerProgram(ctx, target)
+}
+
+// SetTimerProgram writes the time slots of the days contained in schedule to the time program of target and
+// reads them back to confirm them. Days missing in schedule.Days are not changed, days with an empty slice get
+// no time slot. The schedule is checked with WeeklySchedule.Validate() before anything is written.
+func (c *Connection) SetTimerProgram(target TimerTarget, schedule WeeklySchedule) error {
+	return c.SetTimerProgramCtx(context.Background(), target, schedule)
+}
+
+// SetTimerProgramCtx is like SetTimerProgram, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) SetTimerProgramCtx(ctx context.Context, target TimerTarget, schedule WeeklySchedule) error {
+	err := c.mu.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+	schedule.Target = target
+	return c.ebusdConn.setTimerProgram(ctx, schedule)
+}
+
+// SetHoliday puts the hotwater and the zones into holiday mode from the day of from to the day of to (inclusively).
+// The zones are kept at the setpoint temp (HOLIDAY_MIN_TEMP to HOLIDAY_MAX_TEMP °C) meanwhile, the hotwater is off.
+// If no zones are given, the holiday period is set for all configured zones. Each value written is read back to
+// confirm it. If a target could not be written, a *HolidayError listing the written and failed targets is returned.
+func (c *Connection) SetHoliday(from, to time.Time, temp float64, zones ...int) error {
+	return c.SetHolidayCtx(context.Background(), from, to, temp, zones...)
+}
+
+// SetHolidayCtx is like SetHoliday, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) SetHolidayCtx(ctx context.Context, from, to time.Time, temp float64, zones ...int) error {
+	err := c.mu.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+	err = c.ebusdConn.setHoliday(ctx, from, to, temp, zones)
+	c.relData.LastGetSystem = time.Time{} // reset the cache
+	return err
+}
+
+// ClearHoliday ends the holiday periods of the hotwater and of all configured zones. Errors are returned like by
+// SetHoliday().
+func (c *Connection) ClearHoliday() error {
+	return c.ClearHolidayCtx(context.Background())
+}
+
+// ClearHolidayCtx is like ClearHoliday, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) ClearHolidayCtx(ctx context.Context) error {
+	err := c.mu.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+	err = c.ebusdConn.clearHoliday(ctx)
+	c.relData.LastGetSystem = time.Time{} // reset the cache
+	return err
+}
+
+// BreakerStatus returns the state of the circuit breaker of the retry policy
+func (c *Connection) BreakerStatus() BreakerStatus {
+	return c.retry.status()
+}
+
+// Zones returns the indices of the zones configured in the heating system, e.g. [1 2].
+// The zones are detected when connecting to ebusd and by RefreshZones().
+func (c *Connection) Zones() []int {
+	return c.ebusdConn.getZones()
+}
+
+// RefreshZones detects the configured zones again, e.g. after a zone was added to the heating system.
+// The next call of GetSystem() reads the data of the detected zones.
+func (c *Connection) RefreshZones() ([]int, error) {
+	return c.RefreshZonesCtx(context.Background())
+}
+
+// RefreshZonesCtx is like RefreshZones, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) RefreshZonesCtx(ctx context.Context) ([]int, error) {
+	zones, err := c.ebusdConn.refreshZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = c.mu.Lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer c.mu.Unlock()
+	c.relData.LastGetSystem = time.Time{}
+	return zones, nil
+}
+
+// ReadMany reads the elements refs from ebusd in one batch and returns one result per ref in the order of refs.
+// ERR: replies of ebusd are returned per element, the returned error is set if the session to ebusd failed.
+func (c *Connection) ReadMany(refs []ElementRef) ([]ReadResult, error) {
+	return c.ReadManyCtx(context.Background(), refs)
+}
+
+// ReadManyCtx is like ReadMany, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) ReadManyCtx(ctx context.Context, refs []ElementRef) ([]ReadResult, error) {
+	return ReadMany(ctx, c.ebusdConn.transport, refs)
+}
+
 func (c *Connection) StartZoneQuickVeto(zone int, setpoint float32, duration float32) error {
-	if zone < 0 {
-		zone = ZONEINDEX_DEFAULT
-	} // if parameter "zone" is negative, then the default value is used
+	return c.StartZoneQuickVetoCtx(context.Background(), zone, setpoint, duration)
+}
+
+// StartZoneQuickVetoCtx is like StartZoneQuickVeto, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) StartZoneQuickVetoCtx(ctx context.Context, zone int, setpoint float32, duration float32) error {
+	err := c.mu.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+	return c.startZoneQuickVeto(ctx, zone, setpoint, duration)
+}
+
+func (c *Connection) startZoneQuickVeto(ctx context.Context, zone int, setpoint float32, duration float32) error {
+	zone, err := c.zoneIndex(zone)
+	if err != nil {
+		return err
+	}
 	if setpoint < 0.0 {
 		setpoint = ZONEVETOSETPOINT_DEFAULT
 	} // if parameter "setpoint" is negative, then the default value is used
@@ -73,15 +363,13 @@ func (c *Connection) StartZoneQuickVeto(zone int, setpoint float32, duration flo
 	} // if parameter "duration" is negative, then the default value is used
 
 	zonePrefix := fmt.Sprintf("z%01d", zone)
-	message := " -c " + c.ebusdConn.controllerForSFMode + " " + zonePrefix + EBUSDREAD_ZONE_QUICKVETOTEMP + fmt.Sprintf(" %2.1f", setpoint)
-	err := c.ebusdConn.ebusdWrite(message)
+	err = c.ebusdConn.ebusdWrite(ctx, c.ebusdConn.controllerForSFMode, zonePrefix+EBUSDREAD_ZONE_QUICKVETOTEMP, fmt.Sprintf("%2.1f", setpoint))
 	if err != nil {
 		c.debug("could not start zone quick veto. Error: %s", err)
 		return err
 	}
 	// Zone quick veto is started by writing a duration to the controler. A duration of 0.5 hours is set.
-	message = " -c " + c.ebusdConn.controllerForSFMode + " " + zonePrefix + EBUSDREAD_ZONE_QUICKVETODURATION + fmt.Sprintf(" %2.1f", duration)
-	err = c.ebusdConn.ebusdWrite(message)
+	err = c.ebusdConn.ebusdWrite(ctx, c.ebusdConn.controllerForSFMode, zonePrefix+EBUSDREAD_ZONE_QUICKVETODURATION, fmt.Sprintf("%2.1f", duration))
 	if err != nil {
 		c.debug(fmt.Sprintf("could not start zone quick veto. Error: %s", err))
 		return err
@@ -91,13 +379,27 @@ func (c *Connection) StartZoneQuickVeto(zone int, setpoint float32, duration flo
 }
 
 func (c *Connection) StopZoneQuickVeto(zone int) error {
-	if zone < 0 {
-		zone = ZONEINDEX_DEFAULT
-	} // if parameter "zone" is negative, then the default value is used
+	return c.StopZoneQuickVetoCtx(context.Background(), zone)
+}
+
+// StopZoneQuickVetoCtx is like StopZoneQuickVeto, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) StopZoneQuickVetoCtx(ctx context.Context, zone int) error {
+	err := c.mu.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+	return c.stopZoneQuickVeto(ctx, zone)
+}
+
+func (c *Connection) stopZoneQuickVeto(ctx context.Context, zone int) error {
+	zone, err := c.zoneIndex(zone)
+	if err != nil {
+		return err
+	}
 
 	zonePrefix := fmt.Sprintf("z%01d", zone)
-	message := " -c " + c.ebusdConn.controllerForSFMode + " " + zonePrefix + EBUSDREAD_ZONE_SFMODE + " auto"
-	err := c.ebusdConn.ebusdWrite(message)
+	err = c.ebusdConn.ebusdWrite(ctx, c.ebusdConn.controllerForSFMode, zonePrefix+EBUSDREAD_ZONE_SFMODE, ZONE_SFMODE_NORMAL)
 	if err != nil {
 		c.debug(fmt.Sprintf("could not stop zone quick veto. Error: %s", err))
 		return err
@@ -106,9 +408,148 @@ func (c *Connection) StopZoneQuickVeto(zone int) error {
 	return err
 }
 
+// SetZoneOpMode sets the operation mode of the zone to OPERATIONMODE_OFF, OPERATIONMODE_AUTO or OPERATIONMODE_DAY
+// and reads it back to confirm it
+func (c *Connection) SetZoneOpMode(zone int, opMode string) error {
+	return c.SetZoneOpModeCtx(context.Background(), zone, opMode)
+}
+
+// SetZoneOpModeCtx is like SetZoneOpMode, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) SetZoneOpModeCtx(ctx context.Context, zone int, opMode string) error {
+	if !slices.Contains([]string{OPERATIONMODE_OFF, OPERATIONMODE_AUTO, OPERATIONMODE_DAY}, opMode) {
+		return fmt.Errorf("%w: operation mode %q", ErrInvalidParameter, opMode)
+	}
+	err := c.mu.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+	return c.setZoneValue(ctx, zone, EBUSDREAD_ZONE_OPMODE, opMode, sameString(opMode))
+}
+
+// SetZoneDayTemp sets the day setpoint of the zone (ZONE_MIN_TEMP to ZONE_MAX_TEMP °C) and reads it back to confirm it
+func (c *Connection) SetZoneDayTemp(zone int, temp float64) error {
+	return c.SetZoneDayTempCtx(context.Background(), zone, temp)
+}
+
+// SetZoneDayTempCtx is like SetZoneDayTemp, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) SetZoneDayTempCtx(ctx context.Context, zone int, temp float64) error {
+	return c.setZoneTemp(ctx, zone, EBUSDREAD_ZONE_DAYTEMP, temp)
+}
+
+// SetZoneNightTemp sets the night setpoint of the zone (ZONE_MIN_TEMP to ZONE_MAX_TEMP °C) and reads it back to confirm it
+func (c *Connection) SetZoneNightTemp(zone int, temp float64) error {
+	return c.SetZoneNightTempCtx(context.Background(), zone, temp)
+}
+
+// SetZoneNightTempCtx is like SetZoneNightTemp, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) SetZoneNightTempCtx(ctx context.Context, zone int, temp float64) error {
+	return c.setZoneTemp(ctx, zone, EBUSDREAD_ZONE_NIGHTTEMP, temp)
+}
+
+func (c *Connection) setZoneTemp(ctx context.Context, zone int, suffix string, temp float64) error {
+	if temp < ZONE_MIN_TEMP || temp > ZONE_MAX_TEMP {
+		return fmt.Errorf("%w: setpoint %.1f not in range [%.1f,%.1f]", ErrParameterOutOfRange, temp, ZONE_MIN_TEMP, ZONE_MAX_TEMP)
+	}
+	err := c.mu.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+	return c.setZoneValue(ctx, zone, suffix, fmt.Sprintf("%2.1f", temp), sameFloat(temp))
+}
+
+// zoneIndex returns the zone addressed by the zone functions. A negative zone or ZONEINDEX_DEFAULT selects the
+// first configured zone, other zones have to be configured.
+func (c *Connection) zoneIndex(zone int) (int, error) {
+	zones := c.ebusdConn.getZones()
+	if (zone < 0 || zone == ZONEINDEX_DEFAULT) && len(zones) > 0 {
+		zone = zones[0]
+	}
+	if !slices.Contains(zones, zone) {
+		return zone, fmt.Errorf("%w: zone %d is not configured", ErrInvalidParameter, zone)
+	}
+	return zone, nil
+}
+
+// setZoneValue writes value to the element of the zone and reads it back. The cache of the system data is reset.
+func (c *Connection) setZoneValue(ctx context.Context, zone int, suffix, value string, confirmed func(string) bool) error {
+	zone, err := c.zoneIndex(zone)
+	if err != nil {
+		return err
+	}
+	zonePrefix := fmt.Sprintf("z%01d", zone)
+	err = c.ebusdConn.ebusdWriteConfirmed(ctx, c.ebusdConn.controllerForSFMode, zonePrefix+suffix, value, confirmed)
+	if err != nil {
+		c.debug(fmt.Sprintf("could not set %s of zone %d. Error: %s", suffix, zone, err))
+	}
+	c.relData.LastGetSystem = time.Time{} // reset the cache
+	return err
+}
+
+// SetHotwaterTempDesired sets the hotwater setpoint (HWC_MIN_TEMP to HWC_MAX_TEMP °C) and reads it back to confirm it
+func (c *Connection) SetHotwaterTempDesired(temp float64) error {
+	return c.SetHotwaterTempDesiredCtx(context.Background(), temp)
+}
+
+// SetHotwaterTempDesiredCtx is like SetHotwaterTempDesired, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) SetHotwaterTempDesiredCtx(ctx context.Context, temp float64) error {
+	if temp < HWC_MIN_TEMP || temp > HWC_MAX_TEMP {
+		return fmt.Errorf("%w: hotwater setpoint %.1f not in range [%.1f,%.1f]", ErrParameterOutOfRange, temp, HWC_MIN_TEMP, HWC_MAX_TEMP)
+	}
+	err := c.mu.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+	return c.setHotwaterValue(ctx, EBUSDREAD_HOTWATER_TEMPDESIRED, fmt.Sprintf("%2.1f", temp), sameFloat(temp))
+}
+
+// SetHotwaterOpMode sets the operation mode of the hotwater to OPERATIONMODE_OFF, OPERATIONMODE_AUTO or OPERATIONMODE_DAY
+// and reads it back to confirm it
+func (c *Connection) SetHotwaterOpMode(opMode string) error {
+	return c.SetHotwaterOpModeCtx(context.Background(), opMode)
+}
+
+// SetHotwaterOpModeCtx is like SetHotwaterOpMode, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) SetHotwaterOpModeCtx(ctx context.Context, opMode string) error {
+	if !slices.Contains([]string{OPERATIONMODE_OFF, OPERATIONMODE_AUTO, OPERATIONMODE_DAY}, opMode) {
+		return fmt.Errorf("%w: operation mode %q", ErrInvalidParameter, opMode)
+	}
+	err := c.mu.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+	return c.setHotwaterValue(ctx, EBUSDREAD_HOTWATER_OPMODE, opMode, sameString(opMode))
+}
+
+// setHotwaterValue writes value to the hotwater element name and reads it back. The cache of the system data is reset.
+func (c *Connection) setHotwaterValue(ctx context.Context, name, value string, confirmed func(string) bool) error {
+	err := c.ebusdConn.ebusdWriteConfirmed(ctx, c.ebusdConn.controllerForSFMode, name, value, confirmed)
+	if err != nil {
+		c.debug(fmt.Sprintf("could not set %s. Error: %s", name, err))
+	}
+	c.relData.LastGetSystem = time.Time{} // reset the cache
+	return err
+}
+
 func (c *Connection) StartHotWaterBoost() error {
-	message := " -c " + c.ebusdConn.controllerForSFMode + " " + EBUSDREAD_HOTWATER_SFMODE + " load"
-	err := c.ebusdConn.ebusdWrite(message)
+	return c.StartHotWaterBoostCtx(context.Background())
+}
+
+// StartHotWaterBoostCtx is like StartHotWaterBoost, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) StartHotWaterBoostCtx(ctx context.Context) error {
+	err := c.mu.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+	return c.startHotWaterBoost(ctx)
+}
+
+func (c *Connection) startHotWaterBoost(ctx context.Context) error {
+	err := c.ebusdConn.ebusdWrite(ctx, c.ebusdConn.controllerForSFMode, EBUSDREAD_HOTWATER_SFMODE, HWC_SFMODE_BOOST)
 	if err != nil {
 		c.debug(fmt.Sprintf("could not start hotwater boost. Error: %s", err))
 	}
@@ -117,8 +558,21 @@ func (c *Connection) StartHotWaterBoost() error {
 }
 
 func (c *Connection) StopHotWaterBoost() error {
-	message := " -c " + c.ebusdConn.controllerForSFMode + " " + EBUSDREAD_HOTWATER_SFMODE + " auto"
-	err := c.ebusdConn.ebusdWrite(message)
+	return c.StopHotWaterBoostCtx(context.Background())
+}
+
+// StopHotWaterBoostCtx is like StopHotWaterBoost, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) StopHotWaterBoostCtx(ctx context.Context) error {
+	err := c.mu.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.mu.Unlock()
+	return c.stopHotWaterBoost(ctx)
+}
+
+func (c *Connection) stopHotWaterBoost(ctx context.Context) error {
+	err := c.ebusdConn.ebusdWrite(ctx, c.ebusdConn.controllerForSFMode, EBUSDREAD_HOTWATER_SFMODE, HWC_SFMODE_NORMAL)
 	if err != nil {
 		c.debug(fmt.Sprintf("could not start hotwater boost. Error: %s", err))
 	}
@@ -156,7 +610,21 @@ func (c *Connection) refreshCurrentQuickMode() {
 }
 
 func (c *Connection) StartStrategybased(strategy int, heatingPar *HeatingParStruct) (string, error) {
-	err := c.ebusdConn.getSystem(&c.relData, true)
+	return c.StartStrategybasedCtx(context.Background(), strategy, heatingPar)
+}
+
+// StartStrategybasedCtx is like StartStrategybased, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) StartStrategybasedCtx(ctx context.Context, strategy int, heatingPar *HeatingParStruct) (string, error) {
+	err := c.mu.Lock(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer c.mu.Unlock()
+	return c.startStrategybased(ctx, strategy, heatingPar)
+}
+
+func (c *Connection) startStrategybased(ctx context.Context, strategy int, heatingPar *HeatingParStruct) (string, error) {
+	err := c.ebusdConn.getSystem(ctx, &c.relData, true)
 	if err != nil {
 		err = fmt.Errorf("could not read current status information in StartStrategybased(): %s", err)
 		return "", err
@@ -165,6 +633,9 @@ func (c *Connection) StartStrategybased(strategy int, heatingPar *HeatingParStru
 
 	// Extracting correct Zones element
 	zoneData := GetZoneData(c.relData.Zones, heatingPar.ZoneIndex)
+	if zoneData == nil {
+		return "", fmt.Errorf("%w: zone %d not detected", ErrInvalidParameter, heatingPar.ZoneIndex)
+	}
 	if c.currentQuickmode != "" {
 		c.debug(fmt.Sprint("System is already in quick mode:", c.currentQuickmode))
 		c.debug("Is there any need to change that?")
@@ -172,12 +643,12 @@ func (c *Connection) StartStrategybased(strategy int, heatingPar *HeatingParStru
 		c.debug(fmt.Sprint("Special Function of Heating Zone: ", zoneData.SFMode))
 		return QUICKMODE_ERROR_ALREADYON, err
 	}
-	whichQuickMode := c.WhichQuickMode(strategy, heatingPar.ZoneIndex)
+	whichQuickMode := c.whichQuickMode(strategy, heatingPar.ZoneIndex)
 	c.debug(fmt.Sprint("whichQuickMode=", whichQuickMode))
 
 	switch whichQuickMode {
 	case 1:
-		err = c.StartHotWaterBoost()
+		err = c.startHotWaterBoost(ctx)
 		if err == nil {
 			c.currentQuickmode = QUICKMODE_HOTWATER
 			c.quickmodeStarted = time.Now()
@@ -185,7 +656,7 @@ func (c *Connection) StartStrategybased(strategy int, heatingPar *HeatingParStru
 			c.quickModeExpiresAt = ""
 		}
 	case 2:
-		err = c.StartZoneQuickVeto(heatingPar.ZoneIndex, heatingPar.VetoSetpoint, heatingPar.VetoDuration)
+		err = c.startZoneQuickVeto(ctx, heatingPar.ZoneIndex, heatingPar.VetoSetpoint, heatingPar.VetoDuration)
 		if err == nil {
 			c.currentQuickmode = QUICKMODE_HEATING
 			c.quickmodeStarted = time.Now()
@@ -199,14 +670,14 @@ func (c *Connection) StartStrategybased(strategy int, heatingPar *HeatingParStru
 	default:
 		if c.currentQuickmode == QUICKMODE_HOTWATER {
 			// if hotwater boost active, then stop it
-			err = c.StopHotWaterBoost()
+			err = c.stopHotWaterBoost(ctx)
 			if err == nil {
 				c.debug("Stopping hotwater boost")
 			}
 		}
 		if c.currentQuickmode == QUICKMODE_HEATING {
 			// if zone quick veto active, then stop it
-			err = c.StopZoneQuickVeto(heatingPar.ZoneIndex)
+			err = c.stopZoneQuickVeto(ctx, heatingPar.ZoneIndex)
 			if err == nil {
 				c.debug("Stopping zone quick veto")
 			}
@@ -222,7 +693,21 @@ func (c *Connection) StartStrategybased(strategy int, heatingPar *HeatingParStru
 }
 
 func (c *Connection) StopStrategybased(heatingPar *HeatingParStruct) (string, error) {
-	err := c.ebusdConn.getSystem(&c.relData, true)
+	return c.StopStrategybasedCtx(context.Background(), heatingPar)
+}
+
+// StopStrategybasedCtx is like StopStrategybased, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) StopStrategybasedCtx(ctx context.Context, heatingPar *HeatingParStruct) (string, error) {
+	err := c.mu.Lock(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer c.mu.Unlock()
+	return c.stopStrategybased(ctx, heatingPar)
+}
+
+func (c *Connection) stopStrategybased(ctx context.Context, heatingPar *HeatingParStruct) (string, error) {
+	err := c.ebusdConn.getSystem(ctx, &c.relData, true)
 	if err != nil {
 		err = fmt.Errorf("could not read current status information in StopStrategybased(): %s", err)
 		return "", err
@@ -231,16 +716,19 @@ func (c *Connection) StopStrategybased(heatingPar *HeatingParStruct) (string, er
 
 	// Extracting correct Zones element
 	zoneData := GetZoneData(c.relData.Zones, heatingPar.ZoneIndex)
+	if zoneData == nil {
+		return "", fmt.Errorf("%w: zone %d not detected", ErrInvalidParameter, heatingPar.ZoneIndex)
+	}
 	c.debug(fmt.Sprint("Operationg Mode of Dhw: ", c.relData.Hotwater.HwcSFMode))
 	c.debug(fmt.Sprint("Operationg Mode of Heating: ", zoneData.SFMode))
 	switch c.currentQuickmode {
 	case QUICKMODE_HOTWATER:
-		err = c.StopHotWaterBoost()
+		err = c.stopHotWaterBoost(ctx)
 		if err == nil {
 			c.debug(fmt.Sprint("Stopping quick mode", c.currentQuickmode))
 		}
 	case QUICKMODE_HEATING:
-		err = c.StopZoneQuickVeto(heatingPar.ZoneIndex)
+		err = c.stopZoneQuickVeto(ctx, heatingPar.ZoneIndex)
 		if err == nil {
 			c.debug("Stopping zone quick veto")
 		}
@@ -260,6 +748,12 @@ func (c *Connection) StopStrategybased(heatingPar *HeatingParStruct) (string, er
 // This function checks the operation mode of heating and hotwater and the hotwater live temperature
 // and returns, which quick mode should be started, when StartStrategybased() is called
 func (c *Connection) WhichQuickMode(strategy, heatingZone int) int {
+	_ = c.mu.Lock(context.Background())
+	defer c.mu.Unlock()
+	return c.whichQuickMode(strategy, heatingZone)
+}
+
+func (c *Connection) whichQuickMode(strategy, heatingZone int) int {
 	/*err := c.ebusdConn.getSystem(&c.relData, false)
 	if err != nil {
 		err = fmt.Errorf("could not read current status information in WhichQuickMode(): %s", err)
@@ -280,12 +774,10 @@ func (c *Connection) WhichQuickMode(strategy, heatingZone int) int {
 	}
 
 	heatingQuickVetoPossible := false
-	for _, z := range c.relData.Zones {
-		if z.Index == heatingZone {
-			c.debug(fmt.Sprintf("Checking if heating quick veto possible. Operation Mode = %s", z.OpMode))
-			if z.OpMode == OPERATIONMODE_AUTO {
-				heatingQuickVetoPossible = true
-			}
+	if z := GetZoneData(c.relData.Zones, heatingZone); z != nil {
+		c.debug(fmt.Sprintf("Checking if heating quick veto possible. Operation Mode = %s", z.OpMode))
+		if z.OpMode == OPERATIONMODE_AUTO {
+			heatingQuickVetoPossible = true
 		}
 	}
 
@@ -338,7 +830,12 @@ func (c *Connection) WhichQuickMode(strategy, heatingZone int) int {
 
 // Returns the current power consumption for systemId
 func (c *Connection) GetSystemCurrentPower() (float64, error) {
-	state, err := c.GetSystem(false)
+	return c.GetSystemCurrentPowerCtx(context.Background())
+}
+
+// GetSystemCurrentPowerCtx is like GetSystemCurrentPower, but returns ctx.Err() as soon as ctx is done
+func (c *Connection) GetSystemCurrentPowerCtx(ctx context.Context) (float64, error) {
+	state, err := c.GetSystemCtx(ctx, false)
 	if err != nil {
 		return -1.0, err
 	}
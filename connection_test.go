@@ -0,0 +1,673 @@
+package sensonetEbus
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/WulfgarW/sensonetEbus/ebusdtest"
+	"golang.org/x/exp/slices"
+)
+
+// testRetryPolicy is DefaultRetryPolicy() with short wait times, so that retried commands do not slow down the tests
+func testRetryPolicy() RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = 5 * time.Millisecond
+	return policy
+}
+
+// newTestSystem starts a fake ebusd with a Vaillant system of two zones and connects to it
+func newTestSystem(t *testing.T, opts ...ConnOption) (*ebusdtest.Server, *Connection) {
+	t.Helper()
+	server, err := ebusdtest.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() failed: %s", err)
+	}
+	t.Cleanup(func() { server.Close() })
+	server.PopulateVaillantSystem(2)
+
+	opts = append([]ConnOption{WithRetryPolicy(testRetryPolicy())}, opts...)
+	conn, err := NewConnection(server.Addr(), opts...)
+	if err != nil {
+		t.Fatalf("NewConnection() failed: %s", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return server, conn
+}
+
+func TestGetSystem(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(server *ebusdtest.Server)
+		check func(t *testing.T, relData VaillantRelData)
+	}{
+		{
+			name: "populated system",
+			check: func(t *testing.T, relData VaillantRelData) {
+				if relData.Hotwater.HwcOpMode != "auto" || relData.Hotwater.HwcTempDesired != 50.0 ||
+					relData.Hotwater.HwcStorageTemp != 44.5 || relData.Hotwater.HwcSFMode != "auto" {
+					t.Errorf("Hotwater = %+v", relData.Hotwater)
+				}
+				if relData.Status.Time != "10:15:30;18.10.2026" || relData.Status.OutsideTemperature != 8.5 ||
+					relData.Status.SystemFlowTemperature != 37.25 || relData.Status.WaterPressure != 1.8 ||
+					relData.Status.CurrentConsumedPower != 1.2 || relData.Status.State != "heating" {
+					t.Errorf("Status = %+v", relData.Status)
+				}
+				if len(relData.Zones) != 2 {
+					t.Fatalf("%d zones read, expected 2", len(relData.Zones))
+				}
+				for i, zone := range relData.Zones {
+					if zone.Index != i+1 || zone.OpMode != "auto" || zone.SFMode != "auto" ||
+						zone.ActualRoomTempDesired != 20.0 || zone.RoomTemp != 20.5 || zone.QuickVetoTemp != 21.0 {
+						t.Errorf("Zones[%d] = %+v", i, zone)
+					}
+				}
+				if len(relData.HeatCircuits) != 1 || relData.HeatCircuits[0].CircuitType != "mixer" ||
+					relData.HeatCircuits[0].FlowTemp != 34.875 {
+					t.Errorf("HeatCircuits = %+v", relData.HeatCircuits)
+				}
+				if relData.LastGetSystem.IsZero() {
+					t.Errorf("LastGetSystem not set")
+				}
+			},
+		},
+		{
+			name: "power elements not defined",
+			setup: func(server *ebusdtest.Server) {
+				server.Delete("hmu", EBUSDREAD_STATUS_CURRENTCONSUMEDPOWER)
+				server.Delete("vwz00", EBUSDREAD_STATUS_IMMERSIONHEATERPOWER)
+			},
+			check: func(t *testing.T, relData VaillantRelData) {
+				if relData.Status.CurrentConsumedPower != 0.0 || relData.Status.ImmersionHeaterPower != 0.0 {
+					t.Errorf("CurrentConsumedPower = %f, ImmersionHeaterPower = %f, expected 0.0",
+						relData.Status.CurrentConsumedPower, relData.Status.ImmersionHeaterPower)
+				}
+				if relData.Status.State != "heating" {
+					t.Errorf("State = %q, expected the elements after the power elements to be read", relData.Status.State)
+				}
+			},
+		},
+		{
+			name: "invalid values ignored",
+			setup: func(server *ebusdtest.Server) {
+				server.Set(ebusdtest.DEFAULT_CONTROLLER_NAME, EBUSDREAD_HOTWATER_OPMODE, "party")
+				server.Set(ebusdtest.DEFAULT_CONTROLLER_NAME, EBUSDREAD_HOTWATER_TEMPDESIRED, "99.0")
+				server.Set(ebusdtest.DEFAULT_CONTROLLER_NAME, "z1"+EBUSDREAD_ZONE_SFMODE, "-")
+			},
+			check: func(t *testing.T, relData VaillantRelData) {
+				if relData.Hotwater.HwcOpMode != "" || relData.Hotwater.HwcTempDesired != 0.0 {
+					t.Errorf("Hotwater = %+v, expected the invalid values to be ignored", relData.Hotwater)
+				}
+				if len(relData.Zones) != 2 || relData.Zones[0].SFMode != "" || relData.Zones[0].OpMode != "auto" {
+					t.Errorf("Zones = %+v, expected only z1SFMode to be ignored", relData.Zones)
+				}
+			},
+		},
+		{
+			name: "ERR: reply of the last status element ignored",
+			setup: func(server *ebusdtest.Server) {
+				server.SetError(EBUSDREAD_STATUS_STATE, ebusdtest.REPLY_NOSIGNAL)
+			},
+			check: func(t *testing.T, relData VaillantRelData) {
+				if len(relData.Zones) != 2 || relData.Zones[0].OpMode != "auto" || relData.Zones[1].ShortName != "ZONE2" {
+					t.Errorf("Zones = %+v, expected the zones to be read", relData.Zones)
+				}
+				if len(relData.HeatCircuits) != 1 || relData.HeatCircuits[0].CircuitType != "mixer" {
+					t.Errorf("HeatCircuits = %+v, expected the heat circuits to be read", relData.HeatCircuits)
+				}
+			},
+		},
+		{
+			name: "ERR: reply of an element ignored",
+			setup: func(server *ebusdtest.Server) {
+				server.SetError(EBUSDREAD_HOTWATER_STORAGETEMP, ebusdtest.REPLY_READTIMEOUT)
+			},
+			check: func(t *testing.T, relData VaillantRelData) {
+				if relData.Hotwater.HwcStorageTemp != 0.0 || relData.Hotwater.HwcTempDesired != 50.0 {
+					t.Errorf("Hotwater = %+v, expected only HwcStorageTemp to be missing", relData.Hotwater)
+				}
+				if len(relData.Zones) != 2 || relData.Zones[1].RoomTemp != 20.5 {
+					t.Errorf("Zones = %+v, expected the zones to be read", relData.Zones)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, conn := newTestSystem(t)
+			if tt.setup != nil {
+				tt.setup(server)
+			}
+			relData, err := conn.GetSystem(true)
+			if err != nil {
+				t.Fatalf("GetSystem() failed: %s", err)
+			}
+			tt.check(t, relData)
+		})
+	}
+}
+
+func TestGetSystemError(t *testing.T) {
+	server, conn := newTestSystem(t)
+	server.DropNext(10)
+	relData, err := conn.GetSystem(true)
+	if err == nil {
+		t.Fatalf("GetSystem() succeeded, although ebusd dropped the connection")
+	}
+	if !relData.LastGetSystem.IsZero() {
+		t.Errorf("LastGetSystem set by failed GetSystem()")
+	}
+
+	server.DropNext(0)
+	relData, err = conn.GetSystem(false)
+	if err != nil {
+		t.Fatalf("GetSystem() after failed GetSystem() failed: %s", err)
+	}
+	if relData.LastGetSystem.IsZero() || relData.Hotwater.HwcTempDesired != 50.0 {
+		t.Errorf("GetSystem() used the cache of the failed GetSystem(): %+v", relData.Hotwater)
+	}
+}
+
+func TestStrategybased(t *testing.T) {
+	tests := []struct {
+		name        string
+		strategy    int
+		storageTemp string
+		zoneOpMode  string
+		quickMode   string
+		element     string // element written when starting the quick mode
+		value       string
+		active      string // value of SFMode element set by the controller while the quick mode is running
+	}{
+		{"hotwater", STRATEGY_HOTWATER, "44.5", "auto", QUICKMODE_HOTWATER, EBUSDREAD_HOTWATER_SFMODE, HWC_SFMODE_BOOST, ""},
+		{"hotwater not possible", STRATEGY_HOTWATER, "50.0", "auto", QUICKMODE_NOTHING, "", "", ""},
+		{"heating", STRATEGY_HEATING, "44.5", "auto", QUICKMODE_HEATING, "z1" + EBUSDREAD_ZONE_QUICKVETODURATION, "0.5", "z1" + EBUSDREAD_ZONE_SFMODE},
+		{"heating not possible", STRATEGY_HEATING, "44.5", "off", QUICKMODE_NOTHING, "", "", ""},
+		{"hotwater then heating, hotwater", STRATEGY_HOTWATER_THEN_HEATING, "44.5", "auto", QUICKMODE_HOTWATER, EBUSDREAD_HOTWATER_SFMODE, HWC_SFMODE_BOOST, ""},
+		{"hotwater then heating, heating", STRATEGY_HOTWATER_THEN_HEATING, "46.0", "auto", QUICKMODE_HEATING, "z1" + EBUSDREAD_ZONE_QUICKVETODURATION, "0.5", "z1" + EBUSDREAD_ZONE_SFMODE},
+		{"none", STRATEGY_NONE, "44.5", "auto", QUICKMODE_NOTHING, "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, conn := newTestSystem(t)
+			server.Set(ebusdtest.DEFAULT_CONTROLLER_NAME, EBUSDREAD_HOTWATER_STORAGETEMP, tt.storageTemp)
+			server.Set(ebusdtest.DEFAULT_CONTROLLER_NAME, "z1"+EBUSDREAD_ZONE_OPMODE, tt.zoneOpMode)
+			heatingPar := &HeatingParStruct{ZoneIndex: 1, VetoSetpoint: 22.0, VetoDuration: 0.5}
+
+			quickMode, err := conn.StartStrategybased(tt.strategy, heatingPar)
+			if err != nil {
+				t.Fatalf("StartStrategybased() failed: %s", err)
+			}
+			if quickMode != tt.quickMode {
+				t.Fatalf("StartStrategybased() = %q, expected %q", quickMode, tt.quickMode)
+			}
+			if tt.element != "" {
+				if value, _ := server.Value(ebusdtest.DEFAULT_CONTROLLER_NAME, tt.element); value != tt.value {
+					t.Errorf("%s = %q after StartStrategybased(), expected %q", tt.element, value, tt.value)
+				}
+			}
+			if tt.active != "" {
+				server.Set(ebusdtest.DEFAULT_CONTROLLER_NAME, tt.active, ZONE_SFMODE_BOOST)
+			}
+			if quickMode != QUICKMODE_NOTHING {
+				again, err := conn.StartStrategybased(tt.strategy, heatingPar)
+				if err != nil || again != QUICKMODE_ERROR_ALREADYON {
+					t.Errorf("second StartStrategybased() = %q, %v, expected %q", again, err, QUICKMODE_ERROR_ALREADYON)
+				}
+			}
+
+			quickMode, err = conn.StopStrategybased(heatingPar)
+			if err != nil || quickMode != "" {
+				t.Fatalf("StopStrategybased() = %q, %v, expected \"\", nil", quickMode, err)
+			}
+			for _, name := range []string{EBUSDREAD_HOTWATER_SFMODE, "z1" + EBUSDREAD_ZONE_SFMODE} {
+				if value, _ := server.Value(ebusdtest.DEFAULT_CONTROLLER_NAME, name); value != "auto" {
+					t.Errorf("%s = %q after StopStrategybased(), expected \"auto\"", name, value)
+				}
+			}
+			if conn.GetCurrentQuickMode() != "" {
+				t.Errorf("GetCurrentQuickMode() = %q after StopStrategybased()", conn.GetCurrentQuickMode())
+			}
+		})
+	}
+}
+
+func TestStrategybasedDefaultZone(t *testing.T) {
+	for _, zone := range []int{ZONEINDEX_DEFAULT, -1} {
+		t.Run(fmt.Sprintf("zone %d", zone), func(t *testing.T) {
+			server, conn := newTestSystem(t)
+			heatingPar := &HeatingParStruct{ZoneIndex: zone, VetoSetpoint: 22.0, VetoDuration: 1.5}
+			quickMode, err := conn.StartStrategybased(STRATEGY_HEATING, heatingPar)
+			if err != nil || quickMode != QUICKMODE_HEATING {
+				t.Fatalf("StartStrategybased() = %q, %v, expected %q", quickMode, err, QUICKMODE_HEATING)
+			}
+			if value, _ := server.Value(ebusdtest.DEFAULT_CONTROLLER_NAME, "z1"+EBUSDREAD_ZONE_QUICKVETODURATION); value != "1.5" {
+				t.Errorf("z1%s = %q, expected \"1.5\"", EBUSDREAD_ZONE_QUICKVETODURATION, value)
+			}
+		})
+	}
+}
+
+func TestStrategybasedUndetectedZone(t *testing.T) {
+	tests := []struct {
+		name  string
+		zones int // zones left configured on the fake ebusd
+		zone  int
+	}{
+		{"zone not detected", 2, 3},
+		{"no zones detected", 0, 1},
+		{"default zone without zones", 0, ZONEINDEX_DEFAULT},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, conn := newTestSystem(t)
+			for zone := tt.zones + 1; zone <= 2; zone++ {
+				server.Delete(ebusdtest.DEFAULT_CONTROLLER_NAME, fmt.Sprintf("z%d%s", zone, EBUSDREAD_ZONE_SHORTNAME))
+			}
+			if zones, err := conn.RefreshZones(); err != nil || len(zones) != tt.zones {
+				t.Fatalf("RefreshZones() = %v, %v, expected %d zones", zones, err, tt.zones)
+			}
+			heatingPar := &HeatingParStruct{ZoneIndex: tt.zone, VetoSetpoint: 22.0, VetoDuration: 0.5}
+			if _, err := conn.StartStrategybased(STRATEGY_HEATING, heatingPar); !errors.Is(err, ErrInvalidParameter) {
+				t.Errorf("StartStrategybased() = %v, expected %v", err, ErrInvalidParameter)
+			}
+			if _, err := conn.StopStrategybased(heatingPar); !errors.Is(err, ErrInvalidParameter) {
+				t.Errorf("StopStrategybased() = %v, expected %v", err, ErrInvalidParameter)
+			}
+			if value, _ := server.Value(ebusdtest.DEFAULT_CONTROLLER_NAME, EBUSDREAD_HOTWATER_SFMODE); value != HWC_SFMODE_NORMAL {
+				t.Errorf("%s = %q, expected %q", EBUSDREAD_HOTWATER_SFMODE, value, HWC_SFMODE_NORMAL)
+			}
+		})
+	}
+}
+
+func TestReconnect(t *testing.T) {
+	tests := []struct {
+		name string
+		drop func(server *ebusdtest.Server)
+	}{
+		{"next command dropped", func(server *ebusdtest.Server) { server.DropNext(1) }},
+		{"two commands dropped", func(server *ebusdtest.Server) { server.DropNext(2) }},
+		{"connections dropped", func(server *ebusdtest.Server) { server.DropConnections() }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, conn := newTestSystem(t)
+			if _, err := conn.GetSystem(true); err != nil {
+				t.Fatalf("first GetSystem() failed: %s", err)
+			}
+			tt.drop(server)
+			relData, err := conn.GetSystem(true)
+			if err != nil {
+				t.Fatalf("GetSystem() after reconnect failed: %s", err)
+			}
+			if relData.Hotwater.HwcTempDesired != 50.0 || len(relData.Zones) != 2 {
+				t.Errorf("GetSystem() after reconnect: Hotwater = %+v, %d zones", relData.Hotwater, len(relData.Zones))
+			}
+		})
+	}
+}
+
+func TestCheckEbusdConfigServerClosed(t *testing.T) {
+	policy := testRetryPolicy()
+	policy.MaxAttempts = 1
+	server, conn := newTestSystem(t, WithRetryPolicy(policy))
+	if _, err := conn.CheckEbusdConfig(); err != nil {
+		t.Fatalf("CheckEbusdConfig() failed: %s", err)
+	}
+	server.Close()
+	if details, err := conn.CheckEbusdConfig(); err == nil {
+		t.Errorf("CheckEbusdConfig() = %q, nil after ebusd was stopped, expected an error", details)
+	}
+	if report, err := conn.CheckEbusdConfigReport(); err == nil || isEbusdError(err) {
+		t.Errorf("CheckEbusdConfigReport() = %q, %v after ebusd was stopped, expected the connection error", report.Verdict, err)
+	}
+}
+
+func TestRetryWrites(t *testing.T) {
+	register, err := ParseB524Register("020003000800") // z1QuickVetoTemp
+	if err != nil {
+		t.Fatalf("ParseB524Register() failed: %s", err)
+	}
+	value := []byte{0x00, 0x00, 0xb0, 0x41} // 22.0
+	writeTelegram := hexTelegram(VRC720_ADDRESS, B524_PBSB, register.WriteRequest(value))
+	writes := []struct {
+		name  string
+		write func(conn *Connection) error
+	}{
+		{"hotwater boost", func(conn *Connection) error { return conn.StartHotWaterBoost() }},
+		{"B524 register", func(conn *Connection) error { return conn.WriteB524(register, value) }},
+	}
+	tests := []struct {
+		name        string
+		retryWrites bool
+		wantErr     bool
+	}{
+		{"writes not retried", false, true},
+		{"writes retried", true, false},
+	}
+	for _, write := range writes {
+		for _, tt := range tests {
+			t.Run(write.name+", "+tt.name, func(t *testing.T) {
+				policy := testRetryPolicy()
+				policy.RetryWrites = tt.retryWrites
+				server, conn := newTestSystem(t, WithRetryPolicy(policy))
+				server.SetHexEnabled(true)
+				server.SetHexReply(writeTelegram, "00")
+				server.ResetCommands()
+				server.DropNext(1)
+				err := write.write(conn)
+				if (err != nil) != tt.wantErr {
+					t.Fatalf("write = %v, expected error: %t", err, tt.wantErr)
+				}
+				if write.name == "hotwater boost" {
+					value, _ := server.Value(ebusdtest.DEFAULT_CONTROLLER_NAME, EBUSDREAD_HOTWATER_SFMODE)
+					if tt.wantErr == (value == HWC_SFMODE_BOOST) {
+						t.Errorf("%s = %q after StartHotWaterBoost() = %v", EBUSDREAD_HOTWATER_SFMODE, value, err)
+					}
+				} else {
+					sent := 0
+					for _, command := range server.Commands() {
+						if command == "hex "+writeTelegram {
+							sent++
+						}
+					}
+					expected := 1
+					if tt.retryWrites {
+						expected = 2
+					}
+					if sent != expected {
+						t.Errorf("telegram sent %d times, expected %d. Commands: %v", sent, expected, server.Commands())
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestEbusdErrorReplies(t *testing.T) {
+	tests := []struct {
+		reply string
+		err   error
+	}{
+		{ebusdtest.REPLY_NOSIGNAL, ErrNoSignal},
+		{ebusdtest.REPLY_ELEMENTNOTFOUND, ErrElementNotFound},
+		{ebusdtest.REPLY_ARBITRATIONLOST, ErrArbitrationLost},
+		{ebusdtest.REPLY_READTIMEOUT, ErrTimeout},
+		{ebusdtest.REPLY_INVALIDPOSITION, ErrInvalidPosition},
+		{ebusdtest.REPLY_INVALIDARGUMENT, ErrInvalidArgument},
+		{ebusdtest.REPLY_MISSINGARGUMENT, ErrMissingArgument},
+		{ebusdtest.REPLY_NOTENABLED, ErrNotEnabled},
+		{"ERR: CRC error", ErrCRC},
+		{"ERR: NAK received", ErrNAK},
+		{"ERR: argument value out of valid range", ErrOutOfRange},
+		{"ERR: something new", ErrUnknownEbusdReply},
+	}
+	policy := testRetryPolicy()
+	policy.MaxAttempts = 1
+	server, conn := newTestSystem(t, WithRetryPolicy(policy))
+	for _, tt := range tests {
+		t.Run(tt.reply, func(t *testing.T) {
+			server.SetError(EBUSDREAD_HOTWATER_SFMODE, tt.reply)
+			defer server.SetError(EBUSDREAD_HOTWATER_SFMODE, "")
+
+			ref := ElementRef{Circuit: ebusdtest.DEFAULT_CONTROLLER_NAME, Name: EBUSDREAD_HOTWATER_SFMODE, MaxAge: 0}
+			results, err := conn.ReadMany([]ElementRef{ref})
+			if err != nil {
+				t.Fatalf("ReadMany() failed: %s", err)
+			}
+			if len(results) != 1 {
+				t.Fatalf("ReadMany() returned %d results, expected 1", len(results))
+			}
+			var ebusdErr *EbusdError
+			if !errors.As(results[0].Err, &ebusdErr) {
+				t.Fatalf("error %v is no *EbusdError", results[0].Err)
+			}
+			if ebusdErr.Element != EBUSDREAD_HOTWATER_SFMODE || ebusdErr.Reply != tt.reply {
+				t.Errorf("EbusdError = %+v", ebusdErr)
+			}
+			if !errors.Is(results[0].Err, tt.err) {
+				t.Errorf("error %v does not match %v", results[0].Err, tt.err)
+			}
+		})
+	}
+}
+
+func TestSetHoliday(t *testing.T) {
+	from := time.Date(2026, time.December, 24, 0, 0, 0, 0, time.Local)
+	to := time.Date(2027, time.January, 6, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		name    string
+		setup   func(server *ebusdtest.Server)
+		zones   []int
+		err     error
+		written []string
+		failed  []string
+	}{
+		{"all zones", nil, nil, nil, nil, nil},
+		{"zone 2 only", nil, []int{2}, nil, nil, nil},
+		{"write of zone 2 fails", func(server *ebusdtest.Server) {
+			server.SetError("z2HolidayEndPeriod", ebusdtest.REPLY_INVALIDARGUMENT)
+		}, nil, ErrInvalidArgument, []string{"zone 1"}, []string{"zone 2", "hotwater"}},
+		{"hotwater period read-only", func(server *ebusdtest.Server) {
+			server.SetReadOnly(ebusdtest.DEFAULT_CONTROLLER_NAME, "HwcHolidayStartPeriod")
+		}, nil, ErrElementNotFound, []string{"zone 1", "zone 2"}, []string{"hotwater"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, conn := newTestSystem(t)
+			if tt.setup != nil {
+				tt.setup(server)
+			}
+			err := conn.SetHoliday(from, to, 12.5, tt.zones...)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("SetHoliday() = %v, expected %v", err, tt.err)
+			}
+			if tt.err != nil {
+				var holidayErr *HolidayError
+				if !errors.As(err, &holidayErr) {
+					t.Fatalf("SetHoliday() = %v, expected a *HolidayError", err)
+				}
+				if !slices.Equal(holidayErr.Written, tt.written) || !slices.Equal(holidayErr.Failed, tt.failed) {
+					t.Errorf("written %v, failed %v, expected %v and %v", holidayErr.Written, holidayErr.Failed, tt.written, tt.failed)
+				}
+				return
+			}
+			zones := tt.zones
+			if zones == nil {
+				zones = []int{1, 2}
+			}
+			for _, zone := range zones {
+				for name, expected := range map[string]string{"HolidayStartPeriod": "24.12.2026", "HolidayEndPeriod": "06.01.2027", "HolidayTemp": "12.5"} {
+					if value, _ := server.Value(ebusdtest.DEFAULT_CONTROLLER_NAME, fmt.Sprintf("z%d%s", zone, name)); value != expected {
+						t.Errorf("z%d%s = %q, expected %q", zone, name, value, expected)
+					}
+				}
+			}
+			if value, _ := server.Value(ebusdtest.DEFAULT_CONTROLLER_NAME, "HwcHolidayEndPeriod"); value != "06.01.2027" {
+				t.Errorf("HwcHolidayEndPeriod = %q, expected \"06.01.2027\"", value)
+			}
+			if err := conn.ClearHoliday(); err != nil {
+				t.Fatalf("ClearHoliday() failed: %s", err)
+			}
+			if value, _ := server.Value(ebusdtest.DEFAULT_CONTROLLER_NAME, "z1HolidayStartPeriod"); value != HOLIDAY_CLEARED_DATE {
+				t.Errorf("z1HolidayStartPeriod = %q after ClearHoliday(), expected %q", value, HOLIDAY_CLEARED_DATE)
+			}
+		})
+	}
+}
+
+func TestSetters(t *testing.T) {
+	tests := []struct {
+		name    string
+		set     func(conn *Connection) error
+		element string // element expected to be written, or expected to keep its value on errors
+		value   string
+		err     error
+	}{
+		{"zone day setpoint", func(conn *Connection) error { return conn.SetZoneDayTemp(2, 21.5) }, "z2DayTemp", "21.5", nil},
+		{"zone night setpoint of the default zone", func(conn *Connection) error { return conn.SetZoneNightTemp(ZONEINDEX_DEFAULT, 16.0) }, "z1NightTemp", "16.0", nil},
+		{"zone operation mode of a negative zone", func(conn *Connection) error { return conn.SetZoneOpMode(-1, OPERATIONMODE_DAY) }, "z1OpMode", OPERATIONMODE_DAY, nil},
+		{"quick veto of the default zone", func(conn *Connection) error { return conn.StartZoneQuickVeto(-1, 22.0, 1.0) }, "z1QuickVetoTemp", "22.0", nil},
+		{"quick veto of zone 2", func(conn *Connection) error { return conn.StartZoneQuickVeto(2, 22.0, 1.0) }, "z2QuickVetoDuration", "1.0", nil},
+		{"zone setpoint too high", func(conn *Connection) error { return conn.SetZoneDayTemp(1, ZONE_MAX_TEMP+1) }, "z1DayTemp", "20.0", ErrParameterOutOfRange},
+		{"zone setpoint too low", func(conn *Connection) error { return conn.SetZoneNightTemp(1, ZONE_MIN_TEMP-1) }, "z1NightTemp", "17.0", ErrParameterOutOfRange},
+		{"zone not configured", func(conn *Connection) error { return conn.SetZoneDayTemp(3, 20.0) }, "z1DayTemp", "20.0", ErrInvalidParameter},
+		{"quick veto of a zone not configured", func(conn *Connection) error { return conn.StartZoneQuickVeto(3, 22.0, 1.0) }, "z1QuickVetoTemp", "21.0", ErrInvalidParameter},
+		{"unknown zone operation mode", func(conn *Connection) error { return conn.SetZoneOpMode(1, "party") }, "z1OpMode", "auto", ErrInvalidParameter},
+		{"hotwater setpoint", func(conn *Connection) error { return conn.SetHotwaterTempDesired(48.5) }, "HwcTempDesired", "48.5", nil},
+		{"lowest hotwater setpoint", func(conn *Connection) error { return conn.SetHotwaterTempDesired(HWC_MIN_TEMP) }, "HwcTempDesired", "0.0", nil},
+		{"hotwater operation mode", func(conn *Connection) error { return conn.SetHotwaterOpMode(OPERATIONMODE_OFF) }, "HwcOpMode", OPERATIONMODE_OFF, nil},
+		{"hotwater setpoint too low", func(conn *Connection) error { return conn.SetHotwaterTempDesired(HWC_MIN_TEMP - 1) }, "HwcTempDesired", "50.0", ErrParameterOutOfRange},
+		{"hotwater setpoint too high", func(conn *Connection) error { return conn.SetHotwaterTempDesired(HWC_MAX_TEMP + 1) }, "HwcTempDesired", "50.0", ErrParameterOutOfRange},
+		{"unknown hotwater operation mode", func(conn *Connection) error { return conn.SetHotwaterOpMode("night") }, "HwcOpMode", "auto", ErrInvalidParameter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, conn := newTestSystem(t)
+			server.ResetCommands()
+			err := tt.set(conn)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error %v, expected %v", err, tt.err)
+			}
+			if value, _ := server.Value(ebusdtest.DEFAULT_CONTROLLER_NAME, tt.element); value != tt.value {
+				t.Errorf("%s = %q, expected %q", tt.element, value, tt.value)
+			}
+			if tt.err == nil {
+				return
+			}
+			if errors.Is(err, ErrInvalidArgument) || errors.Is(err, ErrOutOfRange) || isEbusdError(err) {
+				t.Errorf("error %v matches an ERR: reply of ebusd", err)
+			}
+			if commands := server.Commands(); len(commands) > 0 {
+				t.Errorf("commands %v sent to ebusd for an invalid argument", commands)
+			}
+		})
+	}
+}
+
+func TestCheckEbusdConfigReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(server *ebusdtest.Server)
+		verdict ConfigVerdict
+		failed  map[string]ConfigElement // expected failed elements, without the Error texts
+		summary string
+	}{
+		{
+			name:    "all elements ok",
+			setup:   func(server *ebusdtest.Server) {},
+			verdict: CONFIG_VERDICT_OK,
+			summary: "55 of 55 elements ok",
+		},
+		{
+			name:    "optional element missing",
+			setup:   func(server *ebusdtest.Server) { server.Delete("hmu", "CurrentConsumedPower") },
+			verdict: CONFIG_VERDICT_DEGRADED,
+			failed: map[string]ConfigElement{
+				"CurrentConsumedPower": {Name: "CurrentConsumedPower", Group: CONFIG_GROUP_POWER,
+					Reply: ebusdtest.REPLY_ELEMENTNOTFOUND},
+			},
+			summary: "54 of 55 elements ok, 1 optional elements failed",
+		},
+		{
+			name: "required elements failed",
+			setup: func(server *ebusdtest.Server) {
+				server.Delete(ebusdtest.DEFAULT_CONTROLLER_NAME, "HwcTempDesired")
+				server.SetError("z2RoomTemp", ebusdtest.REPLY_INVALIDPOSITION)
+				server.Delete("vwz00", "ImmersionHeaterPower")
+			},
+			verdict: CONFIG_VERDICT_FAILED,
+			failed: map[string]ConfigElement{
+				"HwcTempDesired": {Name: "HwcTempDesired", Group: CONFIG_GROUP_HOTWATER, Required: true,
+					Reply: ebusdtest.REPLY_ELEMENTNOTFOUND},
+				"z2RoomTemp": {Name: "z2RoomTemp", Group: CONFIG_GROUP_ZONE, Index: 2, Circuit: ebusdtest.DEFAULT_CONTROLLER_NAME,
+					Required: true, Found: true, Reply: ebusdtest.REPLY_INVALIDPOSITION},
+				"ImmersionHeaterPower": {Name: "ImmersionHeaterPower", Group: CONFIG_GROUP_POWER,
+					Reply: ebusdtest.REPLY_ELEMENTNOTFOUND},
+			},
+			summary: "52 of 55 elements ok, 2 required elements failed, 1 optional elements failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, conn := newTestSystem(t)
+			tt.setup(server)
+			report, err := conn.CheckEbusdConfigReport()
+			if err != nil {
+				t.Fatalf("CheckEbusdConfigReport() failed: %s", err)
+			}
+			if report.Verdict != tt.verdict || report.Summary != tt.summary {
+				t.Errorf("CheckEbusdConfigReport() = %q (%s), expected %q (%s)", report.Verdict, report.Summary, tt.verdict, tt.summary)
+			}
+			failed := report.Failed()
+			if len(failed) != len(tt.failed) {
+				t.Errorf("Failed() = %+v, expected %d elements", failed, len(tt.failed))
+			}
+			for _, element := range failed {
+				if element.Error == "" {
+					t.Errorf("element %s failed without an error", element.Name)
+				}
+				element.Error, element.err = "", nil
+				if element != tt.failed[element.Name] {
+					t.Errorf("Failed() element = %+v, expected %+v", element, tt.failed[element.Name])
+				}
+			}
+			for _, element := range report.Elements {
+				if element.ReadOK && (!element.Found || element.Circuit == "" || element.Error != "") {
+					t.Errorf("element %+v read, expected found with a circuit and without an error", element)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigReportJSON(t *testing.T) {
+	report := ConfigReport{
+		Time:    time.Date(2026, 10, 18, 10, 15, 30, 0, time.UTC),
+		Verdict: CONFIG_VERDICT_FAILED,
+		Summary: "1 of 2 elements ok, 1 required elements failed",
+		Elements: []ConfigElement{
+			{Name: "HwcTempDesired", Group: CONFIG_GROUP_HOTWATER, Circuit: "ctlv2", Required: true, Found: true, ReadOK: true,
+				Reply: "50.0"},
+			{Name: "z1RoomTemp", Group: CONFIG_GROUP_ZONE, Index: 1, Required: true, Reply: "ERR: element not found",
+				Error: "element not found", err: ErrElementNotFound},
+		},
+	}
+	data, err := report.JSON()
+	if err != nil {
+		t.Fatalf("JSON() failed: %s", err)
+	}
+	want := `{
+  "time": "2026-10-18T10:15:30Z",
+  "verdict": "failed",
+  "summary": "1 of 2 elements ok, 1 required elements failed",
+  "elements": [
+    {
+      "name": "HwcTempDesired",
+      "group": "hotwater",
+      "circuit": "ctlv2",
+      "required": true,
+      "found": true,
+      "readok": true,
+      "reply": "50.0"
+    },
+    {
+      "name": "z1RoomTemp",
+      "group": "zone",
+      "index": 1,
+      "circuit": "",
+      "required": true,
+      "found": false,
+      "readok": false,
+      "reply": "ERR: element not found",
+      "error": "element not found"
+    }
+  ]
+}`
+	if string(data) != want {
+		t.Errorf("JSON() =\n%s\nexpected\n%s", data, want)
+	}
+}
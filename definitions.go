@@ -0,0 +1,239 @@
+package sensonetEbus
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/WulfgarW/sensonetEbus/ebusdcsv"
+)
+
+//go:embed ebusd-config-files/*.csv
+var bundledConfigFiles embed.FS
+
+// MessageDefinition is a message defined in an ebusd configuration file or known to ebusd
+type MessageDefinition struct {
+	Type      string   // "r", "w" or "u"
+	Circuit   string   // e.g. "ctlv2"
+	Name      string   // e.g. "z1QuickVetoDuration"
+	ZZ        string   // hex address of the slave, e.g. "15"
+	PBSB      string   // primary and secondary command, e.g. "B524"
+	ID        string   // further command bytes including the ones of the default line, e.g. "020000000200"
+	DataTypes []string // data types or templates of the fields including ignored ones, e.g. ["IGN:4", "tempv"]
+}
+
+func (d MessageDefinition) String() string {
+	return fmt.Sprintf("%s,%s,%s,%s,%s,%s,%s", d.Type, d.Circuit, d.Name, d.ZZ, d.PBSB, d.ID, strings.Join(d.DataTypes, ";"))
+}
+
+// DefinitionDiff is a message that is missing in ebusd, unknown to the bundled file or defined differently
+type DefinitionDiff struct {
+	Type        string
+	Name        string
+	Bundled     *MessageDefinition // nil for messages only known to ebusd
+	Ebusd       *MessageDefinition // nil for messages missing in ebusd
+	Differences []string           // e.g. `ID: bundled "020000000200", ebusd "020000000300"`
+}
+
+// DefinitionFileReport is the result of comparing one bundled configuration file with the definitions loaded by ebusd
+type DefinitionFileReport struct {
+	File      string // e.g. "15.ctlv2.csv"
+	Circuit   string
+	Missing   []DefinitionDiff // defined in the bundled file, unknown to ebusd
+	Extra     []DefinitionDiff // known to ebusd, not defined in the bundled file
+	Different []DefinitionDiff // different PBSB, ID or data types
+}
+
+// Outdated returns true, if ebusd lacks messages of the bundled file or defines them differently,
+// i.e. the file installed in the configuration path of ebusd should be replaced by the bundled one
+func (r DefinitionFileReport) Outdated() bool {
+	return len(r.Missing) > 0 || len(r.Different) > 0
+}
+
+// DefinitionReport is the result of VerifyDefinitions()
+type DefinitionReport struct {
+	Files []DefinitionFileReport
+}
+
+// Outdated returns the names of the bundled files that have to be installed again
+func (r DefinitionReport) Outdated() []string {
+	var files []string
+	for _, file := range r.Files {
+		if file.Outdated() {
+			files = append(files, file.File)
+		}
+	}
+	return files
+}
+
+// BundledDefinitions returns the messages defined in the configuration files in ebusd-config-files, by file name
+func BundledDefinitions() (map[string][]MessageDefinition, error) {
+	files, err := bundledFiles()
+	if err != nil {
+		return nil, err
+	}
+	definitions := make(map[string][]MessageDefinition)
+	for _, file := range files {
+		fileDefinitions := make([]MessageDefinition, 0, len(file.Messages))
+		for _, message := range file.Messages {
+			fileDefinitions = append(fileDefinitions, MessageDefinition{Type: message.Type, Circuit: message.Circuit, Name: message.Name,
+				ZZ: message.ZZ, PBSB: message.PBSB, ID: message.ID, DataTypes: message.DataTypes()})
+		}
+		definitions[file.Name] = fileDefinitions
+	}
+	return definitions, nil
+}
+
+// bundledFiles parses the configuration files in ebusd-config-files
+func bundledFiles() ([]*ebusdcsv.File, error) {
+	entries, err := bundledConfigFiles.ReadDir("ebusd-config-files")
+	if err != nil {
+		return nil, err
+	}
+	var files []*ebusdcsv.File
+	for _, entry := range entries {
+		data, err := bundledConfigFiles.ReadFile("ebusd-config-files/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		file, err := ebusdcsv.ParseFile(entry.Name(), bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("error in %s: %w", entry.Name(), err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// parseEbusdDefinition parses a line of "find -F type,circuit,name,zz,pbsb,id,fields". Each field has the columns
+// name, part, data type, divider/values, unit and comment.
+func parseEbusdDefinition(line string) (MessageDefinition, error) {
+	reader := csv.NewReader(strings.NewReader(line))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	columns, err := reader.Read()
+	if err != nil {
+		return MessageDefinition{}, err
+	}
+	if len(columns) < 6 {
+		return MessageDefinition{}, fmt.Errorf("invalid definition %q", line)
+	}
+	def := MessageDefinition{Type: normalizeMessageType(columns[0]), Circuit: columns[1], Name: columns[2], ZZ: columns[3], PBSB: columns[4], ID: columns[5]}
+	for i := 8; i < len(columns); i += 6 {
+		if dataType := strings.TrimSpace(columns[i]); dataType != "" {
+			def.DataTypes = append(def.DataTypes, dataType)
+		}
+	}
+	return def, nil
+}
+
+// normalizeMessageType removes the poll priority from a message type, e.g. "r1" becomes "r"
+func normalizeMessageType(messageType string) string {
+	return strings.TrimRight(strings.ToLower(strings.TrimSpace(messageType)), "0123456789")
+}
+
+// resolveDataTypes replaces the templates in dataTypes by their base types like ebusd does when loading a file.
+// Data types that cannot be resolved are kept, so that they show up as difference.
+func resolveDataTypes(templates ebusdcsv.Templates, dataTypes []string) []string {
+	var resolved []string
+	for _, dataType := range dataTypes {
+		fields, err := templates.Resolve(ebusdcsv.Field{Types: []string{dataType}})
+		if err != nil {
+			resolved = append(resolved, dataType)
+			continue
+		}
+		for _, field := range fields {
+			resolved = append(resolved, field.Types...)
+		}
+	}
+	return resolved
+}
+
+// compareDefinitions compares the definitions of the bundled file with the ones of ebusd. The templates of the
+// bundled definitions are resolved first, because ebusd reports the base types.
+func compareDefinitions(file string, bundled, ebusd []MessageDefinition) DefinitionFileReport {
+	_, circuit := ebusdcsv.CircuitOfFile(file)
+	templates := ebusdcsv.DefaultTemplates()
+	report := DefinitionFileReport{File: file, Circuit: circuit}
+	key := func(def MessageDefinition) string {
+		return def.Type + "|" + strings.ToLower(def.Name)
+	}
+	known := make(map[string]*MessageDefinition)
+	for i := range ebusd {
+		known[key(ebusd[i])] = &ebusd[i]
+	}
+	for i := range bundled {
+		bundledDef := &bundled[i]
+		ebusdDef, ok := known[key(*bundledDef)]
+		if !ok {
+			report.Missing = append(report.Missing, DefinitionDiff{Type: bundledDef.Type, Name: bundledDef.Name, Bundled: bundledDef})
+			continue
+		}
+		delete(known, key(*bundledDef))
+		var differences []string
+		if !strings.EqualFold(bundledDef.PBSB, ebusdDef.PBSB) {
+			differences = append(differences, fmt.Sprintf("PBSB: bundled %q, ebusd %q", bundledDef.PBSB, ebusdDef.PBSB))
+		}
+		if !strings.EqualFold(bundledDef.ID, ebusdDef.ID) {
+			differences = append(differences, fmt.Sprintf("ID: bundled %q, ebusd %q", bundledDef.ID, ebusdDef.ID))
+		}
+		bundledTypes, ebusdTypes := strings.Join(resolveDataTypes(templates, bundledDef.DataTypes), ";"), strings.Join(ebusdDef.DataTypes, ";")
+		if !strings.EqualFold(bundledTypes, ebusdTypes) {
+			differences = append(differences, fmt.Sprintf("data types: bundled %q, ebusd %q", bundledTypes, ebusdTypes))
+		}
+		if len(differences) > 0 {
+			report.Different = append(report.Different, DefinitionDiff{Type: bundledDef.Type, Name: bundledDef.Name, Bundled: bundledDef,
+				Ebusd: ebusdDef, Differences: differences})
+		}
+	}
+	for _, ebusdDef := range known {
+		report.Extra = append(report.Extra, DefinitionDiff{Type: ebusdDef.Type, Name: ebusdDef.Name, Ebusd: ebusdDef})
+	}
+	sort.Slice(report.Extra, func(i, j int) bool {
+		return report.Extra[i].Name < report.Extra[j].Name || (report.Extra[i].Name == report.Extra[j].Name && report.Extra[i].Type < report.Extra[j].Type)
+	})
+	return report
+}
+
+// verifyDefinitions compares the definitions of the bundled configuration files with the ones loaded by ebusd
+func (c *EbusConnection) verifyDefinitions(ctx context.Context) (DefinitionReport, error) {
+	finder, ok := c.transport.(DefinitionFinder)
+	if !ok {
+		return DefinitionReport{}, errors.ErrUnsupported
+	}
+	bundled, err := BundledDefinitions()
+	if err != nil {
+		return DefinitionReport{}, err
+	}
+	files := make([]string, 0, len(bundled))
+	for file := range bundled {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	var report DefinitionReport
+	for _, file := range files {
+		_, circuit := ebusdcsv.CircuitOfFile(file)
+		lines, err := finder.FindDefinitions(ctx, circuit)
+		if err != nil && !errors.Is(err, ErrElementNotFound) {
+			return report, err
+		}
+		var ebusdDefs []MessageDefinition
+		for _, line := range lines {
+			def, err := parseEbusdDefinition(line)
+			if err != nil {
+				c.debug(fmt.Sprintf("Ignoring definition returned by ebusd: %s", err))
+				continue
+			}
+			ebusdDefs = append(ebusdDefs, def)
+		}
+		fileReport := compareDefinitions(file, bundled[file], ebusdDefs)
+		c.debug(fmt.Sprintf("Definitions of %s: %d missing, %d extra, %d different", file, len(fileReport.Missing), len(fileReport.Extra), len(fileReport.Different)))
+		report.Files = append(report.Files, fileReport)
+	}
+	return report, nil
+}
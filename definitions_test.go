@@ -0,0 +1,141 @@
+package sensonetEbus
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/WulfgarW/sensonetEbus/ebusdcsv"
+	"github.com/WulfgarW/sensonetEbus/ebusdtest"
+)
+
+// bundledDefinition returns the definition of the bundled file named file with the given type and name
+func bundledDefinition(t *testing.T, file, messageType, name string) MessageDefinition {
+	t.Helper()
+	definitions, err := BundledDefinitions()
+	if err != nil {
+		t.Fatalf("BundledDefinitions() failed: %s", err)
+	}
+	for _, def := range definitions[file] {
+		if def.Type == messageType && def.Name == name {
+			return def
+		}
+	}
+	t.Fatalf("%s %s not defined in %s", messageType, name, file)
+	return MessageDefinition{}
+}
+
+func TestBundledTimerDefinitions(t *testing.T) {
+	tests := []struct {
+		messageType string
+		name        string
+		pbsb        string
+		id          string
+	}{
+		{"r", "hwcTimer.Config", "B555", "a30002"},
+		{"r", "hwcTimer.Timeframes", "B555", "a40002"},
+		{"r", "ccTimer.Config", "B555", "a30003"},
+		{"r", "ccTimer.Timeframes", "B555", "a40003"},
+		{"r", "z1Timer.Timeframes", "B555", "a40000"},
+		{"r", "z2Timer.Timeframes", "B555", "a40100"},
+		{"r", "z3Timer.Timeframes", "B555", "a40200"},
+		{"r", "hwcTimer.Monday0", "B555", "a500020000"},
+		{"r", "ccTimer.Sunday2", "B555", "a500030602"},
+		{"r", "z3Timer.Wednesday1", "B555", "a502000201"},
+		{"w", "hwcTimer.Monday", "B555", "a6000200"},
+		{"w", "z1Timer.Sunday", "B555", "a6000006"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			def := bundledDefinition(t, "15.ctlv2.csv", tt.messageType, tt.name)
+			if def.PBSB != tt.pbsb || def.ID != tt.id {
+				t.Errorf("%s %s: PBSB %s, ID %s, expected %s, %s", tt.messageType, tt.name, def.PBSB, def.ID, tt.pbsb, tt.id)
+			}
+		})
+	}
+}
+
+func TestBundledTimerWriteTemplates(t *testing.T) {
+	days := []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+	tests := []struct {
+		target   string
+		template string
+	}{
+		{"hwc", "wTimeSlotWithTemp"},
+		{"cc", "wTimeSlotWithoutTemp"},
+		{"z1", "wTimeSlotWithTemp"},
+		{"z2", "wTimeSlotWithTemp"},
+		{"z3", "wTimeSlotWithTemp"},
+	}
+	for _, tt := range tests {
+		for _, day := range days {
+			name := tt.target + "Timer." + day
+			def := bundledDefinition(t, "15.ctlv2.csv", "w", name)
+			if len(def.DataTypes) != 1 || def.DataTypes[0] != tt.template {
+				t.Errorf("w %s: data types %v, expected [%s]", name, def.DataTypes, tt.template)
+			}
+		}
+	}
+}
+
+func TestBundledMessagesResolve(t *testing.T) {
+	files, err := bundledFiles()
+	if err != nil {
+		t.Fatalf("bundledFiles() failed: %s", err)
+	}
+	templates := ebusdcsv.DefaultTemplates()
+	for _, file := range files {
+		for _, message := range file.Messages {
+			for _, field := range message.Fields {
+				if _, err := templates.Resolve(field); err != nil {
+					t.Errorf("%s line %d: %s %s field %q: %s", file.Name, message.Line, message.Type, message.Name, field.Name, err)
+				}
+			}
+		}
+	}
+}
+
+func TestResolveDataTypes(t *testing.T) {
+	tests := []struct {
+		dataTypes []string
+		resolved  string
+	}{
+		{[]string{"IGN:4", "tempv"}, "IGN:4;EXP"},
+		{[]string{"IGN:4", "mctype", "IGN:1"}, "IGN:4;UCH;IGN:1"},
+		{[]string{"wTimeSlotWithTemp"}, "UCH;UCH;HTM;HTM;UIN"},
+		{[]string{"HDA:3"}, "HDA:3"},
+		{[]string{"unknowntemplate"}, "unknowntemplate"},
+	}
+	templates := ebusdcsv.DefaultTemplates()
+	for _, tt := range tests {
+		if resolved := strings.Join(resolveDataTypes(templates, tt.dataTypes), ";"); resolved != tt.resolved {
+			t.Errorf("resolveDataTypes(%v) = %q, expected %q", tt.dataTypes, resolved, tt.resolved)
+		}
+	}
+}
+
+// TestVerifyDefinitionsOfInstalledFile compares the bundled 15.ctlv2.csv with the output of "find -F" of an ebusd
+// that loaded it. ebusd reports the base types of the templates, so no differences are expected.
+func TestVerifyDefinitionsOfInstalledFile(t *testing.T) {
+	dump, err := os.ReadFile("testdata/find-F.ctlv2.txt")
+	if err != nil {
+		t.Fatalf("reading the dump failed: %s", err)
+	}
+	server, conn := newTestSystem(t)
+	server.SetDefinitions(ebusdtest.DEFAULT_CONTROLLER_NAME, strings.Split(strings.TrimSpace(string(dump)), "\n")...)
+
+	report, err := conn.VerifyDefinitions()
+	if err != nil {
+		t.Fatalf("VerifyDefinitions() failed: %s", err)
+	}
+	for _, file := range report.Files {
+		if file.File != "15.ctlv2.csv" {
+			continue
+		}
+		for _, diff := range append(append(file.Missing, file.Extra...), file.Different...) {
+			t.Errorf("%s %s: bundled %v, ebusd %v, differences %v", diff.Type, diff.Name, diff.Bundled, diff.Ebusd, diff.Differences)
+		}
+		return
+	}
+	t.Errorf("15.ctlv2.csv missing in the report")
+}
@@ -0,0 +1,95 @@
+package sensonetEbus
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/WulfgarW/sensonetEbus/ebusdcsv"
+)
+
+// BusDevice is a device on the bus as reported by the ebusd command "scan result"
+type BusDevice struct {
+	Address         string // hex address of the slave, e.g. "15"
+	Manufacturer    string // e.g. "Vaillant"
+	ID              string // e.g. "CTLV2" (controller), "HMU00" (heat pump), "VWZ00" (indoor unit), "NETX3" (VR921)
+	SoftwareVersion string // e.g. "0514"
+	HardwareVersion string // e.g. "1704"
+	Circuit         string // circuit name used by ebusd for the device, e.g. "ctlv2". Empty if ebusd loaded no configuration for it
+}
+
+// parseScanResult parses the output of the ebusd command "scan result". Each line has the form
+// "<address>;<manufacturer>;<id>;<sw>;<hw>" optionally followed by further fields. Other lines are ignored.
+func parseScanResult(scanResult string) []BusDevice {
+	var devices []BusDevice
+	for _, line := range strings.Split(scanResult, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), ";")
+		if len(fields) < 5 || len(fields[0]) != 2 {
+			continue
+		}
+		if _, err := strconv.ParseUint(fields[0], 16, 8); err != nil {
+			continue
+		}
+		devices = append(devices, BusDevice{
+			Address:         strings.ToLower(fields[0]),
+			Manufacturer:    strings.TrimSpace(fields[1]),
+			ID:              strings.TrimSpace(fields[2]),
+			SoftwareVersion: strings.TrimSpace(fields[3]),
+			HardwareVersion: strings.TrimSpace(fields[4]),
+		})
+	}
+	return devices
+}
+
+// parseScannedID parses the scan id of an address line of "info", e.g. "MF=Vaillant;ID=CTLV2;SW=0514;HW=1704"
+func parseScannedID(address, scanned string) BusDevice {
+	device := BusDevice{Address: address}
+	for _, part := range strings.Split(scanned, ";") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "MF":
+			device.Manufacturer = value
+		case "ID":
+			device.ID = value
+		case "SW":
+			device.SoftwareVersion = value
+		case "HW":
+			device.HardwareVersion = value
+		}
+	}
+	return device
+}
+
+// busDevices parses scanResult and adds the circuit names from the configuration files ebusd loaded for the devices.
+// If the transport does not support scan results, the devices are taken from the scanned addresses in "info".
+func (c *EbusConnection) busDevices(ctx context.Context, scanResult string) []BusDevice {
+	devices := parseScanResult(scanResult)
+	info, err := c.transport.Info(ctx)
+	if err != nil {
+		c.debug(fmt.Sprintf("Circuits of the bus devices not available: %s", err))
+		return devices
+	}
+	addresses := parseEbusdInfo(info).Addresses
+	if len(devices) == 0 {
+		for _, address := range addresses {
+			if address.Scanned != "" {
+				devices = append(devices, parseScannedID(address.Address, address.Scanned))
+			}
+		}
+	}
+	for i := range devices {
+		for _, address := range addresses {
+			if strings.EqualFold(address.Address, devices[i].Address) && address.Loaded != "" {
+				_, devices[i].Circuit = ebusdcsv.CircuitOfFile(address.Loaded)
+			}
+		}
+	}
+	return devices
+}
+
+func (c *EbusConnection) getDevices() []BusDevice {
+	_ = c.mu.Lock(context.Background())
+	defer c.mu.Unlock()
+	return append([]BusDevice(nil), c.devices...)
+}
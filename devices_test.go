@@ -0,0 +1,62 @@
+package sensonetEbus
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseScanResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		scanResult string
+		want       []BusDevice
+	}{
+		{
+			name: "ebusd 23.2",
+			scanResult: "08;Vaillant;HMU00;0902;5103;21;18;21;0010023600;0082;014620;N0\n" +
+				"15;Vaillant;CTLV2;0514;1704;21;18;21;0020260914;0938;017829;N6\n" +
+				"76;Vaillant;VWZ00;0414;5103",
+			want: []BusDevice{
+				{Address: "08", Manufacturer: "Vaillant", ID: "HMU00", SoftwareVersion: "0902", HardwareVersion: "5103"},
+				{Address: "15", Manufacturer: "Vaillant", ID: "CTLV2", SoftwareVersion: "0514", HardwareVersion: "1704"},
+				{Address: "76", Manufacturer: "Vaillant", ID: "VWZ00", SoftwareVersion: "0414", HardwareVersion: "5103"},
+			},
+		},
+		{
+			name:       "upper case address and blanks",
+			scanResult: " EC;Vaillant; NETX3 ;0507;5103 \r\n",
+			want: []BusDevice{
+				{Address: "ec", Manufacturer: "Vaillant", ID: "NETX3", SoftwareVersion: "0507", HardwareVersion: "5103"},
+			},
+		},
+		{
+			name: "malformed lines",
+			scanResult: "scan running\n" +
+				"15;Vaillant;CTLV2;0514\n" +
+				"5;Vaillant;CTLV2;0514;1704\n" +
+				"115;Vaillant;CTLV2;0514;1704\n" +
+				"zz;Vaillant;CTLV2;0514;1704\n" +
+				"\n" +
+				"08;Vaillant;HMU00;0902;5103",
+			want: []BusDevice{
+				{Address: "08", Manufacturer: "Vaillant", ID: "HMU00", SoftwareVersion: "0902", HardwareVersion: "5103"},
+			},
+		},
+		{
+			name:       "error reply",
+			scanResult: "ERR: element not found",
+		},
+		{
+			name:       "empty",
+			scanResult: "",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := parseScanResult(tc.scanResult)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("parseScanResult() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
@@ -1,15 +1,12 @@
 package sensonetEbus
 
 import (
-	"bufio"
-	"syscall"
-
+	"context"
 	"errors"
 	"fmt"
-	"io"
-	"net"
+	"math"
 	"strconv"
-	"strings"
+	"sync"
 	"time"
 
 	"golang.org/x/exp/slices"
@@ -18,23 +15,29 @@ import (
 const SYSTEM_UPDATE_INTERVAL = 120
 
 type EbusConnection struct {
+	mu                   ctxMutex // serializes the passes of getSystem() and checkEbusdConfig()
 	logger               Logger
-	ebusdAddress         string
-	ebusdConn            net.Conn
-	ebusdReadBuffer      bufio.Reader
+	transport            Transport
 	controllerForSFMode  string
+	zones                []int       // indices of the configured zones, guarded by mu
+	heatCircuits         []int       // indices of the configured heat circuits, guarded by mu
+	devices              []BusDevice // devices on the bus, guarded by mu
 	systemUpdateInterval time.Duration
+	valuesMu             sync.Mutex        // guards values
+	values               map[string]string // last known value per element name
 }
 
 // NewConnection creates a new Sensonet device connection.
-func newEbusConnection(ebusdAddress string, opts ...EbusConnOption) (*EbusConnection, error) {
+func newEbusConnection(ctx context.Context, transport Transport, opts ...EbusConnOption) (*EbusConnection, error) {
 	ebus := &EbusConnection{}
-	ebus.ebusdAddress = ebusdAddress
+	ebus.mu = newCtxMutex()
+	ebus.transport = transport
 	ebus.systemUpdateInterval = SYSTEM_UPDATE_INTERVAL * time.Second
+	ebus.values = make(map[string]string)
 	for _, opt := range opts {
 		opt(ebus)
 	}
-	err := ebus.connectToEbusd()
+	err := ebus.connectToEbusd(ctx)
 
 	return ebus, err
 }
@@ -45,250 +48,327 @@ func (c *EbusConnection) debug(fmt string, arg ...any) {
 	}
 }
 
-func (c *EbusConnection) connectToEbusd() error {
-	var err error
-	c.ebusdConn, err = net.Dial("tcp", c.ebusdAddress)
-	if err != nil {
-		return err
+func (c *EbusConnection) connectToEbusd(ctx context.Context) error {
+	scanResult, err := c.transport.Scan(ctx)
+	if ctx.Err() != nil {
+		return ctx.Err()
 	}
-	defer c.ebusdConn.Close()
-	c.ebusdReadBuffer = *bufio.NewReader(c.ebusdConn)
-	scanResult := c.ebusdScanResult()
-	if scanResult == "" {
+	if errors.Is(err, errors.ErrUnsupported) {
+		c.debug("Transport does not support scan results")
+	} else if scanResult == "" {
 		c.debug("Scan result empty")
-		err = fmt.Errorf("empty scan result or error returned from ebusd: %s", scanResult)
+		err = fmt.Errorf("empty scan result or error returned from ebusd: %v", err)
 		return err
+	} else {
+		c.debug(fmt.Sprintf("Scan result= \n%s", scanResult))
+	}
+	c.devices = c.busDevices(ctx, scanResult)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	for _, device := range c.devices {
+		c.debug(fmt.Sprintf("Bus device %s: %s %s SW %s HW %s, circuit '%s'", device.Address, device.Manufacturer, device.ID, device.SoftwareVersion, device.HardwareVersion, device.Circuit))
+	}
+	c.controllerForSFMode = c.ebusdFindControllerForSFMode(ctx)
+	if ctx.Err() != nil {
+		return ctx.Err()
 	}
-	c.debug(fmt.Sprintf("Scan result= \n%s", scanResult))
-	c.controllerForSFMode = c.ebusdFindControllerForSFMode()
 	if c.controllerForSFMode == "" {
 		c.debug("Find result empty")
 		err = fmt.Errorf("empty find %s or error returned from ebusd: %s", EBUSDREAD_HOTWATER_SFMODE, c.controllerForSFMode)
 		return err
 	}
 	c.debug(fmt.Sprintf("Ebus Controller For SFMode= %s\n", c.controllerForSFMode))
-	return err
-}
-
-func (c *EbusConnection) ebusdScanResult() string {
-	var message, messageLine string
-	var err error
-	fmt.Fprintf(c.ebusdConn, "scan result\n")
-	message = ""
-	err = nil
-	for err == nil {
-		messageLine, err = c.ebusdReadBuffer.ReadString('\n')
-		message = message + messageLine
-		if err != nil || messageLine == "\n" {
-			break
-		}
+	c.zones, err = c.probeZones(ctx)
+	if err != nil {
+		c.debug(fmt.Sprintf("Error when probing the zones: %s", err))
+		return err
 	}
+	c.debug(fmt.Sprintf("Configured zones= %v\n", c.zones))
+	c.heatCircuits, err = c.probeHeatCircuits(ctx)
 	if err != nil {
-		c.debug(fmt.Sprintf("error in ebusdScanResult. error: %s", err))
+		c.debug(fmt.Sprintf("Error when probing the heat circuits: %s", err))
+		return err
 	}
-	return message
+	c.debug(fmt.Sprintf("Configured heat circuits= %v\n", c.heatCircuits))
+	return nil
 }
 
-func (c *EbusConnection) ebusdFindControllerForSFMode() string {
-	_, err := fmt.Fprintf(c.ebusdConn, "find "+EBUSDREAD_HOTWATER_SFMODE+"\n")
-	if err != nil {
-		c.debug(fmt.Sprintf("Error sending find command to ebusd: %s", err))
-		return ""
+// probeHeatCircuits detects the configured heat circuits by reading the circuit types of Hc1 to Hc<MAX_NUMBER_OF_HEATCIRCUITS>.
+// A heat circuit, whose type cannot be read or is "inactive", is not configured. Probing stops at the first heat circuit
+// that is not defined in the configuration of ebusd. The caller must hold c.mu (or have exclusive access to c).
+func (c *EbusConnection) probeHeatCircuits(ctx context.Context) ([]int, error) {
+	refs := make([]ElementRef, 0, MAX_NUMBER_OF_HEATCIRCUITS)
+	for hc := 1; hc <= MAX_NUMBER_OF_HEATCIRCUITS; hc++ {
+		refs = append(refs, ElementRef{Name: fmt.Sprintf("Hc%01d", hc) + EBUSDREAD_HC_CIRCUITTYPE, MaxAge: -1})
 	}
-	var message string
-	message, err = c.ebusdReadBuffer.ReadString('\n')
+	results, err := ReadMany(ctx, c.transport, refs)
 	if err != nil {
-		c.debug(fmt.Sprintf("Error when reading from ebusd: %s", err))
-		return ""
+		return nil, err
 	}
-	message = strings.TrimSpace(message)
-	if message[:min(4, len(message))] == "ERR:" {
-		c.debug(fmt.Sprintf("When trying to find controller for SFMode, ebusd answered: %s", message))
+	heatCircuits := []int{}
+	for i, result := range results {
+		if errors.Is(result.Err, ErrElementNotFound) {
+			break
+		}
+		if result.Err != nil || result.Value == HC_CIRCUITTYPE_INACTIVE {
+			c.debug(fmt.Sprintf("Heat circuit %d not configured: value '%s', error: %v", i+1, result.Value, result.Err))
+			continue
+		}
+		heatCircuits = append(heatCircuits, i+1)
 	}
-	strSlices := strings.SplitAfter(message, " ")
-	message = strings.TrimSpace(strSlices[0])
-	return message
+	return heatCircuits, nil
 }
 
-func isNetConnClosedErr(err error) bool {
-	switch {
-	case
-		errors.Is(err, net.ErrClosed),
-		errors.Is(err, io.EOF),
-		errors.Is(err, syscall.EPIPE):
-		return true
-	default:
-		if strings.Contains(err.Error(), "wsasend") {
-			return true
+// probeZones detects the configured zones by reading the short names of the zones z1 to z<MAX_NUMBER_OF_ZONES>.
+// A zone, whose short name cannot be read, is not configured. Probing stops at the first zone that is not
+// defined in the configuration of ebusd. The caller must hold c.mu (or have exclusive access to c).
+func (c *EbusConnection) probeZones(ctx context.Context) ([]int, error) {
+	refs := make([]ElementRef, 0, MAX_NUMBER_OF_ZONES)
+	for zone := 1; zone <= MAX_NUMBER_OF_ZONES; zone++ {
+		refs = append(refs, ElementRef{Name: fmt.Sprintf("z%01d", zone) + EBUSDREAD_ZONE_SHORTNAME, MaxAge: -1})
+	}
+	results, err := ReadMany(ctx, c.transport, refs)
+	if err != nil {
+		return nil, err
+	}
+	zones := []int{}
+	for i, result := range results {
+		if errors.Is(result.Err, ErrElementNotFound) {
+			break
 		}
-		return false
+		if result.Err != nil {
+			c.debug(fmt.Sprintf("Zone %d not configured: %s", i+1, result.Err))
+			continue
+		}
+		zones = append(zones, i+1)
 	}
+	return zones, nil
 }
 
-func (c *EbusConnection) ebusdRead(searchString string, notOlderThan int) (string, error) {
-	var err error
-	var ebusCommand string
-	if notOlderThan >= 0 {
-		ebusCommand = fmt.Sprintf("read -m %0d ", notOlderThan)
-	} else {
-		ebusCommand = "read "
+// refreshZones probes the configured zones again
+func (c *EbusConnection) refreshZones(ctx context.Context) ([]int, error) {
+	err := c.mu.Lock(ctx)
+	if err != nil {
+		return nil, err
 	}
-	message := EBUSD_ERROR_DUMMY
-	readTry := 0
-	buf := c.ebusdReadBuffer
+	defer c.mu.Unlock()
+	zones, err := c.probeZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.zones = zones
+	return append([]int(nil), zones...), nil
+}
 
-	for message[:min(4, len(message))] == "ERR:" && readTry < 3 {
-		// err = c.ebusdEmptyReadBuffer()
-		_, err = fmt.Fprint(c.ebusdConn, ebusCommand+searchString+"\n")
-		if err != nil {
-			c.debug(fmt.Sprintf("Error sending read command to ebusd: %s", err))
-			if isNetConnClosedErr(err) {
-				c.debug("Connection to ebusd is closed. Trying to reopen it.")
-				err = c.refreshEbusdConnection()
-				if err != nil {
-					c.debug("refreshEbusdConnection not successful: %s", err)
-					return "", err
-				} else {
-					_, err = fmt.Fprint(c.ebusdConn, ebusCommand+searchString+"\n")
-					if err != nil {
-						c.debug(fmt.Sprintf("Error sending read command to ebusd: %s", err))
-						return "", err
-					}
-					buf = c.ebusdReadBuffer
-					readTry = 0
-				}
-			} else {
-				return "", err
-			}
-		}
-		time.Sleep(200 * time.Millisecond) // give the ebusd a short time span before reading the answer
-		message, err = buf.ReadString('\n')
-		if err != nil && readTry > 1 {
-			c.debug(fmt.Sprintf("Error when reading from ebusd: %s", err))
-			return "", err
-		}
-		readTry = readTry + 1
-		if readTry < 3 && message[:min(4, len(message))] == "ERR:" {
-			//c.debug(fmt.Sprintf("Read try no. %d, Command: %s, ebusd answered: %s", readTry, ebusCommand+searchString, message))
+// getZones returns the indices of the configured zones
+func (c *EbusConnection) getZones() []int {
+	_ = c.mu.Lock(context.Background())
+	defer c.mu.Unlock()
+	return append([]int(nil), c.zones...)
+}
 
-			// When ebusd return an ERR: message, it returns an additional '\n'
-			_, err = buf.ReadString('\n')
-		}
+func (c *EbusConnection) ebusdFindControllerForSFMode(ctx context.Context) string {
+	circuits, err := c.transport.Find(ctx, EBUSDREAD_HOTWATER_SFMODE)
+	if err != nil {
+		c.debug(fmt.Sprintf("When trying to find controller for SFMode, got error: %s", err))
+		return ""
 	}
-	message = strings.TrimSpace(message)
-	if message[:min(4, len(message))] == "ERR:" {
-		c.debug(fmt.Sprintf("Command: %s, ebusd answered: %s", ebusCommand+searchString, message))
-		if message == EBUSD_ERROR_INVALIDPOSITION {
-			// If ebusd returns "ERR: invalid position in decode", the message is set to ""
-			message = ""
-		}
-		if message == EBUSD_ERROR_ELEMENTNOTFOUND && (searchString == EBUSDREAD_STATUS_CURRENTCONSUMEDPOWER || searchString == EBUSDREAD_STATUS_IMMERSIONHEATERPOWER) {
-			// If ebusd returns "ERR: element not found" for a power element, the message is set to ""
-			message = EBUSD_ERROR_POWERELEMENTNOTFOUND
-		}
-		// When ebusd return an ERR: message, it returns an additional '\n'
-		_, err = buf.ReadString('\n')
+	if len(circuits) == 0 {
+		return ""
 	}
-	return message, err
+	return circuits[0]
 }
 
-/*func (c *EbusConnection) ebusdEmptyReadBuffer() error {
-	var err error
-	buf := c.ebusdReadBuffer
+func (c *EbusConnection) ebusdRead(ctx context.Context, searchString string, notOlderThan int) (string, error) {
+	return c.transport.Read(ctx, "", searchString, notOlderThan)
+}
 
-	for buf.Buffered() > 0 {
-		fmt.Println("Bytes in Buffer:", buf.Buffered())
-		_, err = buf.ReadByte()
-		if err != nil {
-			if errors.Is(err, io.EOF) {
-				err = nil
-			} else {
-				c.debug(fmt.Sprintf("Error while emptying read buffer to ebusd: %s", err))
-				return err
-			}
-		}
-	}
-	return nil
-}*/
+func (c *EbusConnection) ebusdWrite(ctx context.Context, circuit, name, value string) error {
+	return c.transport.Write(ctx, circuit, name, value)
+}
 
-func (c *EbusConnection) ebusdWrite(message string) error {
-	var err error
-	c.ebusdConn, err = net.Dial("tcp", c.ebusdAddress)
+// ebusdWriteConfirmed writes value to the element name and reads it back. confirmed compares the value read back
+// with the value written. If they differ, ErrNotConfirmed is returned.
+func (c *EbusConnection) ebusdWriteConfirmed(ctx context.Context, circuit, name, value string, confirmed func(readBack string) bool) error {
+	err := c.transport.Write(ctx, circuit, name, value)
 	if err != nil {
 		return err
 	}
-	defer c.ebusdConn.Close()
-	c.ebusdReadBuffer = *bufio.NewReader(c.ebusdConn)
-	_, err = fmt.Fprint(c.ebusdConn, "write "+message+"\n")
+	readBack, err := c.transport.Read(ctx, circuit, name, 0)
 	if err != nil {
-		c.debug(fmt.Sprintf("Error writing to ebusd: %s", err))
 		return err
 	}
-	var ebusAnswer string
-	ebusAnswer, err = c.ebusdReadBuffer.ReadString('\n')
-	if err != nil {
-		c.debug(fmt.Sprintf("Error when reading answer after ebusd write: %s", err))
-		return err
+	c.setValue(name, readBack)
+	if !confirmed(readBack) {
+		c.debug(fmt.Sprintf("Value '%s' written to %s, but '%s' read back", value, name, readBack))
+		return fmt.Errorf("%w: %s is %q instead of %q", ErrNotConfirmed, name, readBack, value)
 	}
-	c.debug(fmt.Sprintf("Command sent to ebusd: %s", "write "+message))
-	c.debug(fmt.Sprintf("ebusd answered: %s", ebusAnswer))
-	return err
+	return nil
 }
 
-func (c *EbusConnection) refreshEbusdConnection() error {
-	var err error
-	c.ebusdConn, err = net.Dial("tcp", c.ebusdAddress)
-	if err != nil {
-		return err
+// sameFloat returns a function for ebusdWriteConfirmed() comparing the value read back with value
+func sameFloat(value float64) func(string) bool {
+	return func(readBack string) bool {
+		number, err := strconv.ParseFloat(readBack, 64)
+		return err == nil && math.Abs(number-value) < 0.05
 	}
-	c.ebusdReadBuffer = *bufio.NewReader(c.ebusdConn)
-	return nil
 }
 
-func (c *EbusConnection) getSystem(relData *VaillantRelData, reset bool) error {
+// sameString returns a function for ebusdWriteConfirmed() comparing the value read back with value
+func sameString(value string) func(string) bool {
+	return func(readBack string) bool {
+		return readBack == value
+	}
+}
+
+func (c *EbusConnection) ebusdReadMany(ctx context.Context, refs []ElementRef) (readResults, error) {
+	results, err := ReadMany(ctx, c.transport, refs)
+	byName := make(readResults, len(results))
+	for _, result := range results {
+		byName[result.Ref.Name] = result
+		if result.Err == nil {
+			c.setValue(result.Ref.Name, result.Value)
+		}
+	}
+	return byName, err
+}
+
+// setValue remembers value as last known value of the element name and returns the value known before
+func (c *EbusConnection) setValue(name, value string) (string, bool) {
+	c.valuesMu.Lock()
+	defer c.valuesMu.Unlock()
+	oldValue, known := c.values[name]
+	c.values[name] = value
+	return oldValue, known
+}
+
+// readResults are the results of ebusdReadMany() by element name
+type readResults map[string]ReadResult
+
+// get returns the value read for the element name or the error returned for it
+func (r readResults) get(name string) (string, error) {
+	result, ok := r[name]
+	if !ok {
+		return "", fmt.Errorf("element %s was not read from ebusd", name)
+	}
+	return result.Value, result.Err
+}
+
+// systemElementRefs are the elements read by getSystem() besides the zone elements
+var systemElementRefs = []ElementRef{
+	{Name: EBUSDREAD_HOTWATER_OPMODE, MaxAge: -1},
+	{Name: EBUSDREAD_HOTWATER_TEMPDESIRED, MaxAge: -1},
+	{Name: EBUSDREAD_HOTWATER_STORAGETEMP, MaxAge: 60},
+	{Name: EBUSDREAD_HOTWATER_SFMODE, MaxAge: 0},
+	{Name: EBUSDREAD_STATUS_TIME, MaxAge: -1},
+	{Name: EBUSDREAD_STATUS_OUTSIDETEMPERATURE, MaxAge: -1},
+	{Name: EBUSDREAD_STATUS_SYSTEMFLOWTEMPERATUE, MaxAge: -1},
+	{Name: EBUSDREAD_STATUS_WATERPRESSURE, MaxAge: -1},
+	{Name: EBUSDREAD_STATUS_CURRENTCONSUMEDPOWER, MaxAge: 60},
+	{Name: EBUSDREAD_STATUS_IMMERSIONHEATERPOWER, MaxAge: 60},
+	{Name: EBUSDREAD_STATUS_STATUS01, MaxAge: -1},
+	{Name: EBUSDREAD_STATUS_STATE, MaxAge: -1},
+	{Name: EBUSDREAD_HOTWATER_HOLIDAYSTARTPERIOD, MaxAge: -1},
+	{Name: EBUSDREAD_HOTWATER_HOLIDAYENDPERIOD, MaxAge: -1},
+}
+
+// zoneElementRefs returns the elements read by getSystem() for the zone heatingZone
+func zoneElementRefs(heatingZone int) []ElementRef {
+	zonePrefix := fmt.Sprintf("z%01d", heatingZone)
+	return []ElementRef{
+		{Name: zonePrefix + EBUSDREAD_ZONE_OPMODE, MaxAge: -1},
+		{Name: zonePrefix + EBUSDREAD_ZONE_SFMODE, MaxAge: 0},
+		{Name: zonePrefix + EBUSDREAD_ZONE_ACTUALROOMTEMPDESIRED, MaxAge: -1},
+		{Name: zonePrefix + EBUSDREAD_ZONE_ROOMTEMP, MaxAge: 180},
+		{Name: zonePrefix + EBUSDREAD_ZONE_QUICKVETOTEMP, MaxAge: 0},
+		{Name: zonePrefix + EBUSDREAD_ZONE_QUICKVETOENDDATE, MaxAge: -1},
+		{Name: zonePrefix + EBUSDREAD_ZONE_QUICKVETOENDTIME, MaxAge: -1},
+		{Name: zonePrefix + EBUSDREAD_ZONE_SHORTNAME, MaxAge: -1},
+		{Name: zonePrefix + EBUSDREAD_ZONE_NAME1, MaxAge: -1},
+		{Name: zonePrefix + EBUSDREAD_ZONE_NAME2, MaxAge: -1},
+		{Name: zonePrefix + EBUSDREAD_ZONE_HOLIDAYSTARTPERIOD, MaxAge: -1},
+		{Name: zonePrefix + EBUSDREAD_ZONE_HOLIDAYENDPERIOD, MaxAge: -1},
+		{Name: zonePrefix + EBUSDREAD_ZONE_HOLIDAYTEMP, MaxAge: -1},
+	}
+}
+
+// heatCircuitElementRefs returns the elements read by getSystem() for the heat circuit hc
+func heatCircuitElementRefs(hc int) []ElementRef {
+	hcPrefix := fmt.Sprintf("Hc%01d", hc)
+	return []ElementRef{
+		{Name: hcPrefix + EBUSDREAD_HC_CIRCUITTYPE, MaxAge: -1},
+		{Name: hcPrefix + EBUSDREAD_HC_ACTUALFLOWTEMPDESIRED, MaxAge: 60},
+		{Name: hcPrefix + EBUSDREAD_HC_FLOWTEMP, MaxAge: 60},
+		{Name: hcPrefix + EBUSDREAD_HC_STATUS, MaxAge: 60},
+		{Name: hcPrefix + EBUSDREAD_HC_PUMPSTATUS, MaxAge: 60},
+		{Name: hcPrefix + EBUSDREAD_HC_HEATCURVE, MaxAge: -1},
+		{Name: hcPrefix + EBUSDREAD_HC_SUMMERTEMPLIMIT, MaxAge: -1},
+		{Name: hcPrefix + EBUSDREAD_HC_MINFLOWTEMPDESIRED, MaxAge: -1},
+		{Name: hcPrefix + EBUSDREAD_HC_MAXFLOWTEMPDESIRED, MaxAge: -1},
+	}
+}
+
+// Close tears down the transport to ebusd. Further calls return an error.
+func (c *EbusConnection) Close() error {
+	return c.transport.Close()
+}
+
+func (c *EbusConnection) getSystem(ctx context.Context, relData *VaillantRelData, reset bool) error {
 	var err error
 	var findResult string
 	if !reset && time.Now().Before(relData.LastGetSystem.Add(c.systemUpdateInterval)) {
 		// Use relData that are already present instead of reading current data from ebusd
 		return nil
 	}
-	c.ebusdConn, err = net.Dial("tcp", c.ebusdAddress)
+	err = c.mu.Lock(ctx)
 	if err != nil {
-		c.debug(fmt.Sprintf("Error in net.Dial(). Error: %s\n", err))
 		return err
 	}
-	defer c.ebusdConn.Close()
-	c.ebusdReadBuffer = *bufio.NewReader(c.ebusdConn)
+	defer c.mu.Unlock()
 
-	// Getting Data for Hotwater
-	findResult, err = c.ebusdRead(EBUSDREAD_HOTWATER_OPMODE, -1)
+	refs := append([]ElementRef(nil), systemElementRefs...)
+	for _, zone := range c.zones {
+		refs = append(refs, zoneElementRefs(zone)...)
+	}
+	for _, hc := range c.heatCircuits {
+		refs = append(refs, heatCircuitElementRefs(hc)...)
+	}
+	results, err := c.ebusdReadMany(ctx, refs)
 	if err != nil {
+		c.debug(fmt.Sprintf("Reading from ebusd aborted: %s. Leaving getSystem()", err))
+		return err
+	}
+
+	// Getting Data for Hotwater
+	findResult, err = results.get(EBUSDREAD_HOTWATER_OPMODE)
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_HOTWATER_OPMODE, err))
 		return err
-	} else {
+	} else if err == nil {
 		if slices.Contains([]string{"off", "auto", "day"}, findResult) {
 			relData.Hotwater.HwcOpMode = findResult
 		} else {
 			c.debug(fmt.Sprintf("Value '%s' returnd from ebusd for %s invalid and therefore ignored", findResult, EBUSDREAD_HOTWATER_OPMODE))
 		}
 	}
-	findResult, err = c.ebusdRead(EBUSDREAD_HOTWATER_TEMPDESIRED, -1)
-	if err != nil {
+	findResult, err = results.get(EBUSDREAD_HOTWATER_TEMPDESIRED)
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_HOTWATER_TEMPDESIRED, err))
 		return err
-	} else {
-		convertedValue, err := convertToFloat(findResult, 0.0, 75.0)
+	} else if err == nil {
+		convertedValue, err := convertToFloat(findResult, HWC_MIN_TEMP, HWC_MAX_TEMP)
 		if err != nil {
 			c.debug(fmt.Sprintf("Value '%s' returned from ebusd for %s invalid and therefore ignored. Error: %s", findResult, EBUSDREAD_HOTWATER_TEMPDESIRED, err))
 		} else {
 			relData.Hotwater.HwcTempDesired = convertedValue
 		}
 	}
-	findResult, err = c.ebusdRead(EBUSDREAD_HOTWATER_STORAGETEMP, 60)
-	if err != nil {
+	findResult, err = results.get(EBUSDREAD_HOTWATER_STORAGETEMP)
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_HOTWATER_STORAGETEMP, err))
 		return err
-	} else {
+	} else if err == nil {
 		convertedValue, err := convertToFloat(findResult, 0.0, 75.0)
 		if err != nil {
 			c.debug(fmt.Sprintf("Value '%s' returned from ebusd for %s invalid and therefore ignored. Error: %s", findResult, EBUSDREAD_HOTWATER_STORAGETEMP, err))
@@ -296,11 +376,11 @@ func (c *EbusConnection) getSystem(relData *VaillantRelData, reset bool) error {
 			relData.Hotwater.HwcStorageTemp = convertedValue
 		}
 	}
-	findResult, err = c.ebusdRead(EBUSDREAD_HOTWATER_SFMODE, 0)
-	if err != nil {
+	findResult, err = results.get(EBUSDREAD_HOTWATER_SFMODE)
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_HOTWATER_SFMODE, err))
 		return err
-	} else {
+	} else if err == nil {
 		if slices.Contains([]string{HWC_SFMODE_BOOST, HWC_SFMODE_NORMAL}, findResult) {
 			relData.Hotwater.HwcSFMode = findResult
 		} else {
@@ -309,32 +389,32 @@ func (c *EbusConnection) getSystem(relData *VaillantRelData, reset bool) error {
 	}
 
 	// Getting General Status Data
-	findResult, err = c.ebusdRead(EBUSDREAD_STATUS_TIME, -1)
-	if err != nil {
+	findResult, err = results.get(EBUSDREAD_STATUS_TIME)
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_STATUS_TIME, err))
 		return err
-	} else {
+	} else if err == nil {
 		relData.Status.Time = findResult
 	}
-	findResult, err = c.ebusdRead(EBUSDREAD_STATUS_OUTSIDETEMPERATURE, -1)
-	if err != nil {
+	findResult, err = results.get(EBUSDREAD_STATUS_OUTSIDETEMPERATURE)
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_STATUS_OUTSIDETEMPERATURE, err))
 		return err
-	} else {
+	} else if err == nil {
 		relData.Status.OutsideTemperature, _ = strconv.ParseFloat(findResult, 64)
 	}
-	findResult, err = c.ebusdRead(EBUSDREAD_STATUS_SYSTEMFLOWTEMPERATUE, -1)
-	if err != nil {
+	findResult, err = results.get(EBUSDREAD_STATUS_SYSTEMFLOWTEMPERATUE)
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_STATUS_SYSTEMFLOWTEMPERATUE, err))
 		return err
-	} else {
+	} else if err == nil {
 		relData.Status.SystemFlowTemperature, _ = strconv.ParseFloat(findResult, 64)
 	}
-	findResult, err = c.ebusdRead(EBUSDREAD_STATUS_WATERPRESSURE, -1)
-	if err != nil {
+	findResult, err = results.get(EBUSDREAD_STATUS_WATERPRESSURE)
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_STATUS_WATERPRESSURE, err))
 		return err
-	} else {
+	} else if err == nil {
 		convertedValue, err := convertToFloat(findResult, 0.0, 5.0)
 		if err != nil {
 			c.debug(fmt.Sprintf("Value '%s' returned from ebusd for %s invalid and therefore ignored. Error: %s", findResult, EBUSDREAD_STATUS_WATERPRESSURE, err))
@@ -342,15 +422,16 @@ func (c *EbusConnection) getSystem(relData *VaillantRelData, reset bool) error {
 			relData.Status.WaterPressure = convertedValue
 		}
 	}
-	findResult, err = c.ebusdRead(EBUSDREAD_STATUS_CURRENTCONSUMEDPOWER, 60)
-	if err != nil {
+	findResult, err = results.get(EBUSDREAD_STATUS_CURRENTCONSUMEDPOWER)
+	if errors.Is(err, ErrElementNotFound) {
+		// The power elements are only defined in the custom ebusd config files. If they are missing, 0.0 is used
+		findResult, err = "0.0", nil
+		c.debug(fmt.Sprintf("Ebusd element %s not found. Value therefore set to '%s'", EBUSDREAD_STATUS_CURRENTCONSUMEDPOWER, findResult))
+	}
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_STATUS_CURRENTCONSUMEDPOWER, err))
 		return err
-	} else {
-		if findResult == EBUSD_ERROR_POWERELEMENTNOTFOUND {
-			findResult = "0.0"
-			c.debug(fmt.Sprintf("Ebusd element %s not found. Value therefore set to '%s'", EBUSDREAD_STATUS_IMMERSIONHEATERPOWER, findResult))
-		}
+	} else if err == nil {
 		convertedValue, err := convertToFloat(findResult, 0.0, 30.0)
 		if err != nil {
 			c.debug(fmt.Sprintf("Value '%s' returned from ebusd for %s invalid and therefore ignored. Error: %s", findResult, EBUSDREAD_STATUS_CURRENTCONSUMEDPOWER, err))
@@ -358,15 +439,16 @@ func (c *EbusConnection) getSystem(relData *VaillantRelData, reset bool) error {
 			relData.Status.CurrentConsumedPower = convertedValue
 		}
 	}
-	findResult, err = c.ebusdRead(EBUSDREAD_STATUS_IMMERSIONHEATERPOWER, 60)
-	if err != nil {
+	findResult, err = results.get(EBUSDREAD_STATUS_IMMERSIONHEATERPOWER)
+	if errors.Is(err, ErrElementNotFound) {
+		// The power elements are only defined in the custom ebusd config files. If they are missing, 0.0 is used
+		findResult, err = "0.0", nil
+		c.debug(fmt.Sprintf("Ebusd element %s not found. Value therefore set to '%s'", EBUSDREAD_STATUS_IMMERSIONHEATERPOWER, findResult))
+	}
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_STATUS_IMMERSIONHEATERPOWER, err))
 		return err
-	} else {
-		if findResult == EBUSD_ERROR_POWERELEMENTNOTFOUND {
-			findResult = "0.0"
-			c.debug(fmt.Sprintf("Ebusd element %s not found. Value therefore set to '%s'", EBUSDREAD_STATUS_IMMERSIONHEATERPOWER, findResult))
-		}
+	} else if err == nil {
 		convertedValue, err := convertToFloat(findResult, 0.0, 30.0)
 		if err != nil {
 			c.debug(fmt.Sprintf("Value '%s' returned from ebusd for %s invalid and therefore ignored. Error: %s", findResult, EBUSDREAD_STATUS_IMMERSIONHEATERPOWER, err))
@@ -374,64 +456,81 @@ func (c *EbusConnection) getSystem(relData *VaillantRelData, reset bool) error {
 			relData.Status.ImmersionHeaterPower = convertedValue
 		}
 	}
-	findResult, err = c.ebusdRead(EBUSDREAD_STATUS_STATUS01, -1)
-	if err != nil {
+	findResult, err = results.get(EBUSDREAD_STATUS_STATUS01)
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_STATUS_STATUS01, err))
 		return err
-	} else {
+	} else if err == nil {
 		relData.Status.Status01 = findResult
 	}
-	findResult, err = c.ebusdRead(EBUSDREAD_STATUS_STATE, -1)
-	if err != nil {
+	findResult, err = results.get(EBUSDREAD_STATUS_STATE)
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_STATUS_STATE, err))
 		return err
-	} else {
+	} else if err == nil {
 		relData.Status.State = findResult
 	}
 
 	// Getting Zone Data
-	if len(relData.Zones) == 0 {
-		relData.Zones = make([]VaillantRelDataZones, NUMBER_OF_ZONES_TO_READ)
+	if len(relData.Zones) != len(c.zones) {
+		relData.Zones = make([]VaillantRelDataZones, len(c.zones))
 	}
-	for i := 0; i < NUMBER_OF_ZONES_TO_READ && err == nil; i++ {
-		err = c.getZoneDataFromEbus(&relData.Zones[i], i+1)
+	for i := range c.zones {
+		if err := c.getZoneDataFromEbus(results, &relData.Zones[i], c.zones[i]); err != nil {
+			return err
+		}
 	}
 
-	// Set timestamp lastGetSystemAt and return nil error
+	// Getting Heat Circuit Data
+	if len(relData.HeatCircuits) != len(c.heatCircuits) {
+		relData.HeatCircuits = make([]VaillantRelDataHeatCircuits, len(c.heatCircuits))
+	}
+	for i := range c.heatCircuits {
+		if err := c.getHeatCircuitDataFromEbus(results, &relData.HeatCircuits[i], c.heatCircuits[i]); err != nil {
+			return err
+		}
+	}
+
+	// Getting Holiday Data
+	if err := c.getHolidayDataFromEbus(results, relData); err != nil {
+		return err
+	}
+
+	// Set timestamp lastGetSystemAt only if all data were read
 	relData.LastGetSystem = time.Now()
 	return nil
 }
 
-func (c *EbusConnection) getZoneDataFromEbus(zoneData *VaillantRelDataZones, heatingZone int) error {
+func (c *EbusConnection) getZoneDataFromEbus(results readResults, zoneData *VaillantRelDataZones, heatingZone int) error {
 	zonePrefix := fmt.Sprintf("z%01d", heatingZone)
 	zoneData.Index = heatingZone
-	findResult, err := c.ebusdRead(zonePrefix+EBUSDREAD_ZONE_OPMODE, -1)
-	if err != nil {
+	findResult, err := results.get(zonePrefix + EBUSDREAD_ZONE_OPMODE)
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_ZONE_OPMODE, err))
 		return err
-	} else {
+	} else if err == nil {
 		if slices.Contains([]string{"off", "auto", "day"}, findResult) {
 			zoneData.OpMode = findResult
 		} else {
 			c.debug(fmt.Sprintf("Value '%s' returned from ebusd for %s invalid and therefore ignored", findResult, zonePrefix+EBUSDREAD_ZONE_OPMODE))
 		}
 	}
-	findResult, err = c.ebusdRead(zonePrefix+EBUSDREAD_ZONE_SFMODE, 0)
-	if err != nil {
+	findResult, err = results.get(zonePrefix + EBUSDREAD_ZONE_SFMODE)
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_ZONE_SFMODE, err))
 		return err
-	} else {
+	} else if err == nil {
 		if slices.Contains([]string{"auto", "veto"}, findResult) {
 			zoneData.SFMode = findResult
 		} else {
 			c.debug(fmt.Sprintf("Value '%s' returned from ebusd for %s invalid and therefore ignored", findResult, zonePrefix+EBUSDREAD_ZONE_SFMODE))
 		}
 	}
-	findResult, err = c.ebusdRead(zonePrefix+EBUSDREAD_ZONE_ACTUALROOMTEMPDESIRED, -1)
-	if err != nil {
+	findResult, err = results.get(zonePrefix + EBUSDREAD_ZONE_ACTUALROOMTEMPDESIRED)
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_ZONE_ACTUALROOMTEMPDESIRED, err))
 		return err
-	} else {
+	} else if err == nil {
 		convertedValue, err := convertToFloat(findResult, 0.0, 50.0)
 		if err != nil {
 			c.debug(fmt.Sprintf("Value '%s' returned from ebusd for %s invalid and therefore ignored. Error: %s", findResult, zonePrefix+EBUSDREAD_ZONE_ACTUALROOMTEMPDESIRED, err))
@@ -439,11 +538,11 @@ func (c *EbusConnection) getZoneDataFromEbus(zoneData *VaillantRelDataZones, hea
 			zoneData.ActualRoomTempDesired = convertedValue
 		}
 	}
-	findResult, err = c.ebusdRead(zonePrefix+EBUSDREAD_ZONE_ROOMTEMP, 180)
-	if err != nil {
+	findResult, err = results.get(zonePrefix + EBUSDREAD_ZONE_ROOMTEMP)
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_ZONE_ROOMTEMP, err))
 		return err
-	} else {
+	} else if err == nil {
 		convertedValue, err := convertToFloat(findResult, 0.0, 50.0)
 		if err != nil {
 			c.debug(fmt.Sprintf("Value '%s' returned from ebusd for %s invalid and therefore ignored. Error: %s", findResult, zonePrefix+EBUSDREAD_ZONE_ROOMTEMP, err))
@@ -451,11 +550,11 @@ func (c *EbusConnection) getZoneDataFromEbus(zoneData *VaillantRelDataZones, hea
 			zoneData.RoomTemp = convertedValue
 		}
 	}
-	findResult, err = c.ebusdRead(zonePrefix+EBUSDREAD_ZONE_QUICKVETOTEMP, 0)
-	if err != nil {
+	findResult, err = results.get(zonePrefix + EBUSDREAD_ZONE_QUICKVETOTEMP)
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_ZONE_QUICKVETOTEMP, err))
 		return err
-	} else {
+	} else if err == nil {
 		convertedValue, err := convertToFloat(findResult, 0.0, 50.0)
 		if err != nil {
 			c.debug(fmt.Sprintf("Value '%s' returned from ebusd for %s invalid and therefore ignored. Error: %s", findResult, zonePrefix+EBUSDREAD_ZONE_QUICKVETOTEMP, err))
@@ -463,118 +562,269 @@ func (c *EbusConnection) getZoneDataFromEbus(zoneData *VaillantRelDataZones, hea
 			zoneData.QuickVetoTemp = convertedValue
 		}
 	}
-	findResult, err = c.ebusdRead(zonePrefix+EBUSDREAD_ZONE_QUICKVETOENDDATE, -1)
-	if err != nil {
+	findResult, err = results.get(zonePrefix + EBUSDREAD_ZONE_QUICKVETOENDDATE)
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_ZONE_QUICKVETOENDDATE, err))
 		return err
-	} else {
+	} else if err == nil {
 		zoneData.QuickVetoEndDate = findResult
 	}
-	findResult, err = c.ebusdRead(zonePrefix+EBUSDREAD_ZONE_QUICKVETOENDTIME, -1)
-	if err != nil {
+	findResult, err = results.get(zonePrefix + EBUSDREAD_ZONE_QUICKVETOENDTIME)
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_ZONE_QUICKVETOENDTIME, err))
 		return err
-	} else {
+	} else if err == nil {
 		zoneData.QuickVetoEndTime = findResult
 	}
-	findResult, err = c.ebusdRead(zonePrefix+EBUSDREAD_ZONE_SHORTNAME, -1)
-	if err != nil {
+	findResult, err = results.get(zonePrefix + EBUSDREAD_ZONE_SHORTNAME)
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_ZONE_SHORTNAME, err))
 		return err
-	} else {
+	} else if err == nil {
 		zoneData.ShortName = findResult
 	}
-	findResult, err = c.ebusdRead(zonePrefix+EBUSDREAD_ZONE_NAME1, -1)
-	if err != nil {
+	findResult, err = results.get(zonePrefix + EBUSDREAD_ZONE_NAME1)
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_ZONE_NAME1, err))
 		return err
-	} else {
+	} else if err == nil {
 		zoneData.Name1 = findResult
 	}
-	findResult, err = c.ebusdRead(zonePrefix+EBUSDREAD_ZONE_NAME2, -1)
-	if err != nil {
+	findResult, err = results.get(zonePrefix + EBUSDREAD_ZONE_NAME2)
+	if err != nil && !isEbusdError(err) {
 		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_ZONE_NAME2, err))
 		return err
-	} else {
+	} else if err == nil {
 		zoneData.Name2 = findResult
 	}
 
-	return err
+	return nil
+}
+
+func (c *EbusConnection) getHeatCircuitDataFromEbus(results readResults, hcData *VaillantRelDataHeatCircuits, heatCircuit int) error {
+	hcPrefix := fmt.Sprintf("Hc%01d", heatCircuit)
+	hcData.Index = heatCircuit
+	findResult, err := results.get(hcPrefix + EBUSDREAD_HC_CIRCUITTYPE)
+	if err != nil && !isEbusdError(err) {
+		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_HC_CIRCUITTYPE, err))
+		return err
+	} else if err == nil {
+		hcData.CircuitType = findResult
+	}
+	for _, temp := range []struct {
+		what  string
+		field *float64
+		max   float64
+	}{
+		{EBUSDREAD_HC_ACTUALFLOWTEMPDESIRED, &hcData.ActualFlowTempDesired, 100.0},
+		{EBUSDREAD_HC_FLOWTEMP, &hcData.FlowTemp, 100.0},
+		{EBUSDREAD_HC_HEATCURVE, &hcData.HeatCurve, 10.0},
+		{EBUSDREAD_HC_SUMMERTEMPLIMIT, &hcData.SummerTempLimit, 100.0},
+		{EBUSDREAD_HC_MINFLOWTEMPDESIRED, &hcData.MinFlowTempDesired, 100.0},
+		{EBUSDREAD_HC_MAXFLOWTEMPDESIRED, &hcData.MaxFlowTempDesired, 100.0},
+	} {
+		findResult, err = results.get(hcPrefix + temp.what)
+		if err != nil && !isEbusdError(err) {
+			c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", temp.what, err))
+			return err
+		} else if err == nil {
+			convertedValue, err := convertToFloat(findResult, 0.0, temp.max)
+			if err != nil {
+				c.debug(fmt.Sprintf("Value '%s' returned from ebusd for %s invalid and therefore ignored. Error: %s", findResult, hcPrefix+temp.what, err))
+			} else {
+				*temp.field = convertedValue
+			}
+		}
+	}
+	findResult, err = results.get(hcPrefix + EBUSDREAD_HC_STATUS)
+	if err != nil && !isEbusdError(err) {
+		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_HC_STATUS, err))
+		return err
+	} else if err == nil {
+		hcData.Status = findResult
+	}
+	findResult, err = results.get(hcPrefix + EBUSDREAD_HC_PUMPSTATUS)
+	if err != nil && !isEbusdError(err) {
+		c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_HC_PUMPSTATUS, err))
+		return err
+	} else if err == nil {
+		hcData.PumpStatus = findResult
+	}
+
+	return nil
 }
 
 // checkEbusdConfig() tries to read all elements that are used in the package to check if the configuration of the ebusd supports them
-func (c *EbusConnection) checkEbusdConfig() (string, error) {
-	var err error
-	var findResult, details string
+func (c *EbusConnection) checkEbusdConfig(ctx context.Context) (string, error) {
+	var details string
 	c.debug("In checkEbusConfig()")
 	errElementNotFound := false
 	errPowerConsumptionElementNotFound := ""
-	c.ebusdConn, err = net.Dial("tcp", c.ebusdAddress)
+	report, err := c.checkConfig(ctx)
 	if err != nil {
-		details = details + fmt.Sprintf("Error in net.Dial(). Error: %s\n", err)
-		c.debug(fmt.Sprintf("Error in net.Dial(). Error: %s\n", err))
 		return details, err
 	}
-	defer c.ebusdConn.Close()
-	c.ebusdReadBuffer = *bufio.NewReader(c.ebusdConn)
 
-	// Getting Data for Hotwater
-	for _, what := range []string{EBUSDREAD_HOTWATER_OPMODE, EBUSDREAD_HOTWATER_TEMPDESIRED, EBUSDREAD_HOTWATER_STORAGETEMP, EBUSDREAD_HOTWATER_SFMODE} {
-		findResult, err = c.ebusdRead(what, -1)
-		if err != nil {
-			details += c.setDetailsAndWriteDebugMessage(what, findResult, err)
+	for _, element := range report.Elements {
+		if element.err != nil {
+			details += c.setDetailsAndWriteDebugMessage(element.Name, element.Reply, element.err)
+		}
+		if !errors.Is(element.err, ErrElementNotFound) {
+			continue
 		}
-		if findResult == EBUSD_ERROR_ELEMENTNOTFOUND {
+		if element.Group == CONFIG_GROUP_POWER {
+			errPowerConsumptionElementNotFound = errPowerConsumptionElementNotFound + "Ebus element " + element.Name + " got " + EBUSD_ERROR_ELEMENTNOTFOUND + ", "
+		} else if element.Required {
 			errElementNotFound = true
 		}
 	}
+	if errPowerConsumptionElementNotFound != "" {
+		err = fmt.Errorf("%q: %w", errPowerConsumptionElementNotFound, ErrElementNotFound)
+	}
+	if errElementNotFound {
+		err = fmt.Errorf("Some ebus read commands got %q: %w", EBUSD_ERROR_ELEMENTNOTFOUND, ErrElementNotFound)
+	}
+	c.debug("End of checkEbusConfig()")
+	return details, err
+}
 
-	// Getting General Status Data
-	for _, what := range []string{EBUSDREAD_STATUS_TIME, EBUSDREAD_STATUS_OUTSIDETEMPERATURE, EBUSDREAD_STATUS_SYSTEMFLOWTEMPERATUE, EBUSDREAD_STATUS_WATERPRESSURE,
-		EBUSDREAD_STATUS_STATUS01, EBUSDREAD_STATUS_STATE} {
-		findResult, err = c.ebusdRead(what, -1)
-		if err != nil {
-			details += c.setDetailsAndWriteDebugMessage(what, findResult, err)
-		}
-		if findResult == EBUSD_ERROR_ELEMENTNOTFOUND {
-			errElementNotFound = true
+// applyElementValue sets the field of relData that getSystem() reads from the element name to value.
+// It returns false, if there is no such field or the value is invalid.
+func (c *EbusConnection) applyElementValue(relData *VaillantRelData, name, value string) bool {
+	setString := func(field *string, valid ...string) bool {
+		if len(valid) > 0 && !slices.Contains(valid, value) {
+			c.debug(fmt.Sprintf("Value '%s' returned from ebusd for %s invalid and therefore ignored", value, name))
+			return false
 		}
+		*field = value
+		return true
 	}
-
-	// Getting Power Consumption Data
-	for _, what := range []string{EBUSDREAD_STATUS_CURRENTCONSUMEDPOWER, EBUSDREAD_STATUS_IMMERSIONHEATERPOWER} {
-		findResult, err = c.ebusdRead(what, -1)
+	setFloat := func(field *float64, min, max float64) bool {
+		convertedValue, err := convertToFloat(value, min, max)
 		if err != nil {
-			details += c.setDetailsAndWriteDebugMessage(what, findResult, err)
+			c.debug(fmt.Sprintf("Value '%s' returned from ebusd for %s invalid and therefore ignored. Error: %s", value, name, err))
+			return false
 		}
-		if findResult == EBUSD_ERROR_ELEMENTNOTFOUND || findResult == EBUSD_ERROR_POWERELEMENTNOTFOUND {
-			errPowerConsumptionElementNotFound = errPowerConsumptionElementNotFound + "Ebus element" + what + "got " + EBUSD_ERROR_ELEMENTNOTFOUND + ", "
+		*field = convertedValue
+		return true
+	}
+	setHolidayDate := func(field *string) bool {
+		if _, err := time.Parse(HOLIDAY_DATE_LAYOUT, value); err != nil {
+			c.debug(fmt.Sprintf("Value '%s' returned from ebusd for %s invalid and therefore ignored", value, name))
+			return false
 		}
+		*field = value
+		updateHolidayActive(relData)
+		return true
 	}
-
-	// Getting Zone Data
-	for i := 0; i < NUMBER_OF_ZONES_TO_READ; i++ {
-		zonePrefix := fmt.Sprintf("z%01d", i+1)
-		for _, what := range []string{EBUSDREAD_ZONE_OPMODE, EBUSDREAD_ZONE_SFMODE, EBUSDREAD_ZONE_ACTUALROOMTEMPDESIRED, EBUSDREAD_ZONE_ROOMTEMP,
-			EBUSDREAD_ZONE_QUICKVETOTEMP, EBUSDREAD_ZONE_QUICKVETOENDTIME, EBUSDREAD_ZONE_QUICKVETOENDDATE, EBUSDREAD_ZONE_QUICKVETODURATION,
-			EBUSDREAD_ZONE_NAME1, EBUSDREAD_ZONE_NAME2, EBUSDREAD_ZONE_SHORTNAME} {
-			findResult, err = c.ebusdRead(zonePrefix+what, -1)
-			if err != nil || findResult[:min(4, len(findResult))] == "ERR:" {
-				details += c.setDetailsAndWriteDebugMessage(zonePrefix+what, findResult, err)
+	switch name {
+	case EBUSDREAD_HOTWATER_OPMODE:
+		return setString(&relData.Hotwater.HwcOpMode, "off", "auto", "day")
+	case EBUSDREAD_HOTWATER_TEMPDESIRED:
+		return setFloat(&relData.Hotwater.HwcTempDesired, HWC_MIN_TEMP, HWC_MAX_TEMP)
+	case EBUSDREAD_HOTWATER_STORAGETEMP:
+		return setFloat(&relData.Hotwater.HwcStorageTemp, 0.0, 75.0)
+	case EBUSDREAD_HOTWATER_SFMODE:
+		return setString(&relData.Hotwater.HwcSFMode, HWC_SFMODE_BOOST, HWC_SFMODE_NORMAL)
+	case EBUSDREAD_STATUS_TIME:
+		return setString(&relData.Status.Time)
+	case EBUSDREAD_STATUS_OUTSIDETEMPERATURE:
+		relData.Status.OutsideTemperature, _ = strconv.ParseFloat(value, 64)
+		return true
+	case EBUSDREAD_STATUS_SYSTEMFLOWTEMPERATUE:
+		relData.Status.SystemFlowTemperature, _ = strconv.ParseFloat(value, 64)
+		return true
+	case EBUSDREAD_STATUS_WATERPRESSURE:
+		return setFloat(&relData.Status.WaterPressure, 0.0, 5.0)
+	case EBUSDREAD_STATUS_CURRENTCONSUMEDPOWER:
+		return setFloat(&relData.Status.CurrentConsumedPower, 0.0, 30.0)
+	case EBUSDREAD_STATUS_IMMERSIONHEATERPOWER:
+		return setFloat(&relData.Status.ImmersionHeaterPower, 0.0, 30.0)
+	case EBUSDREAD_STATUS_STATUS01:
+		return setString(&relData.Status.Status01)
+	case EBUSDREAD_STATUS_STATE:
+		return setString(&relData.Status.State)
+	case EBUSDREAD_HOTWATER_HOLIDAYSTARTPERIOD:
+		return setHolidayDate(&relData.Holiday.Hotwater.StartDate)
+	case EBUSDREAD_HOTWATER_HOLIDAYENDPERIOD:
+		return setHolidayDate(&relData.Holiday.Hotwater.EndDate)
+	}
+	var index int
+	var suffix string
+	if n, _ := fmt.Sscanf(name, "z%d%s", &index, &suffix); n == 2 {
+		for i := range relData.Holiday.Zones {
+			if relData.Holiday.Zones[i].Index != index {
+				continue
 			}
-			if findResult == EBUSD_ERROR_ELEMENTNOTFOUND {
-				errElementNotFound = true
+			zoneHoliday := &relData.Holiday.Zones[i]
+			switch suffix {
+			case EBUSDREAD_ZONE_HOLIDAYSTARTPERIOD:
+				return setHolidayDate(&zoneHoliday.StartDate)
+			case EBUSDREAD_ZONE_HOLIDAYENDPERIOD:
+				return setHolidayDate(&zoneHoliday.EndDate)
+			case EBUSDREAD_ZONE_HOLIDAYTEMP:
+				return setFloat(&zoneHoliday.Temp, 0.0, 50.0)
 			}
 		}
+		for i := range relData.Zones {
+			if relData.Zones[i].Index != index {
+				continue
+			}
+			zoneData := &relData.Zones[i]
+			switch suffix {
+			case EBUSDREAD_ZONE_OPMODE:
+				return setString(&zoneData.OpMode, "off", "auto", "day")
+			case EBUSDREAD_ZONE_SFMODE:
+				return setString(&zoneData.SFMode, "auto", "veto")
+			case EBUSDREAD_ZONE_ACTUALROOMTEMPDESIRED:
+				return setFloat(&zoneData.ActualRoomTempDesired, 0.0, 50.0)
+			case EBUSDREAD_ZONE_ROOMTEMP:
+				return setFloat(&zoneData.RoomTemp, 0.0, 50.0)
+			case EBUSDREAD_ZONE_QUICKVETOTEMP:
+				return setFloat(&zoneData.QuickVetoTemp, 0.0, 50.0)
+			case EBUSDREAD_ZONE_QUICKVETOENDDATE:
+				return setString(&zoneData.QuickVetoEndDate)
+			case EBUSDREAD_ZONE_QUICKVETOENDTIME:
+				return setString(&zoneData.QuickVetoEndTime)
+			case EBUSDREAD_ZONE_SHORTNAME:
+				return setString(&zoneData.ShortName)
+			case EBUSDREAD_ZONE_NAME1:
+				return setString(&zoneData.Name1)
+			case EBUSDREAD_ZONE_NAME2:
+				return setString(&zoneData.Name2)
+			}
+		}
+		return false
 	}
-	if errPowerConsumptionElementNotFound != "" {
-		err = fmt.Errorf("%q", errPowerConsumptionElementNotFound)
-	}
-	if errElementNotFound {
-		err = fmt.Errorf("Some ebus read commands got %q", EBUSD_ERROR_ELEMENTNOTFOUND)
+	if n, _ := fmt.Sscanf(name, "Hc%d%s", &index, &suffix); n == 2 {
+		for i := range relData.HeatCircuits {
+			if relData.HeatCircuits[i].Index != index {
+				continue
+			}
+			hcData := &relData.HeatCircuits[i]
+			switch suffix {
+			case EBUSDREAD_HC_CIRCUITTYPE:
+				return setString(&hcData.CircuitType)
+			case EBUSDREAD_HC_ACTUALFLOWTEMPDESIRED:
+				return setFloat(&hcData.ActualFlowTempDesired, 0.0, 100.0)
+			case EBUSDREAD_HC_FLOWTEMP:
+				return setFloat(&hcData.FlowTemp, 0.0, 100.0)
+			case EBUSDREAD_HC_STATUS:
+				return setString(&hcData.Status)
+			case EBUSDREAD_HC_PUMPSTATUS:
+				return setString(&hcData.PumpStatus)
+			case EBUSDREAD_HC_HEATCURVE:
+				return setFloat(&hcData.HeatCurve, 0.0, 10.0)
+			case EBUSDREAD_HC_SUMMERTEMPLIMIT:
+				return setFloat(&hcData.SummerTempLimit, 0.0, 100.0)
+			case EBUSDREAD_HC_MINFLOWTEMPDESIRED:
+				return setFloat(&hcData.MinFlowTempDesired, 0.0, 100.0)
+			case EBUSDREAD_HC_MAXFLOWTEMPDESIRED:
+				return setFloat(&hcData.MaxFlowTempDesired, 0.0, 100.0)
+			}
+		}
 	}
-	c.debug("End of checkEbusConfig()")
-	return details, err
+	return false
 }
 
 func convertToFloat(rawResult string, min, max float64) (float64, error) {
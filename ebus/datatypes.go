@@ -0,0 +1,328 @@
+package ebus
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// REPLACEMENT_VALUE is returned (like ebusd does) for values marked as not available, e.g. a missing sensor
+const REPLACEMENT_VALUE = "-"
+
+type Kind int
+
+const (
+	KIND_NUMBER Kind = iota // integers and fixed point numbers, e.g. UCH, D2C, FLT
+	KIND_BCD                // binary coded decimal (BCD)
+	KIND_FLOAT              // IEEE 754 float (EXP)
+	KIND_TIME               // time of day, e.g. HTI
+	KIND_DATE               // date, e.g. HDA
+	KIND_STRING             // characters (STR)
+	KIND_HEX                // bytes shown as hex (HEX)
+	KIND_IGNORE             // bytes skipped (IGN)
+)
+
+// DataType is a base data type of ebusd, see https://github.com/john30/ebusd/wiki/4.3.-Builtin-data-types.
+// Numbers are little endian. The names of the eBUS specification map to them as follows: DATA1b is D1B, DATA1c is D1C,
+// DATA2b is D2B and DATA2c is D2C.
+type DataType struct {
+	Name        string // e.g. "D2C" or "STR"
+	Size        int    // bytes, -1 for the rest of the data (e.g. "STR:*")
+	Kind        Kind
+	signed      bool
+	factor      float64 // value of the least significant bit of numbers
+	replacement uint64  // raw value of numbers marked as not available
+	order       string  // order of the fields of times and dates: h(our), m(inute), s(econd), d(ay), M(onth), w(eekday), y(ear)
+}
+
+// dataTypes are the data types supported, by name. Types with explicit length (e.g. "STR:5") are listed without length.
+var dataTypes = map[string]DataType{
+	"UCH": {Size: 1, Kind: KIND_NUMBER, factor: 1, replacement: 0xff},
+	"SCH": {Size: 1, Kind: KIND_NUMBER, signed: true, factor: 1, replacement: 0x80},
+	"D1B": {Size: 1, Kind: KIND_NUMBER, signed: true, factor: 1, replacement: 0x80},
+	"D1C": {Size: 1, Kind: KIND_NUMBER, factor: 0.5, replacement: 0xff},
+	"UIN": {Size: 2, Kind: KIND_NUMBER, factor: 1, replacement: 0xffff},
+	"SIN": {Size: 2, Kind: KIND_NUMBER, signed: true, factor: 1, replacement: 0x8000},
+	"D2B": {Size: 2, Kind: KIND_NUMBER, signed: true, factor: 1.0 / 256, replacement: 0x8000},
+	"D2C": {Size: 2, Kind: KIND_NUMBER, signed: true, factor: 1.0 / 16, replacement: 0x8000},
+	"FLT": {Size: 2, Kind: KIND_NUMBER, signed: true, factor: 1.0 / 1000, replacement: 0x8000},
+	"ULG": {Size: 4, Kind: KIND_NUMBER, factor: 1, replacement: 0xffffffff},
+	"SLG": {Size: 4, Kind: KIND_NUMBER, signed: true, factor: 1, replacement: 0x80000000},
+	"BCD": {Size: 1, Kind: KIND_BCD, replacement: 0xff},
+	"EXP": {Size: 4, Kind: KIND_FLOAT},
+	"HTI": {Size: 3, Kind: KIND_TIME, order: "hms"},
+	"HTM": {Size: 2, Kind: KIND_TIME, order: "hm"},
+	"BTI": {Size: 3, Kind: KIND_TIME, order: "smh"},
+	"BTM": {Size: 2, Kind: KIND_TIME, order: "mh"},
+	"HDA": {Size: 4, Kind: KIND_DATE, order: "dMwy"},
+	"BDA": {Size: 4, Kind: KIND_DATE, order: "dMwy"},
+	"STR": {Size: 1, Kind: KIND_STRING},
+	"HEX": {Size: 1, Kind: KIND_HEX},
+	"IGN": {Size: 1, Kind: KIND_IGNORE},
+}
+
+// ParseDataType returns the data type named like in the message definitions of ebusd, e.g. "D2C", "HDA:3" or "STR:*"
+func ParseDataType(dataType string) (DataType, error) {
+	name, length, hasLength := strings.Cut(strings.ToUpper(strings.TrimSpace(dataType)), ":")
+	t, ok := dataTypes[name]
+	if !ok {
+		return DataType{}, fmt.Errorf("%w: %s", ErrUnknownType, dataType)
+	}
+	t.Name = name
+	if !hasLength {
+		return t, nil
+	}
+	switch t.Kind {
+	case KIND_DATE:
+		// the date without weekday
+		if length == "3" {
+			t.Size, t.order = 3, "dMy"
+			return t, nil
+		}
+	case KIND_STRING, KIND_HEX, KIND_IGNORE:
+		if length == "*" {
+			t.Size = -1
+			return t, nil
+		}
+		size, err := strconv.Atoi(length)
+		if err == nil && size > 0 {
+			t.Size = size
+			return t, nil
+		}
+	}
+	return DataType{}, fmt.Errorf("%w: %s", ErrUnknownType, dataType)
+}
+
+// String returns the name of the data type like in the message definitions of ebusd
+func (t DataType) String() string {
+	switch {
+	case t.Size < 0:
+		return t.Name + ":*"
+	case t.Kind == KIND_DATE && t.Size == 3:
+		return t.Name + ":3"
+	case (t.Kind == KIND_STRING || t.Kind == KIND_HEX || t.Kind == KIND_IGNORE) && t.Size != 1:
+		return fmt.Sprintf("%s:%d", t.Name, t.Size)
+	}
+	return t.Name
+}
+
+// Numeric returns true for the data types decoded to numbers, which dividers and value lists apply to
+func (t DataType) Numeric() bool {
+	return t.Kind == KIND_NUMBER || t.Kind == KIND_BCD || t.Kind == KIND_FLOAT
+}
+
+// DecodeNumber decodes the data of a numeric data type. ok is false for the replacement value.
+func (t DataType) DecodeNumber(data []byte) (number float64, ok bool, err error) {
+	if len(data) != t.Size {
+		return 0, false, fmt.Errorf("%w: %d bytes for %s", ErrIncomplete, len(data), t)
+	}
+	switch t.Kind {
+	case KIND_FLOAT:
+		f := math.Float32frombits(binary.LittleEndian.Uint32(data))
+		if math.IsNaN(float64(f)) {
+			return 0, false, nil
+		}
+		return float64(f), true, nil
+	case KIND_BCD:
+		if uint64(data[0]) == t.replacement {
+			return 0, false, nil
+		}
+		if data[0]>>4 > 9 || data[0]&0x0f > 9 {
+			return 0, false, fmt.Errorf("%w: %02x is no BCD", ErrInvalidValue, data[0])
+		}
+		return float64(data[0]>>4*10 + data[0]&0x0f), true, nil
+	case KIND_NUMBER:
+		var raw uint64
+		for i := len(data) - 1; i >= 0; i-- {
+			raw = raw<<8 | uint64(data[i])
+		}
+		if raw == t.replacement {
+			return 0, false, nil
+		}
+		number := float64(raw)
+		if t.signed && raw&(1<<(8*len(data)-1)) != 0 {
+			number = float64(int64(raw) - int64(1)<<(8*len(data)))
+		}
+		return number * t.factor, true, nil
+	}
+	return 0, false, fmt.Errorf("%w: %s is no number", ErrUnknownType, t)
+}
+
+// EncodeNumber encodes number with a numeric data type
+func (t DataType) EncodeNumber(number float64) ([]byte, error) {
+	switch t.Kind {
+	case KIND_FLOAT:
+		data := make([]byte, 4)
+		binary.LittleEndian.PutUint32(data, math.Float32bits(float32(number)))
+		return data, nil
+	case KIND_BCD:
+		if number < 0 || number > 99 || number != math.Trunc(number) {
+			return nil, fmt.Errorf("%w: %v for %s", ErrOutOfRange, number, t)
+		}
+		return []byte{byte(number)/10<<4 | byte(number)%10}, nil
+	case KIND_NUMBER:
+		raw := math.Round(number / t.factor)
+		bits := 8 * t.Size
+		minimum, maximum := 0.0, math.Exp2(float64(bits))-1
+		if t.signed {
+			minimum, maximum = -math.Exp2(float64(bits-1)), math.Exp2(float64(bits-1))-1
+		}
+		if raw < minimum || raw > maximum || uint64(int64(raw))&(1<<bits-1) == t.replacement {
+			return nil, fmt.Errorf("%w: %v for %s", ErrOutOfRange, number, t)
+		}
+		data := make([]byte, t.Size)
+		unsigned := uint64(int64(raw))
+		for i := range data {
+			data[i] = byte(unsigned >> (8 * i))
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("%w: %s is no number", ErrUnknownType, t)
+}
+
+// Decode decodes data like ebusd: numbers without trailing zeros, times as "hh:mm:ss" or "hh:mm", dates as
+// "dd.mm.yyyy", HEX as hex digits and strings without trailing spaces. Values marked as not available are
+// returned as REPLACEMENT_VALUE. IGN returns an empty string.
+func (t DataType) Decode(data []byte) (string, error) {
+	if t.Size >= 0 && len(data) != t.Size {
+		return "", fmt.Errorf("%w: %d bytes for %s", ErrIncomplete, len(data), t)
+	}
+	switch t.Kind {
+	case KIND_IGNORE:
+		return "", nil
+	case KIND_STRING:
+		return strings.TrimRight(string(data), " \x00"), nil
+	case KIND_HEX:
+		return hex.EncodeToString(data), nil
+	case KIND_TIME, KIND_DATE:
+		return t.decodeTimeDate(data)
+	}
+	number, ok, err := t.DecodeNumber(data)
+	if err != nil || !ok {
+		return REPLACEMENT_VALUE, err
+	}
+	return FormatNumber(number), nil
+}
+
+// FormatNumber formats number like ebusd, e.g. "21.5" or "3"
+func FormatNumber(number float64) string {
+	return strconv.FormatFloat(number, 'f', -1, 32)
+}
+
+// decodeTimeDate decodes the fields of a time or date in t.order. Invalid values are returned as REPLACEMENT_VALUE.
+func (t DataType) decodeTimeDate(data []byte) (string, error) {
+	values := make(map[byte]int)
+	for i, field := range []byte(t.order) {
+		value := int(data[i])
+		if t.Name == "BTI" || t.Name == "BTM" || t.Name == "BDA" {
+			if data[i]>>4 > 9 || data[i]&0x0f > 9 {
+				return REPLACEMENT_VALUE, nil
+			}
+			value = int(data[i]>>4)*10 + int(data[i]&0x0f)
+		}
+		values[field] = value
+	}
+	if t.Kind == KIND_TIME {
+		hour, minute, second := values['h'], values['m'], values['s']
+		// 24:00 is valid as the end of a time slot
+		if hour > 24 || minute > 59 || second > 59 || (hour == 24 && minute+second > 0) {
+			return REPLACEMENT_VALUE, nil
+		}
+		if len(t.order) == 2 {
+			return fmt.Sprintf("%02d:%02d", hour, minute), nil
+		}
+		return fmt.Sprintf("%02d:%02d:%02d", hour, minute, second), nil
+	}
+	day, month, year := values['d'], values['M'], values['y']
+	if day < 1 || day > 31 || month < 1 || month > 12 || year > 99 {
+		return REPLACEMENT_VALUE, nil
+	}
+	return fmt.Sprintf("%02d.%02d.%04d", day, month, 2000+year), nil
+}
+
+// Encode encodes value as written by ebusd (see Decode()). Strings are padded with spaces to the size of the type,
+// REPLACEMENT_VALUE is encoded as the replacement value of numbers.
+func (t DataType) Encode(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	switch t.Kind {
+	case KIND_IGNORE:
+		if t.Size < 0 {
+			return nil, fmt.Errorf("%w: IGN:* cannot be encoded", ErrInvalidValue)
+		}
+		return make([]byte, t.Size), nil
+	case KIND_STRING:
+		if t.Size < 0 {
+			return []byte(value), nil
+		}
+		if len(value) > t.Size {
+			return nil, fmt.Errorf("%w: %q longer than %d characters", ErrOutOfRange, value, t.Size)
+		}
+		return []byte(value + strings.Repeat(" ", t.Size-len(value))), nil
+	case KIND_HEX:
+		data, err := hex.DecodeString(strings.ReplaceAll(value, " ", ""))
+		if err != nil || (t.Size >= 0 && len(data) != t.Size) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidValue, value)
+		}
+		return data, nil
+	case KIND_TIME, KIND_DATE:
+		return t.encodeTimeDate(value)
+	}
+	if value == REPLACEMENT_VALUE {
+		return t.encodeReplacement(), nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidValue, value)
+	}
+	return t.EncodeNumber(number)
+}
+
+// encodeReplacement returns the raw value of a numeric data type marking the value as not available
+func (t DataType) encodeReplacement() []byte {
+	if t.Kind == KIND_FLOAT {
+		data := make([]byte, 4)
+		binary.LittleEndian.PutUint32(data, math.Float32bits(float32(math.NaN())))
+		return data
+	}
+	data := make([]byte, t.Size)
+	for i := range data {
+		data[i] = byte(t.replacement >> (8 * i))
+	}
+	return data
+}
+
+func (t DataType) encodeTimeDate(value string) ([]byte, error) {
+	values := make(map[byte]int)
+	if t.Kind == KIND_TIME {
+		var hour, minute, second int
+		n, _ := fmt.Sscanf(value, "%d:%d:%d", &hour, &minute, &second)
+		if n < 2 || hour < 0 || hour > 24 || minute < 0 || minute > 59 || second < 0 || second > 59 || (hour == 24 && minute+second > 0) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidValue, value)
+		}
+		values['h'], values['m'], values['s'] = hour, minute, second
+	} else {
+		date, err := time.Parse("02.01.2006", value)
+		if err != nil || date.Year() < 2000 || date.Year() > 2099 {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidValue, value)
+		}
+		values['d'], values['M'], values['y'] = date.Day(), int(date.Month()), date.Year()-2000
+		// HDA counts the weekdays from 1 (Monday) to 7 (Sunday), BDA from 0 (Monday) to 6 (Sunday)
+		values['w'] = (int(date.Weekday())+6)%7 + 1
+		if t.Name == "BDA" {
+			values['w']--
+		}
+	}
+	data := make([]byte, 0, len(t.order))
+	for _, field := range []byte(t.order) {
+		value := values[field]
+		if t.Name == "BTI" || t.Name == "BTM" || t.Name == "BDA" {
+			value = value/10<<4 | value%10
+		}
+		data = append(data, byte(value))
+	}
+	return data, nil
+}
@@ -0,0 +1,109 @@
+package ebus
+
+import (
+	"errors"
+	"testing"
+)
+
+// The vectors follow the definitions of the data types in the eBUS specification (application layer, chapter 6)
+// and the test data of ebusd. Multi-byte values are sent with the low byte first.
+func TestDecodeNumber(t *testing.T) {
+	tests := []struct {
+		dataType string
+		data     string
+		number   float64
+		ok       bool // false for the replacement value
+		err      error
+	}{
+		{"BCD", "00", 0, true, nil},
+		{"BCD", "26", 26, true, nil},
+		{"BCD", "99", 99, true, nil},
+		{"BCD", "ff", 0, false, nil},
+		{"BCD", "1a", 0, false, ErrInvalidValue},
+		{"BCD", "a1", 0, false, ErrInvalidValue},
+		{"D1B", "00", 0, true, nil},
+		{"D1B", "01", 1, true, nil},
+		{"D1B", "7f", 127, true, nil},
+		{"D1B", "81", -127, true, nil},
+		{"D1B", "80", 0, false, nil},
+		{"D1C", "00", 0, true, nil},
+		{"D1C", "64", 50, true, nil},
+		{"D1C", "c8", 100, true, nil},
+		{"D1C", "ff", 0, false, nil},
+		{"D2B", "0000", 0, true, nil},
+		{"D2B", "0100", 0.00390625, true, nil},
+		{"D2B", "ffff", -0.00390625, true, nil},
+		{"D2B", "00ff", -1, true, nil},
+		{"D2B", "0180", -127.99609375, true, nil},
+		{"D2B", "ff7f", 127.99609375, true, nil},
+		{"D2B", "0080", 0, false, nil},
+		{"D2C", "0000", 0, true, nil},
+		{"D2C", "0100", 0.0625, true, nil},
+		{"D2C", "ffff", -0.0625, true, nil},
+		{"D2C", "f0ff", -1, true, nil},
+		{"D2C", "0180", -2047.9375, true, nil},
+		{"D2C", "ff7f", 2047.9375, true, nil},
+		{"D2C", "0080", 0, false, nil},
+		{"FLT", "0000", 0, true, nil},
+		{"FLT", "0100", 0.001, true, nil},
+		{"FLT", "ffff", -0.001, true, nil},
+		{"FLT", "0180", -32.767, true, nil},
+		{"FLT", "ff7f", 32.767, true, nil},
+		{"FLT", "0080", 0, false, nil},
+		{"UIN", "ffff", 0, false, nil},
+		{"EXP", "0000a041", 20, true, nil},
+		{"EXP", "0000c07f", 0, false, nil},
+		{"D2C", "01", 0, false, ErrIncomplete},
+	}
+	for _, tt := range tests {
+		dataType, err := ParseDataType(tt.dataType)
+		if err != nil {
+			t.Fatalf("ParseDataType(%s) failed: %s", tt.dataType, err)
+		}
+		number, ok, err := dataType.DecodeNumber(decodeHex(t, tt.data))
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s %s: error %v, expected %v", tt.dataType, tt.data, err, tt.err)
+			continue
+		}
+		if ok != tt.ok || (ok && number != tt.number) {
+			t.Errorf("%s %s = %v, %t, expected %v, %t", tt.dataType, tt.data, number, ok, tt.number, tt.ok)
+		}
+	}
+}
+
+func TestDecodeTimeDate(t *testing.T) {
+	tests := []struct {
+		dataType string
+		data     string
+		value    string
+	}{
+		{"HDA:3", "1a0a18", "26.10.2024"},
+		{"HDA:3", "010100", "01.01.2000"},
+		{"HDA:3", "1f0c63", "31.12.2099"},
+		{"HDA:3", "000118", REPLACEMENT_VALUE},
+		{"HDA:3", "010d18", REPLACEMENT_VALUE},
+		{"HDA", "1a0a0618", "26.10.2024"},
+		{"BDA", "26100524", "26.10.2024"},
+		{"BDA", "31120399", "31.12.2099"},
+		{"BDA", "ffffffff", REPLACEMENT_VALUE},
+		{"HTI", "0e1e2d", "14:30:45"},
+		{"HTI", "000000", "00:00:00"},
+		{"HTI", "183b3b", REPLACEMENT_VALUE},
+		{"BTI", "453014", "14:30:45"},
+		{"BTI", "4a3014", REPLACEMENT_VALUE},
+		{"HTM", "0e1e", "14:30"},
+		{"HTM", "1800", "24:00"},
+		{"HTM", "1801", REPLACEMENT_VALUE},
+		{"BTM", "3014", "14:30"},
+	}
+	for _, tt := range tests {
+		dataType, err := ParseDataType(tt.dataType)
+		if err != nil {
+			t.Fatalf("ParseDataType(%s) failed: %s", tt.dataType, err)
+		}
+		value, err := dataType.Decode(decodeHex(t, tt.data))
+		if err != nil || value != tt.value {
+			t.Errorf("%s %s = %q, %v, expected %q", tt.dataType, tt.data, value, err, tt.value)
+		}
+	}
+}
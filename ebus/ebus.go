@@ -0,0 +1,100 @@
+// Package ebus decodes raw eBUS telegrams and the data types used in them. Telegrams are parsed from the symbols
+// seen on the bus (QQ ZZ PB SB NN data CRC, ACK, slave response NN data CRC, ACK), with the escape sequences
+// of A9 and AA removed and the CRCs verified. The data types are the base types of ebusd (e.g. "D2C" for DATA2c
+// or "HDA:3"), so the message definitions parsed by the package ebusdcsv can be used to decode the data
+// the same way ebusd does.
+package ebus
+
+import "errors"
+
+const (
+	SYN       = 0xaa // synchronization symbol, sent by the bus master when the bus is idle
+	ESC       = 0xa9 // escape symbol: A9 is sent as A9 00, AA as A9 01
+	ACK       = 0x00
+	NAK       = 0xff
+	BROADCAST = 0xfe // destination address of broadcast telegrams
+
+	PB_IDENTIFICATION = 0x07 // primary command of the device identification (07 04)
+	SB_IDENTIFICATION = 0x04
+)
+
+var (
+	ErrCRC           = errors.New("CRC error")
+	ErrInvalidEscape = errors.New("invalid escape sequence")
+	ErrNAK           = errors.New("NAK received")
+	ErrACK           = errors.New("ACK error")
+	ErrIncomplete    = errors.New("telegram incomplete")
+	ErrUnknownType   = errors.New("unknown data type")
+	ErrInvalidValue  = errors.New("invalid value")
+	ErrOutOfRange    = errors.New("value out of valid range")
+	ErrMissingValue  = errors.New("missing value")
+)
+
+// IsMaster returns true for the 25 master addresses of the eBUS (both nibbles 0, 1, 3, 7 or F)
+func IsMaster(address byte) bool {
+	isMasterNibble := func(nibble byte) bool {
+		return nibble == 0x0 || nibble == 0x1 || nibble == 0x3 || nibble == 0x7 || nibble == 0xf
+	}
+	return isMasterNibble(address>>4) && isMasterNibble(address&0x0f)
+}
+
+// Escape returns data as sent on the bus, with the symbols SYN and ESC replaced by escape sequences
+func Escape(data []byte) []byte {
+	escaped := make([]byte, 0, len(data))
+	for _, symbol := range data {
+		switch symbol {
+		case ESC:
+			escaped = append(escaped, ESC, 0x00)
+		case SYN:
+			escaped = append(escaped, ESC, 0x01)
+		default:
+			escaped = append(escaped, symbol)
+		}
+	}
+	return escaped
+}
+
+// Unescape returns the data of symbols as sent on the bus
+func Unescape(symbols []byte) ([]byte, error) {
+	data := make([]byte, 0, len(symbols))
+	for i := 0; i < len(symbols); i++ {
+		if symbols[i] != ESC {
+			data = append(data, symbols[i])
+			continue
+		}
+		if i+1 == len(symbols) || symbols[i+1] > 0x01 {
+			return nil, ErrInvalidEscape
+		}
+		i++
+		if symbols[i] == 0x00 {
+			data = append(data, ESC)
+		} else {
+			data = append(data, SYN)
+		}
+	}
+	return data, nil
+}
+
+// CRCUpdate adds a symbol as sent on the bus (escaped) to crc. The CRC-8 of the eBUS uses the polynomial
+// x^8+x^7+x^4+x^3+x+1 (0x9B) and starts with 0.
+func CRCUpdate(crc, symbol byte) byte {
+	for i := 0; i < 8; i++ {
+		polynomial := byte(0)
+		if crc&0x80 != 0 {
+			polynomial = 0x9b
+		}
+		crc = crc<<1 | symbol>>7
+		crc ^= polynomial
+		symbol <<= 1
+	}
+	return crc
+}
+
+// CRC returns the CRC of data (unescaped). The CRC is calculated over the escaped symbols.
+func CRC(data []byte) byte {
+	var crc byte
+	for _, symbol := range Escape(data) {
+		crc = CRCUpdate(crc, symbol)
+	}
+	return crc
+}
@@ -0,0 +1,121 @@
+package ebus
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("invalid hex %q: %s", s, err)
+	}
+	return data
+}
+
+func TestCRC(t *testing.T) {
+	tests := []struct {
+		data string
+		crc  byte
+	}{
+		{"", 0x00},
+		{"1008b5110101", 0x89}, // B511 query of the controller to the heater, as seen on the bus
+		{"01", 0x01},
+		{"0100", 0x9b}, // first entries of the CRC table of the eBUS specification
+		{"0200", 0xad},
+		{"0300", 0x36},
+		{"0400", 0xc1},
+		{"0f00", 0xee},
+	}
+	for _, tt := range tests {
+		if crc := CRC(decodeHex(t, tt.data)); crc != tt.crc {
+			t.Errorf("CRC(%s) = %02x, expected %02x", tt.data, crc, tt.crc)
+		}
+	}
+}
+
+func TestCRCOverEscapedSymbols(t *testing.T) {
+	// the CRC covers the symbols as sent on the bus, i.e. A9 00 for A9 and A9 01 for AA
+	tests := []struct {
+		data    string
+		escaped string
+	}{
+		{"15b50903a9aa01", "15b50903a900a90101"},
+		{"a9", "a900"},
+		{"aa", "a901"},
+	}
+	for _, tt := range tests {
+		var crc byte
+		for _, symbol := range decodeHex(t, tt.escaped) {
+			crc = CRCUpdate(crc, symbol)
+		}
+		if CRC(decodeHex(t, tt.data)) != crc {
+			t.Errorf("CRC(%s) = %02x, expected the CRC %02x of the escaped symbols %s", tt.data, CRC(decodeHex(t, tt.data)), crc, tt.escaped)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	tests := []struct {
+		data    string
+		escaped string
+	}{
+		{"", ""},
+		{"1008b5110101", "1008b5110101"},
+		{"a9", "a900"},
+		{"aa", "a901"},
+		{"a9aa", "a900a901"},
+		{"00a901aa00", "00a90001a90100"},
+	}
+	for _, tt := range tests {
+		if escaped := hex.EncodeToString(Escape(decodeHex(t, tt.data))); escaped != tt.escaped {
+			t.Errorf("Escape(%s) = %s, expected %s", tt.data, escaped, tt.escaped)
+		}
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	tests := []struct {
+		symbols string
+		data    string
+		err     error
+	}{
+		{"", "", nil},
+		{"1008b5110101", "1008b5110101", nil},
+		{"a900", "a9", nil},
+		{"a901", "aa", nil},
+		{"a900a901", "a9aa", nil},
+		{"00a90001a90100", "00a901aa00", nil},
+		{"a902", "", ErrInvalidEscape},
+		{"a9ff", "", ErrInvalidEscape},
+		{"01a9", "", ErrInvalidEscape},
+	}
+	for _, tt := range tests {
+		data, err := Unescape(decodeHex(t, tt.symbols))
+		if !errors.Is(err, tt.err) {
+			t.Errorf("Unescape(%s) error %v, expected %v", tt.symbols, err, tt.err)
+			continue
+		}
+		if err == nil && !bytes.Equal(data, decodeHex(t, tt.data)) {
+			t.Errorf("Unescape(%s) = %x, expected %s", tt.symbols, data, tt.data)
+		}
+	}
+}
+
+func TestIsMaster(t *testing.T) {
+	tests := []struct {
+		address byte
+		master  bool
+	}{
+		{0x00, true}, {0x10, true}, {0x31, true}, {0x37, true}, {0x7f, true}, {0xff, true}, {0xf3, true},
+		{0x08, false}, {0x15, false}, {0x76, false}, {0xfe, false}, {0x02, false}, {0x50, false},
+	}
+	for _, tt := range tests {
+		if IsMaster(tt.address) != tt.master {
+			t.Errorf("IsMaster(%02x) = %t, expected %t", tt.address, !tt.master, tt.master)
+		}
+	}
+}
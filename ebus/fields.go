@@ -0,0 +1,138 @@
+package ebus
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/WulfgarW/sensonetEbus/ebusdcsv"
+)
+
+// DecodeFields decodes data with fields of a message definition, whose templates are resolved to base types
+// (see ebusdcsv.Templates.Resolve()). Dividers and value lists are applied. The values of several fields are
+// separated by ";" like in the replies of ebusd, ignored fields (IGN) are skipped.
+func DecodeFields(fields []ebusdcsv.Field, data []byte) (string, error) {
+	var values []string
+	for _, field := range fields {
+		t, err := fieldType(field)
+		if err != nil {
+			return "", err
+		}
+		size := t.Size
+		if size < 0 {
+			size = len(data)
+		}
+		if len(data) < size {
+			return "", fmt.Errorf("%w: %d bytes missing for %s", ErrIncomplete, size-len(data), t)
+		}
+		value, err := DecodeField(t, field, data[:size])
+		if err != nil {
+			return "", err
+		}
+		data = data[size:]
+		if t.Kind != KIND_IGNORE {
+			values = append(values, value)
+		}
+	}
+	return strings.Join(values, ";"), nil
+}
+
+// DecodeField decodes data with the data type t of field, applying the divider and the value list of field
+func DecodeField(t DataType, field ebusdcsv.Field, data []byte) (string, error) {
+	if !t.Numeric() {
+		return t.Decode(data)
+	}
+	number, ok, err := t.DecodeNumber(data)
+	if err != nil || !ok {
+		return REPLACEMENT_VALUE, err
+	}
+	number = applyDivider(number, field.Divider)
+	if field.Values != nil {
+		if name, ok := field.Values[int(number)]; ok && float64(int(number)) == number {
+			return name, nil
+		}
+	}
+	return FormatNumber(number), nil
+}
+
+func applyDivider(number, divider float64) float64 {
+	switch {
+	case divider > 0:
+		return number / divider
+	case divider < 0:
+		return number * -divider
+	}
+	return number
+}
+
+// EncodeFields encodes value (several values separated by ";") with the resolved fields. Ignored fields are filled with zeros.
+func EncodeFields(fields []ebusdcsv.Field, value string) ([]byte, error) {
+	values := strings.Split(value, ";")
+	var data []byte
+	for _, field := range fields {
+		t, err := fieldType(field)
+		if err != nil {
+			return nil, err
+		}
+		if t.Kind == KIND_IGNORE {
+			encoded, err := t.Encode("")
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, encoded...)
+			continue
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("%w for %s", ErrMissingValue, field.Name)
+		}
+		encoded, err := EncodeField(t, field, strings.TrimSpace(values[0]))
+		if err != nil {
+			return nil, err
+		}
+		values = values[1:]
+		data = append(data, encoded...)
+	}
+	return data, nil
+}
+
+// EncodeField encodes value with the data type t of field. Names of the value list of field are accepted
+// as well as numbers, the divider is reverted.
+func EncodeField(t DataType, field ebusdcsv.Field, value string) ([]byte, error) {
+	if !t.Numeric() || value == REPLACEMENT_VALUE {
+		return t.Encode(value)
+	}
+	number, err := parseNumber(value, field)
+	if err != nil {
+		return nil, err
+	}
+	// value = raw / divider
+	switch {
+	case field.Divider > 0:
+		number = number * field.Divider
+	case field.Divider < 0:
+		number = number / -field.Divider
+	}
+	return t.EncodeNumber(number)
+}
+
+// parseNumber parses value as number or looks it up in the value list of field
+func parseNumber(value string, field ebusdcsv.Field) (float64, error) {
+	for number, name := range field.Values {
+		if name == value {
+			return float64(number), nil
+		}
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidValue, value)
+	}
+	return number, nil
+}
+
+// fieldType returns the data type of a field resolved to a single base type
+func fieldType(field ebusdcsv.Field) (DataType, error) {
+	if len(field.Types) != 1 {
+		return DataType{}, fmt.Errorf("%w: field %s not resolved to a base type: %v", ErrUnknownType, field.Name, field.Types)
+	}
+	return ParseDataType(field.Types[0])
+}
@@ -0,0 +1,240 @@
+package ebus
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+type TelegramType int
+
+const (
+	TELEGRAM_BROADCAST     TelegramType = iota // no acknowledge and no response
+	TELEGRAM_MASTER_MASTER                     // acknowledged by the destination master, no response
+	TELEGRAM_MASTER_SLAVE                      // acknowledged and answered by the slave
+)
+
+func (t TelegramType) String() string {
+	switch t {
+	case TELEGRAM_BROADCAST:
+		return "broadcast"
+	case TELEGRAM_MASTER_MASTER:
+		return "master-master"
+	case TELEGRAM_MASTER_SLAVE:
+		return "master-slave"
+	}
+	return "unknown"
+}
+
+// Telegram is a telegram sent on the bus
+type Telegram struct {
+	QQ       byte   // source (master) address
+	ZZ       byte   // destination address
+	PBSB     uint16 // primary and secondary command, e.g. 0xb524
+	Data     []byte // data of the master part
+	Response []byte // data of the response of the slave, nil for broadcast and master-master telegrams
+}
+
+// TypeOf returns the type of a telegram to the destination zz
+func TypeOf(zz byte) TelegramType {
+	switch {
+	case zz == BROADCAST:
+		return TELEGRAM_BROADCAST
+	case IsMaster(zz):
+		return TELEGRAM_MASTER_MASTER
+	}
+	return TELEGRAM_MASTER_SLAVE
+}
+
+func (t Telegram) Type() TelegramType {
+	return TypeOf(t.ZZ)
+}
+
+// Master returns the master part (QQ ZZ PB SB NN data CRC) unescaped
+func (t Telegram) Master() []byte {
+	master := append([]byte{t.QQ, t.ZZ, byte(t.PBSB >> 8), byte(t.PBSB), byte(len(t.Data))}, t.Data...)
+	return append(master, CRC(master))
+}
+
+// Symbols returns the telegram as sent on the bus without the surrounding SYN symbols
+func (t Telegram) Symbols() []byte {
+	symbols := Escape(t.Master())
+	if t.Type() == TELEGRAM_BROADCAST {
+		return symbols
+	}
+	symbols = append(symbols, ACK)
+	if t.Type() == TELEGRAM_MASTER_MASTER {
+		return symbols
+	}
+	response := append([]byte{byte(len(t.Response))}, t.Response...)
+	symbols = append(symbols, Escape(append(response, CRC(response)))...)
+	return append(symbols, ACK)
+}
+
+// String returns the telegram in the notation of ebusd, e.g. "3115b52406020003002600 / 08000000000000c03f"
+func (t Telegram) String() string {
+	master := hex.EncodeToString(append([]byte{t.QQ, t.ZZ, byte(t.PBSB >> 8), byte(t.PBSB), byte(len(t.Data))}, t.Data...))
+	if t.Type() != TELEGRAM_MASTER_SLAVE {
+		return master
+	}
+	return master + " / " + hex.EncodeToString(append([]byte{byte(len(t.Response))}, t.Response...))
+}
+
+// ParseTelegramHex parses the symbols of a telegram given as hex, e.g. "3115b524060200030026008d 00 08000000000000c03f2b 00".
+// Spaces are ignored.
+func ParseTelegramHex(symbols string) (Telegram, error) {
+	data, err := hex.DecodeString(strings.ReplaceAll(symbols, " ", ""))
+	if err != nil {
+		return Telegram{}, fmt.Errorf("%w: %s", ErrInvalidValue, err)
+	}
+	return ParseTelegram(data)
+}
+
+// ParseTelegram parses the symbols of a telegram as seen on the bus. Leading and trailing SYN symbols are skipped.
+// A master part or a response answered with NAK has to be followed by its repetition, like on the bus.
+func ParseTelegram(symbols []byte) (Telegram, error) {
+	for len(symbols) > 0 && symbols[0] == SYN {
+		symbols = symbols[1:]
+	}
+	for len(symbols) > 0 && symbols[len(symbols)-1] == SYN {
+		symbols = symbols[:len(symbols)-1]
+	}
+	p := &parser{symbols: symbols}
+	var telegram Telegram
+	for attempt := 0; ; attempt++ {
+		master, err := p.part(5, 4)
+		if err != nil && err != ErrCRC {
+			return telegram, err
+		}
+		telegram = Telegram{QQ: master[0], ZZ: master[1], PBSB: uint16(master[2])<<8 | uint16(master[3]), Data: master[5:]}
+		if telegram.Type() == TELEGRAM_BROADCAST {
+			if err == nil && p.pos < len(p.symbols) {
+				return telegram, fmt.Errorf("%w: %d symbols after broadcast", ErrInvalidValue, len(p.symbols)-p.pos)
+			}
+			return telegram, err
+		}
+		ack, ackErr := p.ack()
+		if ackErr != nil {
+			return telegram, ackErr
+		}
+		if ack == ACK && err == nil {
+			break
+		}
+		if ack == ACK {
+			return telegram, err
+		}
+		if attempt > 0 {
+			return telegram, ErrNAK
+		}
+	}
+	if telegram.Type() == TELEGRAM_MASTER_MASTER {
+		return telegram, p.end()
+	}
+	for attempt := 0; ; attempt++ {
+		response, err := p.part(1, 0)
+		if err != nil && err != ErrCRC {
+			return telegram, err
+		}
+		telegram.Response = response[1:]
+		ack, ackErr := p.ack()
+		if ackErr != nil {
+			return telegram, ackErr
+		}
+		if ack == ACK && err == nil {
+			break
+		}
+		if ack == ACK {
+			return telegram, err
+		}
+		if attempt > 0 {
+			return telegram, ErrNAK
+		}
+	}
+	return telegram, p.end()
+}
+
+// parser reads the parts of a telegram from its symbols
+type parser struct {
+	symbols []byte
+	pos     int
+}
+
+// byte returns the next unescaped byte and adds its symbols to crc
+func (p *parser) byte(crc *byte) (byte, error) {
+	if p.pos >= len(p.symbols) {
+		return 0, ErrIncomplete
+	}
+	symbol := p.symbols[p.pos]
+	p.pos++
+	*crc = CRCUpdate(*crc, symbol)
+	if symbol == SYN {
+		return 0, fmt.Errorf("%w: SYN inside telegram", ErrIncomplete)
+	}
+	if symbol != ESC {
+		return symbol, nil
+	}
+	if p.pos >= len(p.symbols) {
+		return 0, ErrIncomplete
+	}
+	next := p.symbols[p.pos]
+	p.pos++
+	*crc = CRCUpdate(*crc, next)
+	switch next {
+	case 0x00:
+		return ESC, nil
+	case 0x01:
+		return SYN, nil
+	}
+	return 0, ErrInvalidEscape
+}
+
+// part reads a part of length header bytes, whose last byte is NN followed by NN data bytes and the CRC.
+// lengthIndex is the index of NN in the part. ErrCRC is returned with the part read.
+func (p *parser) part(header, lengthIndex int) ([]byte, error) {
+	var crc byte
+	var part []byte
+	for i := 0; i < header; i++ {
+		b, err := p.byte(&crc)
+		if err != nil {
+			return nil, err
+		}
+		part = append(part, b)
+	}
+	for i := 0; i < int(part[lengthIndex]); i++ {
+		b, err := p.byte(&crc)
+		if err != nil {
+			return nil, err
+		}
+		part = append(part, b)
+	}
+	expected := crc
+	received, err := p.byte(&crc)
+	if err != nil {
+		return nil, err
+	}
+	if received != expected {
+		return part, ErrCRC
+	}
+	return part, nil
+}
+
+// ack reads an ACK or NAK symbol
+func (p *parser) ack() (byte, error) {
+	if p.pos >= len(p.symbols) {
+		return 0, ErrIncomplete
+	}
+	symbol := p.symbols[p.pos]
+	p.pos++
+	if symbol != ACK && symbol != NAK {
+		return symbol, fmt.Errorf("%w: %02x instead of ACK or NAK", ErrACK, symbol)
+	}
+	return symbol, nil
+}
+
+// end checks that all symbols were parsed
+func (p *parser) end() error {
+	if p.pos < len(p.symbols) {
+		return fmt.Errorf("%w: %d symbols after the end of the telegram", ErrInvalidValue, len(p.symbols)-p.pos)
+	}
+	return nil
+}
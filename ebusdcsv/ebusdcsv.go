@@ -0,0 +1,269 @@
+// Package ebusdcsv parses the message definition files of ebusd (see https://github.com/john30/ebusd/wiki/4.-Configuration),
+// e.g. the files 15.ctlv2.csv and 76.vwz00.csv in ebusd-config-files, and the template files (_templates.csv)
+// defining data types like tempv, energy4 or hoursum2.
+package ebusdcsv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	TYPE_READ   = "r"
+	TYPE_WRITE  = "w"
+	TYPE_UPDATE = "u" // passively received message
+
+	PART_MASTER = "m"
+	PART_SLAVE  = "s"
+
+	// columns of a message definition. The fields follow with FIELD_COLUMNS columns each.
+	COLUMN_TYPE    = 0
+	COLUMN_CIRCUIT = 1
+	COLUMN_NAME    = 2
+	COLUMN_COMMENT = 3
+	COLUMN_QQ      = 4
+	COLUMN_ZZ      = 5
+	COLUMN_PBSB    = 6
+	COLUMN_ID      = 7
+	COLUMN_FIELDS  = 8
+	FIELD_COLUMNS  = 6 // name, part, data types/templates, divider/values, unit, comment
+)
+
+// File is a parsed message definition file
+type File struct {
+	Name     string // file name, e.g. "15.ctlv2.csv"
+	ZZ       string // slave address taken from the file name, e.g. "15"
+	Circuit  string // circuit taken from the file name, e.g. "ctlv2"
+	Messages []Message
+}
+
+// Message is a message definition. Lines with several types (e.g. "r;w") result in one Message per type.
+// The values of the default line preceding the message (PBSB, leading ID bytes, leading fields) are merged in.
+type Message struct {
+	Type     string // TYPE_READ, TYPE_WRITE or TYPE_UPDATE
+	Priority int    // poll priority of read messages ("r1" to "r9"), 0 if not polled
+	Circuit  string
+	Name     string
+	Comment  string
+	QQ       string // hex address of the master, empty for any
+	ZZ       string // hex address of the slave
+	PBSB     string // primary and secondary command, e.g. "B524"
+	ID       string // further command bytes as hex, e.g. "020000000200"
+	Fields   []Field
+	Line     int // line of the definition in the file
+}
+
+// Field is a field of a message
+type Field struct {
+	Name    string
+	Part    string         // PART_MASTER or PART_SLAVE, empty for the default (slave part of read messages, master part of others)
+	Types   []string       // data types or templates, e.g. ["IGN:4"], ["tempv"] or ["temp1", "temp2"]
+	Divider float64        // divider of the value, negative for a multiplier, 0 if none
+	Values  map[int]string // value list, e.g. 0="off", 1="on"
+	Unit    string
+	Comment string
+}
+
+// DataTypes returns the data types or templates of all fields in order
+func (m Message) DataTypes() []string {
+	var types []string
+	for _, field := range m.Fields {
+		types = append(types, field.Types...)
+	}
+	return types
+}
+
+// Key returns the key ebusd identifies the message with: type, circuit and name (case insensitive)
+func (m Message) Key() string {
+	return m.Type + "|" + strings.ToLower(m.Circuit) + "|" + strings.ToLower(m.Name)
+}
+
+// CircuitOfFile returns the slave address and the circuit name ebusd derives from the name of a definition file,
+// e.g. "15" and "ctlv2" for "vaillant/15.ctlv2.csv" or "08" and "hmu" for "08.hmu.HW5103.csv"
+func CircuitOfFile(file string) (zz, circuit string) {
+	parts := strings.Split(strings.TrimSuffix(path.Base(file), ".csv"), ".")
+	if len(parts) > 1 && len(parts[0]) == 2 {
+		if _, err := strconv.ParseUint(parts[0], 16, 8); err == nil {
+			return strings.ToLower(parts[0]), parts[1]
+		}
+	}
+	return "", parts[0]
+}
+
+// ParseFile parses the message definitions read from r. name is the file name used for the default
+// slave address and circuit (see CircuitOfFile()). Comment lines and instructions like "!include" are skipped.
+func ParseFile(name string, r io.Reader) (*File, error) {
+	file := &File{Name: path.Base(name)}
+	file.ZZ, file.Circuit = CircuitOfFile(name)
+	reader := newReader(r)
+	defaults := make(map[string]Message)
+	for {
+		columns, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		for len(columns) < COLUMN_FIELDS {
+			columns = append(columns, "")
+		}
+		typeColumn := strings.TrimSpace(columns[COLUMN_TYPE])
+		if strings.HasPrefix(typeColumn, "!") {
+			continue
+		}
+		fields, err := parseFields(columns[COLUMN_FIELDS:])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if strings.HasPrefix(typeColumn, "*") {
+			for _, messageType := range strings.Split(strings.TrimPrefix(typeColumn, "*"), ";") {
+				messageType, _, err = parseType(messageType)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", line, err)
+				}
+				defaults[messageType] = Message{Circuit: columns[COLUMN_CIRCUIT], Name: columns[COLUMN_NAME], QQ: columns[COLUMN_QQ],
+					ZZ: columns[COLUMN_ZZ], PBSB: columns[COLUMN_PBSB], ID: columns[COLUMN_ID], Fields: fields}
+			}
+			continue
+		}
+		if columns[COLUMN_NAME] == "" {
+			continue
+		}
+		for _, messageType := range strings.Split(typeColumn, ";") {
+			message := Message{Circuit: file.Circuit, Name: columns[COLUMN_NAME], Comment: columns[COLUMN_COMMENT], QQ: columns[COLUMN_QQ],
+				ZZ: file.ZZ, PBSB: columns[COLUMN_PBSB], ID: columns[COLUMN_ID], Line: line}
+			message.Type, message.Priority, err = parseType(messageType)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			defaultMessage, ok := defaults[message.Type]
+			if ok && (message.PBSB == "" || strings.EqualFold(message.PBSB, defaultMessage.PBSB)) {
+				if defaultMessage.Circuit != "" {
+					message.Circuit = defaultMessage.Circuit
+				}
+				message.Name = defaultMessage.Name + message.Name
+				if message.QQ == "" {
+					message.QQ = defaultMessage.QQ
+				}
+				if defaultMessage.ZZ != "" {
+					message.ZZ = defaultMessage.ZZ
+				}
+				message.PBSB = defaultMessage.PBSB
+				message.ID = defaultMessage.ID + message.ID
+				message.Fields = append(message.Fields, defaultMessage.Fields...)
+			}
+			if columns[COLUMN_CIRCUIT] != "" {
+				message.Circuit = columns[COLUMN_CIRCUIT]
+			}
+			if columns[COLUMN_ZZ] != "" {
+				message.ZZ = columns[COLUMN_ZZ]
+			}
+			message.Fields = append(message.Fields, fields...)
+			file.Messages = append(file.Messages, message)
+		}
+	}
+	return file, nil
+}
+
+func newReader(r io.Reader) *csv.Reader {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	return reader
+}
+
+// parseType parses the type of a message, e.g. "r1" to TYPE_READ with priority 1
+func parseType(messageType string) (string, int, error) {
+	messageType = strings.ToLower(strings.TrimSpace(messageType))
+	if messageType == "" {
+		return "", 0, errors.New("missing message type")
+	}
+	base := messageType[:1]
+	switch base {
+	case TYPE_READ, TYPE_WRITE, TYPE_UPDATE:
+	default:
+		return "", 0, fmt.Errorf("invalid message type %q", messageType)
+	}
+	if len(messageType) == 1 {
+		return base, 0, nil
+	}
+	priority, err := strconv.Atoi(messageType[1:])
+	if err != nil || priority < 1 || priority > 9 {
+		return "", 0, fmt.Errorf("invalid message type %q", messageType)
+	}
+	return base, priority, nil
+}
+
+// parseFields parses the field columns of a message. Fields without data type are skipped.
+func parseFields(columns []string) ([]Field, error) {
+	var fields []Field
+	for i := 0; i < len(columns); i += FIELD_COLUMNS {
+		column := func(j int) string {
+			if i+j < len(columns) {
+				return strings.TrimSpace(columns[i+j])
+			}
+			return ""
+		}
+		field, err := parseField(column(0), column(1), column(2), column(3), column(4), column(5))
+		if err != nil {
+			return nil, err
+		}
+		if len(field.Types) > 0 {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+func parseField(name, part, types, dividerValues, unit, comment string) (Field, error) {
+	field := Field{Name: name, Part: strings.ToLower(part), Unit: unit, Comment: comment}
+	switch field.Part {
+	case "", PART_MASTER, PART_SLAVE:
+	default:
+		return field, fmt.Errorf("invalid part %q of field %s", part, name)
+	}
+	for _, dataType := range strings.Split(types, ";") {
+		if dataType = strings.TrimSpace(dataType); dataType != "" {
+			field.Types = append(field.Types, dataType)
+		}
+	}
+	var err error
+	field.Divider, field.Values, err = parseDividerValues(dividerValues)
+	return field, err
+}
+
+// parseDividerValues parses the divider/values column, e.g. "10", "-60" or "0=off;1=on;0x0b=error"
+func parseDividerValues(column string) (float64, map[int]string, error) {
+	if column == "" {
+		return 0, nil, nil
+	}
+	if !strings.Contains(column, "=") {
+		divider, err := strconv.ParseFloat(column, 64)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid divider %q", column)
+		}
+		return divider, nil, nil
+	}
+	values := make(map[int]string)
+	for _, entry := range strings.Split(column, ";") {
+		key, value, found := strings.Cut(entry, "=")
+		if !found {
+			return 0, nil, fmt.Errorf("invalid value list %q", column)
+		}
+		number, err := strconv.ParseInt(strings.TrimSpace(key), 0, 64)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid value list %q", column)
+		}
+		values[int(number)] = strings.TrimSpace(value)
+	}
+	return 0, values, nil
+}
@@ -0,0 +1,180 @@
+package ebusdcsv
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// definitionsWithDefaults uses default lines like the B524 and B555 sections of 15.ctlv2.csv
+const definitionsWithDefaults = `# type (r[1-9]wu),circuit,name,[comment],[QQ],ZZ,PBSB,[ID],field1,part (m/s),datatypes/templates,divider/values,unit,comment
+!include,_templates.csv,,,,,,,,,,,,
+*r,,,,,,B524,02000000,,,IGN:4,,,
+*w,,,,,,B524,02010000,,,,,,
+r;w,,HwcTempDesired,Solltemperatur,,,,0400,,,tempv,,,setpoint
+r1,,HwcStorageTemp,Speichertemperatur,,,,0500,,,tempv,,,
+r,,Date,Datum,,,B509,0d2c00,,,date,,,
+w,,HwcSFMode,,,,,0600,mode,m,UCH,0=auto;1=load,,
+*r,,,,,,,,,,,,,
+r,,unknownValue.a0,,,,B555,a0,,,HEX:8,,,
+*r,,hwcTimer.,,,,B555,a50002,,,,,,
+r,,Monday0,Zeitfenster,,,,0000,,,rTimeSlotWithTemp,,,
+r,vwz00,Monday1,,,76,,0001,,,rTimeSlotWithTemp,,,
+`
+
+func TestParseFileDefaults(t *testing.T) {
+	file, err := ParseFile("vaillant/15.ctlv2.csv", strings.NewReader(definitionsWithDefaults))
+	if err != nil {
+		t.Fatalf("ParseFile() failed: %s", err)
+	}
+	if file.Name != "15.ctlv2.csv" || file.ZZ != "15" || file.Circuit != "ctlv2" {
+		t.Errorf("file %s, ZZ %s, circuit %s, expected 15.ctlv2.csv, 15, ctlv2", file.Name, file.ZZ, file.Circuit)
+	}
+	tempv := Field{Types: []string{"tempv"}}
+	ign4 := Field{Types: []string{"IGN:4"}}
+	expected := []Message{
+		{Type: TYPE_READ, Circuit: "ctlv2", Name: "HwcTempDesired", Comment: "Solltemperatur", ZZ: "15", PBSB: "B524", ID: "020000000400",
+			Fields: []Field{ign4, {Types: []string{"tempv"}, Comment: "setpoint"}}, Line: 5},
+		{Type: TYPE_WRITE, Circuit: "ctlv2", Name: "HwcTempDesired", Comment: "Solltemperatur", ZZ: "15", PBSB: "B524", ID: "020100000400",
+			Fields: []Field{{Types: []string{"tempv"}, Comment: "setpoint"}}, Line: 5},
+		{Type: TYPE_READ, Priority: 1, Circuit: "ctlv2", Name: "HwcStorageTemp", Comment: "Speichertemperatur", ZZ: "15", PBSB: "B524",
+			ID: "020000000500", Fields: []Field{ign4, tempv}, Line: 6},
+		// a different PBSB ends the default
+		{Type: TYPE_READ, Circuit: "ctlv2", Name: "Date", Comment: "Datum", ZZ: "15", PBSB: "B509", ID: "0d2c00",
+			Fields: []Field{{Types: []string{"date"}}}, Line: 7},
+		{Type: TYPE_WRITE, Circuit: "ctlv2", Name: "HwcSFMode", ZZ: "15", PBSB: "B524", ID: "020100000600",
+			Fields: []Field{{Name: "mode", Part: PART_MASTER, Types: []string{"UCH"}, Values: map[int]string{0: "auto", 1: "load"}}}, Line: 8},
+		// an empty default line resets the default of its type
+		{Type: TYPE_READ, Circuit: "ctlv2", Name: "unknownValue.a0", ZZ: "15", PBSB: "B555", ID: "a0",
+			Fields: []Field{{Types: []string{"HEX:8"}}}, Line: 10},
+		{Type: TYPE_READ, Circuit: "ctlv2", Name: "hwcTimer.Monday0", Comment: "Zeitfenster", ZZ: "15", PBSB: "B555", ID: "a500020000",
+			Fields: []Field{{Types: []string{"rTimeSlotWithTemp"}}}, Line: 12},
+		{Type: TYPE_READ, Circuit: "vwz00", Name: "hwcTimer.Monday1", ZZ: "76", PBSB: "B555", ID: "a500020001",
+			Fields: []Field{{Types: []string{"rTimeSlotWithTemp"}}}, Line: 13},
+	}
+	if len(file.Messages) != len(expected) {
+		t.Fatalf("ParseFile() returned %d messages, expected %d: %+v", len(file.Messages), len(expected), file.Messages)
+	}
+	for i, message := range file.Messages {
+		if !reflect.DeepEqual(message, expected[i]) {
+			t.Errorf("message %d = %+v, expected %+v", i, message, expected[i])
+		}
+	}
+}
+
+func TestParseFileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		err  string
+	}{
+		{"invalid type", "x,,Name,,,,B524,0400,,,tempv,,,", `line 1: invalid message type "x"`},
+		{"invalid priority", "r0,,Name,,,,B524,0400,,,tempv,,,", `line 1: invalid message type "r0"`},
+		{"missing type of default", "*;r,,,,,,B524,02000000,,,IGN:4,,,", "line 1: missing message type"},
+		{"invalid part", "r,,Name,,,,B524,0400,,x,tempv,,,", "line 1: invalid part \"x\" of field "},
+		{"invalid divider", "r,,Name,,,,B524,0400,,,tempv,ten,,", `line 1: invalid divider "ten"`},
+		{"invalid value list", "r,,Name,,,,B524,0400,,,UCH,0=off;on,,", `line 1: invalid value list "0=off;on"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseFile("15.ctlv2.csv", strings.NewReader(tt.line))
+			if err == nil || err.Error() != tt.err {
+				t.Errorf("ParseFile() = %v, expected the error %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestParseBundledCtlv2(t *testing.T) {
+	f, err := os.Open("../ebusd-config-files/15.ctlv2.csv")
+	if err != nil {
+		t.Fatalf("bundled 15.ctlv2.csv not readable: %s", err)
+	}
+	defer f.Close()
+	file, err := ParseFile(f.Name(), f)
+	if err != nil {
+		t.Fatalf("ParseFile() failed: %s", err)
+	}
+	messages := make(map[string]Message)
+	for _, message := range file.Messages {
+		messages[message.Key()] = message
+	}
+	tests := []struct {
+		messageType string
+		name        string
+		id          string
+		types       []string
+	}{
+		{TYPE_READ, "ContinuousHeating", "020000000200", []string{"IGN:4", "tempv"}},
+		{TYPE_WRITE, "ContinuousHeating", "020100000200", []string{"tempv"}},
+		{TYPE_READ, "HwcTempDesired", "020001000400", []string{"IGN:4", "tempv"}},
+		{TYPE_WRITE, "HwcTempDesired", "020101000400", []string{"tempv"}},
+		// Config and Timeframes are defined before the defaults of the time slots, so that no default ID is prepended
+		{TYPE_READ, "hwcTimer.Config", "a30002", []string{"HEX:9"}},
+		{TYPE_READ, "hwcTimer.Timeframes", "a40002", []string{"slotCountWeek"}},
+		{TYPE_READ, "z3Timer.Timeframes", "a40200", []string{"slotCountWeek"}},
+		{TYPE_READ, "hwcTimer.Monday0", "a500020000", []string{"rTimeSlotWithTemp"}},
+		{TYPE_WRITE, "hwcTimer.Monday", "a6000200", []string{"wTimeSlotWithTemp"}},
+		{TYPE_WRITE, "z1Timer.Sunday", "a6000006", []string{"wTimeSlotWithTemp"}},
+	}
+	for _, tt := range tests {
+		message, ok := messages[tt.messageType+"|ctlv2|"+strings.ToLower(tt.name)]
+		if !ok {
+			t.Errorf("%s %s not defined", tt.messageType, tt.name)
+			continue
+		}
+		if message.PBSB == "" || message.ID != tt.id || !reflect.DeepEqual(message.DataTypes(), tt.types) {
+			t.Errorf("%s %s: PBSB %s, ID %s, types %v, expected ID %s, types %v", tt.messageType, tt.name, message.PBSB, message.ID,
+				message.DataTypes(), tt.id, tt.types)
+		}
+	}
+	templates := DefaultTemplates()
+	for _, message := range file.Messages {
+		for _, field := range message.Fields {
+			if _, err := templates.Resolve(field); err != nil {
+				t.Errorf("%s %s (line %d): %s", message.Type, message.Name, message.Line, err)
+			}
+		}
+	}
+}
+
+func TestResolveTimeSlotTemplates(t *testing.T) {
+	tests := []struct {
+		template string
+		types    []string
+	}{
+		{"rTimeSlotWithTemp", []string{"IGN:1", "HTM", "HTM", "UIN"}},
+		{"wTimeSlotWithTemp", []string{"UCH", "UCH", "HTM", "HTM", "UIN"}},
+		{"wTimeSlotWithoutTemp", []string{"UCH", "UCH", "HTM", "HTM", "IGN:2"}},
+		{"slotCountWeek", []string{"UCH", "UCH", "UCH", "UCH", "UCH", "UCH", "UCH"}},
+	}
+	templates := DefaultTemplates()
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			fields, err := templates.Resolve(Field{Name: "slot", Types: []string{tt.template}})
+			if err != nil {
+				t.Fatalf("Resolve() failed: %s", err)
+			}
+			var types []string
+			for _, field := range fields {
+				types = append(types, field.Types...)
+				if field.Name != "slot" {
+					t.Errorf("resolved field named %q, expected \"slot\"", field.Name)
+				}
+			}
+			if !reflect.DeepEqual(types, tt.types) {
+				t.Errorf("Resolve() = %v, expected %v", types, tt.types)
+			}
+		})
+	}
+	// the setpoint of a time slot is sent in 1/10 °C
+	fields, _ := templates.Resolve(Field{Types: []string{"wTimeSlotWithTemp"}})
+	if setpoint := fields[len(fields)-1]; setpoint.Divider != 10 || setpoint.Unit != "°C" {
+		t.Errorf("setpoint of wTimeSlotWithTemp: divider %v, unit %q, expected 10 and °C", setpoint.Divider, setpoint.Unit)
+	}
+	if _, err := templates.Resolve(Field{Types: []string{"noSuchSlot"}}); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("Resolve() of an unknown template = %v, expected %v", err, ErrUnknownTemplate)
+	}
+}
@@ -0,0 +1,140 @@
+package ebusdcsv
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+//go:embed templates.csv
+var defaultTemplates string
+
+// ErrUnknownTemplate is returned by Resolve() for data types that are neither base types nor templates
+var ErrUnknownTemplate = errors.New("unknown template")
+
+// baseTypes are the data types built into ebusd. Types with a length are given without it, e.g. "HEX" for "HEX:8".
+var baseTypes = map[string]bool{
+	"BCD": true, "BDA": true, "BDY": true, "BI0": true, "BI1": true, "BI2": true, "BI3": true, "BI4": true, "BI5": true,
+	"BI6": true, "BI7": true, "BTI": true, "BTM": true, "D1B": true, "D1C": true, "D2B": true, "D2C": true, "EXP": true,
+	"EXR": true, "FLR": true, "FLT": true, "HCD": true, "HDA": true, "HDY": true, "HEX": true, "HTI": true, "HTM": true,
+	"IGN": true, "MIN": true, "NTS": true, "PIN": true, "SCH": true, "SIN": true, "SIR": true, "SLG": true, "SLR": true,
+	"STR": true, "TTH": true, "TTM": true, "TTQ": true, "UCH": true, "UIN": true, "UIR": true, "ULG": true, "ULR": true,
+	"VTI": true, "VTM": true,
+}
+
+// IsBaseType returns true for the data types built into ebusd, e.g. "D2C", "IGN:4" or "HEX:*"
+func IsBaseType(dataType string) bool {
+	name, _, _ := strings.Cut(dataType, ":")
+	return baseTypes[strings.ToUpper(name)]
+}
+
+// Templates are named data types, e.g. "tempv" for EXP in °C. A template refers to base types or other templates.
+type Templates map[string]Field
+
+// DefaultTemplates returns the templates of vaillant/_templates.csv of the ebusd configuration and the ones
+// of the time slots and holiday periods used by the files in ebusd-config-files
+func DefaultTemplates() Templates {
+	templates, err := ParseTemplates(strings.NewReader(defaultTemplates))
+	if err != nil {
+		panic(err)
+	}
+	return templates
+}
+
+// ParseTemplates parses a template file like _templates.csv of the ebusd configuration.
+// Each line has the columns name, data types, divider/values, unit and comment.
+func ParseTemplates(r io.Reader) (Templates, error) {
+	templates := make(Templates)
+	reader := newReader(r)
+	for {
+		columns, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		for len(columns) < 5 {
+			columns = append(columns, "")
+		}
+		name := strings.TrimSpace(columns[0])
+		if name == "" || strings.HasPrefix(name, "!") {
+			continue
+		}
+		field, err := parseField(name, "", columns[1], strings.TrimSpace(columns[2]), strings.TrimSpace(columns[3]), strings.TrimSpace(columns[4]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if len(field.Types) == 0 {
+			return nil, fmt.Errorf("line %d: missing data type of template %s", line, name)
+		}
+		templates[name] = field
+	}
+	return templates, nil
+}
+
+// Resolve replaces the templates used by field with their base types. The result has one field per base type.
+// Divider, values and unit of field take precedence over the ones of the templates (dividers are multiplied).
+func (t Templates) Resolve(field Field) ([]Field, error) {
+	return t.resolve(field, 0)
+}
+
+func (t Templates) resolve(field Field, depth int) ([]Field, error) {
+	if depth > 10 {
+		return nil, fmt.Errorf("templates of field %s nested too deep", field.Name)
+	}
+	var resolved []Field
+	for _, dataType := range field.Types {
+		if IsBaseType(dataType) {
+			resolved = append(resolved, Field{Name: field.Name, Part: field.Part, Types: []string{dataType}, Divider: field.Divider,
+				Values: field.Values, Unit: field.Unit, Comment: field.Comment})
+			continue
+		}
+		template, ok := t[dataType]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, dataType)
+		}
+		templateFields, err := t.resolve(template, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		for _, templateField := range templateFields {
+			templateField.Name, templateField.Part = field.Name, field.Part
+			switch {
+			case field.Divider != 0 && templateField.Divider != 0:
+				templateField.Divider = combineDividers(templateField.Divider, field.Divider)
+			case field.Divider != 0:
+				templateField.Divider = field.Divider
+			}
+			if field.Values != nil {
+				templateField.Values = field.Values
+			}
+			if field.Unit != "" {
+				templateField.Unit = field.Unit
+			}
+			if field.Comment != "" {
+				templateField.Comment = field.Comment
+			}
+			resolved = append(resolved, templateField)
+		}
+	}
+	return resolved, nil
+}
+
+// combineDividers combines two dividers. Negative dividers are multipliers.
+func combineDividers(a, b float64) float64 {
+	factor := func(divider float64) float64 {
+		if divider < 0 {
+			return -divider
+		}
+		return 1 / divider
+	}
+	product := factor(a) * factor(b)
+	if product > 1 {
+		return -product
+	}
+	return 1 / product
+}
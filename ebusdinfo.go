@@ -0,0 +1,106 @@
+package sensonetEbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EbusdInfo is the parsed output of the ebusd commands "info" and "state"
+type EbusdInfo struct {
+	Version        string // e.g. "ebusd 23.2.p20231001"
+	Device         string // e.g. "/dev/ttyEBUS"
+	DeviceType     string // details of the adapter, e.g. "serial, enhanced"
+	SignalAcquired bool
+	SymbolRate     int // symbols per second
+	MaxSymbolRate  int
+	Reconnects     int // reconnects of ebusd to the adapter
+	Masters        int // number of masters seen on the bus
+	Messages       int // number of message definitions loaded
+	Addresses      []EbusdAddress
+	State          string // output of "state", e.g. "signal acquired, 24 symbols/sec (122 max), 3 masters"
+}
+
+// EbusdAddress is an address seen on the bus, as reported in the "address" lines of "info"
+type EbusdAddress struct {
+	Address string // hex address, e.g. "15"
+	Master  bool
+	Ebusd   bool   // the address is used by ebusd itself
+	Scanned string // scan id of the device, e.g. "MF=Vaillant;ID=CTLV2;SW=0514;HW=1704". Empty if not scanned (yet)
+	Loaded  string // configuration file loaded for the device, e.g. "vaillant/15.ctlv2.csv"
+}
+
+// parseEbusdInfo parses the output of the ebusd command "info". Unknown lines are ignored.
+func parseEbusdInfo(info string) EbusdInfo {
+	var result EbusdInfo
+	for _, line := range strings.Split(info, "\n") {
+		key, value, found := strings.Cut(strings.TrimSpace(line), ": ")
+		if !found {
+			continue
+		}
+		switch {
+		case key == "version":
+			result.Version = value
+		case key == "device":
+			result.Device, result.DeviceType, _ = strings.Cut(value, ", ")
+		case key == "signal":
+			result.SignalAcquired = value == "acquired"
+		case key == "symbol rate":
+			result.SymbolRate, _ = strconv.Atoi(value)
+		case key == "max symbol rate":
+			result.MaxSymbolRate, _ = strconv.Atoi(value)
+		case key == "reconnects":
+			result.Reconnects, _ = strconv.Atoi(value)
+		case key == "masters":
+			result.Masters, _ = strconv.Atoi(value)
+		case key == "messages":
+			result.Messages, _ = strconv.Atoi(value)
+		case strings.HasPrefix(key, "address "):
+			result.Addresses = append(result.Addresses, parseEbusdAddress(strings.TrimPrefix(key, "address "), value))
+		}
+	}
+	return result
+}
+
+// parseEbusdAddress parses an address line of "info", e.g.
+// `address 15: slave #2, scanned "MF=Vaillant;ID=CTLV2;SW=0514;HW=1704", loaded "vaillant/15.ctlv2.csv"`
+func parseEbusdAddress(address, details string) EbusdAddress {
+	result := EbusdAddress{Address: address}
+	for _, part := range strings.Split(details, ", ") {
+		switch {
+		case strings.HasPrefix(part, "master"):
+			result.Master = true
+		case part == "ebusd":
+			result.Ebusd = true
+		case strings.HasPrefix(part, "scanned "):
+			result.Scanned = strings.Trim(strings.TrimPrefix(part, "scanned "), "\"")
+		case strings.HasPrefix(part, "loaded "):
+			result.Loaded = strings.Trim(strings.TrimPrefix(part, "loaded "), "\"")
+		}
+	}
+	return result
+}
+
+func (c *EbusConnection) getEbusdInfo(ctx context.Context) (EbusdInfo, error) {
+	info, err := c.transport.Info(ctx)
+	if err != nil {
+		return EbusdInfo{}, err
+	}
+	result := parseEbusdInfo(info)
+	state, err := c.transport.State(ctx)
+	if errors.Is(err, errors.ErrUnsupported) || isEbusdError(err) {
+		// "state" is not available with all ebusd versions and transports. The signal is reported by "info" as well.
+		c.debug(fmt.Sprintf("Ebusd state not available: %s", err))
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+	result.State = state
+	if !strings.HasPrefix(state, "signal acquired") {
+		result.SignalAcquired = false
+	}
+	return result, nil
+}
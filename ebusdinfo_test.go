@@ -0,0 +1,103 @@
+package sensonetEbus
+
+import (
+	"reflect"
+	"testing"
+)
+
+// ebusdInfoOutput is the output of "info" of ebusd 23.2 connected to a VRC720 system with a heat pump
+const ebusdInfoOutput = `version: ebusd 23.2.p20230716
+device: /dev/ttyEBUS, serial, enhanced
+access: *
+signal: acquired
+symbol rate: 23
+max symbol rate: 109
+min arbitration micros: 5
+max arbitration micros: 50
+min symbol latency: 4
+max symbol latency: 9
+reconnects: 1
+masters: 3
+messages: 316
+conditional: 3
+poll: 0
+update: 7
+address 03: master #11
+address 08: slave #11, scanned "MF=Vaillant;ID=HMU00;SW=0902;HW=5103", loaded "vaillant/08.hmu.csv"
+address 10: master #2
+address 15: slave #2, scanned "MF=Vaillant;ID=CTLV2;SW=0514;HW=1704", loaded "vaillant/15.ctlv2.csv"
+address 31: master #8, ebusd
+address 36: slave #8, ebusd
+address 76: slave, scanned "MF=Vaillant;ID=VWZ00;SW=0414;HW=5103", loaded "vaillant/76.vwzio.csv"`
+
+func TestParseEbusdInfo(t *testing.T) {
+	tests := []struct {
+		name string
+		info string
+		want EbusdInfo
+	}{
+		{
+			name: "ebusd 23.2",
+			info: ebusdInfoOutput,
+			want: EbusdInfo{
+				Version:        "ebusd 23.2.p20230716",
+				Device:         "/dev/ttyEBUS",
+				DeviceType:     "serial, enhanced",
+				SignalAcquired: true,
+				SymbolRate:     23,
+				MaxSymbolRate:  109,
+				Reconnects:     1,
+				Masters:        3,
+				Messages:       316,
+				Addresses: []EbusdAddress{
+					{Address: "03", Master: true},
+					{Address: "08", Scanned: "MF=Vaillant;ID=HMU00;SW=0902;HW=5103", Loaded: "vaillant/08.hmu.csv"},
+					{Address: "10", Master: true},
+					{Address: "15", Scanned: "MF=Vaillant;ID=CTLV2;SW=0514;HW=1704", Loaded: "vaillant/15.ctlv2.csv"},
+					{Address: "31", Master: true, Ebusd: true},
+					{Address: "36", Ebusd: true},
+					{Address: "76", Scanned: "MF=Vaillant;ID=VWZ00;SW=0414;HW=5103", Loaded: "vaillant/76.vwzio.csv"},
+				},
+			},
+		},
+		{
+			name: "no signal",
+			info: "version: ebusd 21.3.v21.3\r\ndevice: 192.168.1.20:9999, TCP\r\nsignal: no signal\r\nreconnects: 12\r\nmasters: 1\r\nmessages: 0\r\n",
+			want: EbusdInfo{
+				Version:    "ebusd 21.3.v21.3",
+				Device:     "192.168.1.20:9999",
+				DeviceType: "TCP",
+				Reconnects: 12,
+				Masters:    1,
+			},
+		},
+		{
+			name: "device without type",
+			info: "device: /dev/ttyUSB0",
+			want: EbusdInfo{Device: "/dev/ttyUSB0"},
+		},
+		{
+			name: "malformed lines",
+			info: "version ebusd 23.2\nsignal:acquired\nsymbol rate: fast\nmasters: 3 \n: 12\nmessages:\naddress 15 slave #2\n\n",
+			want: EbusdInfo{Masters: 3},
+		},
+		{
+			name: "error reply",
+			info: "ERR: invalid argument",
+			want: EbusdInfo{},
+		},
+		{
+			name: "empty",
+			info: "",
+			want: EbusdInfo{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := parseEbusdInfo(tc.info)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("parseEbusdInfo() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
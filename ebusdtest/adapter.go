@@ -0,0 +1,353 @@
+package ebusdtest
+
+import (
+	"bufio"
+	"encoding/hex"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WulfgarW/sensonetEbus/ebus"
+)
+
+const (
+	SYN_INTERVAL = 20 * time.Millisecond // interval of the SYN symbols sent while the bus is idle
+
+	ENH_REQ_INIT     = 0x0
+	ENH_REQ_SEND     = 0x1
+	ENH_REQ_START    = 0x2
+	ENH_RES_RESETTED = 0x0
+	ENH_RES_RECEIVED = 0x1
+	ENH_RES_STARTED  = 0x2
+	ENH_RES_FAILED   = 0xa
+)
+
+// Adapter is a fake eBUS adapter speaking the enhanced protocol of the ebusd adapters over TCP, with slaves
+// answering programmed telegrams. While the bus is idle, SYN symbols are sent to the clients. Arbitration
+// losses, NAKs and CRC errors can be injected. All methods are safe for concurrent use.
+type Adapter struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu              sync.Mutex
+	responses       map[string]string // response of the slave (NN data) per master part (ZZ PB SB NN data)
+	loseArbitration int
+	nakNext         int
+	corruptNext     int
+	noSignal        bool
+	telegrams       []string
+	conns           map[net.Conn]*adapterClient
+	closed          bool
+}
+
+// adapterClient is a connection to the adapter. Writes are serialized, as SYN symbols are sent from another goroutine.
+type adapterClient struct {
+	conn net.Conn
+	wmu  sync.Mutex
+	busy bool // the client owns the bus, guarded by wmu
+}
+
+func (cl *adapterClient) write(data []byte) {
+	cl.wmu.Lock()
+	defer cl.wmu.Unlock()
+	_, _ = cl.conn.Write(data)
+}
+
+// received sends a symbol received from the bus to the client
+func (cl *adapterClient) received(symbol byte) {
+	if symbol < 0x80 {
+		cl.write([]byte{symbol})
+		return
+	}
+	cl.write(encode(ENH_RES_RECEIVED, symbol))
+}
+
+func encode(command, data byte) []byte {
+	return []byte{0xc0 | command<<2 | data>>6, 0x80 | data&0x3f}
+}
+
+// NewAdapter starts a fake adapter on a free port of 127.0.0.1. The slaves 08, 15 and 76 answer the identification
+// like the devices of the scan result of NewServer().
+func NewAdapter() (*Adapter, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	a := &Adapter{
+		listener: listener,
+		conns:    make(map[net.Conn]*adapterClient),
+		responses: map[string]string{
+			"08070400": "0ab5484d55303009025103", // MF=Vaillant;ID=HMU00;SW=0902;HW=5103
+			"15070400": "0ab543544c563205141704", // MF=Vaillant;ID=CTLV2;SW=0514;HW=1704
+			"76070400": "0ab556575a303004195103", // MF=Vaillant;ID=VWZ00;SW=0419;HW=5103
+		},
+	}
+	a.wg.Add(2)
+	go a.serve()
+	go a.sendSYN()
+	return a, nil
+}
+
+// Addr returns the address of the adapter to be passed to sensonetEbus.NewConnection() with WithAdapterTransport()
+func (a *Adapter) Addr() string {
+	return a.listener.Addr().String()
+}
+
+// Close stops the adapter and closes all connections
+func (a *Adapter) Close() error {
+	a.mu.Lock()
+	a.closed = true
+	for conn := range a.conns {
+		conn.Close()
+	}
+	a.mu.Unlock()
+	err := a.listener.Close()
+	a.wg.Wait()
+	return err
+}
+
+// SetResponse sets the response of the slave (NN data, e.g. "08000000000000c03f") to the master part
+// of a telegram (ZZ PB SB NN data, e.g. "15b52406020003002600"). Telegrams to masters are acknowledged
+// with an empty response, the response is ignored for them. Telegrams without response are not answered at all.
+func (a *Adapter) SetResponse(telegram, response string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.responses[strings.ToLower(telegram)] = strings.ToLower(response)
+}
+
+// LoseArbitration makes the next n arbitrations fail
+func (a *Adapter) LoseArbitration(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.loseArbitration = n
+}
+
+// NAKNext makes the slaves answer the next n master parts with NAK
+func (a *Adapter) NAKNext(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.nakNext = n
+}
+
+// CorruptNext makes the slaves send the next n responses with a wrong CRC
+func (a *Adapter) CorruptNext(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.corruptNext = n
+}
+
+// SetSignal switches the SYN symbols off and on. Without signal, arbitrations do not get an answer.
+func (a *Adapter) SetSignal(signal bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.noSignal = !signal
+}
+
+// Telegrams returns the master parts (ZZ PB SB NN data) received so far with correct CRC
+func (a *Adapter) Telegrams() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]string(nil), a.telegrams...)
+}
+
+func (a *Adapter) serve() {
+	defer a.wg.Done()
+	for {
+		conn, err := a.listener.Accept()
+		if err != nil {
+			return
+		}
+		cl := &adapterClient{conn: conn}
+		a.mu.Lock()
+		if a.closed {
+			a.mu.Unlock()
+			conn.Close()
+			return
+		}
+		a.conns[conn] = cl
+		a.mu.Unlock()
+		a.wg.Add(1)
+		go a.handle(cl)
+	}
+}
+
+// sendSYN sends SYN symbols to all clients not owning the bus
+func (a *Adapter) sendSYN() {
+	defer a.wg.Done()
+	ticker := time.NewTicker(SYN_INTERVAL)
+	defer ticker.Stop()
+	for range ticker.C {
+		a.mu.Lock()
+		if a.closed {
+			a.mu.Unlock()
+			return
+		}
+		var clients []*adapterClient
+		if !a.noSignal {
+			for _, cl := range a.conns {
+				clients = append(clients, cl)
+			}
+		}
+		a.mu.Unlock()
+		for _, cl := range clients {
+			cl.wmu.Lock()
+			if !cl.busy {
+				_, _ = cl.conn.Write(encode(ENH_RES_RECEIVED, ebus.SYN))
+			}
+			cl.wmu.Unlock()
+		}
+	}
+}
+
+// telegramState is the state of a telegram sent by a client that owns the bus
+type telegramState struct {
+	master   []byte // escaped symbols of the master part, starting with QQ
+	response []byte // escaped symbols of the response sent, for the repetition after a NAK
+	repeated bool
+	done     bool // waiting for SYN
+}
+
+func (a *Adapter) handle(cl *adapterClient) {
+	defer a.wg.Done()
+	defer func() {
+		a.mu.Lock()
+		delete(a.conns, cl.conn)
+		a.mu.Unlock()
+		cl.conn.Close()
+	}()
+	reader := bufio.NewReader(cl.conn)
+	var state *telegramState
+	for {
+		first, err := reader.ReadByte()
+		if err != nil {
+			return
+		}
+		command, data := byte(ENH_REQ_SEND), first
+		if first&0x80 != 0 {
+			second, err := reader.ReadByte()
+			if err != nil {
+				return
+			}
+			command, data = first>>2&0x0f, first<<6|second&0x3f
+		}
+		switch command {
+		case ENH_REQ_INIT:
+			cl.write(encode(ENH_RES_RESETTED, 0x00))
+		case ENH_REQ_START:
+			if data == ebus.SYN {
+				continue
+			}
+			a.mu.Lock()
+			lose, noSignal := a.loseArbitration > 0, a.noSignal
+			if lose {
+				a.loseArbitration--
+			}
+			a.mu.Unlock()
+			switch {
+			case noSignal:
+			case lose:
+				cl.write(encode(ENH_RES_FAILED, 0x10))
+			default:
+				cl.wmu.Lock()
+				cl.busy = true
+				cl.wmu.Unlock()
+				state = &telegramState{master: []byte{data}}
+				cl.write(encode(ENH_RES_STARTED, data))
+			}
+		case ENH_REQ_SEND:
+			// echo of the symbol from the bus
+			cl.received(data)
+			if data == ebus.SYN {
+				cl.wmu.Lock()
+				cl.busy = false
+				cl.wmu.Unlock()
+				state = nil
+				continue
+			}
+			if state != nil {
+				a.symbolSent(cl, state, data)
+			}
+		}
+	}
+}
+
+// symbolSent processes a symbol of the telegram sent by the client cl owning the bus and answers as the slave
+func (a *Adapter) symbolSent(cl *adapterClient, state *telegramState, symbol byte) {
+	if state.done {
+		return
+	}
+	if state.response != nil {
+		// ACK or NAK of the master for the response
+		if symbol == ebus.NAK && !state.repeated {
+			state.repeated = true
+			for _, s := range state.response {
+				cl.received(s)
+			}
+			return
+		}
+		state.done = true
+		return
+	}
+	state.master = append(state.master, symbol)
+	if symbol == ebus.ESC {
+		return
+	}
+	master, err := ebus.Unescape(state.master)
+	if err != nil {
+		cl.received(ebus.NAK)
+		state.master = nil
+		return
+	}
+	if len(master) < 5 || len(master) < 6+int(master[4]) {
+		return
+	}
+	telegram := master[:5+int(master[4])]
+	zz := telegram[1]
+	if ebus.CRC(telegram) != master[5+int(master[4])] {
+		cl.received(ebus.NAK)
+		state.master = nil
+		return
+	}
+	key := hex.EncodeToString(telegram[1:])
+	a.mu.Lock()
+	a.telegrams = append(a.telegrams, key)
+	response, known := a.responses[key]
+	nak := a.nakNext > 0 && zz != ebus.BROADCAST
+	if nak {
+		a.nakNext--
+	}
+	corrupt := a.corruptNext > 0
+	a.mu.Unlock()
+	switch {
+	case zz == ebus.BROADCAST:
+		state.done = true
+	case nak:
+		cl.received(ebus.NAK)
+		state.master = nil
+	case ebus.IsMaster(zz):
+		cl.received(ebus.ACK)
+		state.done = true
+	case known:
+		data, err := hex.DecodeString(response)
+		if err != nil {
+			state.done = true
+			return
+		}
+		cl.received(ebus.ACK)
+		state.response = ebus.Escape(append(data, ebus.CRC(data)))
+		symbols := state.response
+		if corrupt {
+			a.mu.Lock()
+			a.corruptNext--
+			a.mu.Unlock()
+			symbols = append(append([]byte(nil), state.response[:len(state.response)-1]...), state.response[len(state.response)-1]^0x01)
+		}
+		for _, s := range symbols {
+			cl.received(s)
+		}
+	default:
+		// no slave answers
+		state.done = true
+	}
+}
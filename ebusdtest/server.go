@@ -0,0 +1,598 @@
+// Package ebusdtest provides an in-process fake ebusd for tests of the sensonetEbus package.
+// The server speaks the ebusd command protocol (read, write, find, find -F, hex, scan result, info, state and listen)
+// on a local TCP port and answers from a programmable in-memory register map. Errors, delays,
+// dropped connections and garbage lines can be injected to test error handling and reconnects
+// without hardware.
+package ebusdtest
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	REPLY_DONE              = "done"
+	REPLY_ELEMENTNOTFOUND   = "ERR: element not found"
+	REPLY_NOSIGNAL          = "ERR: no signal"
+	REPLY_COMMANDNOTFOUND   = "ERR: command not found"
+	REPLY_INVALIDARGUMENT   = "ERR: invalid argument"
+	REPLY_ARBITRATIONLOST   = "ERR: arbitration lost"
+	REPLY_READTIMEOUT       = "ERR: read timeout"
+	REPLY_INVALIDPOSITION   = "ERR: invalid position in decode"
+	REPLY_MISSINGARGUMENT   = "ERR: missing argument"
+	REPLY_NOTENABLED        = "ERR: command not enabled"
+	DEFAULT_CONTROLLER_NAME = "ctlv2"
+)
+
+type element struct {
+	value    string
+	readOnly bool
+}
+
+// client is a connection to the server. Writes are serialized, as updates are pushed to clients
+// in listen mode from other goroutines.
+type client struct {
+	conn      net.Conn
+	wmu       sync.Mutex
+	listening bool // guarded by Server.mu
+}
+
+func (cl *client) write(text string) error {
+	cl.wmu.Lock()
+	defer cl.wmu.Unlock()
+	_, err := cl.conn.Write([]byte(text))
+	return err
+}
+
+// Server is a fake ebusd. All methods are safe for concurrent use.
+type Server struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu          sync.Mutex
+	elements    map[string]map[string]*element // circuit -> name -> element
+	errors      map[string]string              // injected reply per element name
+	ignored     map[string]bool                // element names whose writes are answered but not applied
+	globalError string                         // injected reply for all reads and writes
+	delay       time.Duration
+	dropNext    int
+	garbage     []string
+	scanResult  []string
+	definitions map[string][]string // lines returned by "find -F" per circuit
+	hexEnabled  bool
+	hexReplies  map[string]string // reply per telegram for "hex"
+	info        []string
+	state       string
+	commands    []string
+	conns       map[net.Conn]*client
+	updates     []string // updates not yet pushed to the clients in listen mode
+	closed      bool
+}
+
+// NewServer starts a fake ebusd on a free port of 127.0.0.1
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		listener:    listener,
+		elements:    make(map[string]map[string]*element),
+		errors:      make(map[string]string),
+		ignored:     make(map[string]bool),
+		conns:       make(map[net.Conn]*client),
+		definitions: make(map[string][]string),
+		hexReplies:  make(map[string]string),
+		scanResult: []string{
+			"08;Vaillant;HMU00;0902;5103",
+			"15;Vaillant;CTLV2;0514;1704",
+			"76;Vaillant;VWZ00;0419;5103",
+		},
+		info: []string{
+			"version: ebusd 23.2.p20231001",
+			"device: /dev/ttyEBUS, serial, enhanced",
+			"signal: acquired",
+			"symbol rate: 24",
+			"max symbol rate: 122",
+			"reconnects: 0",
+			"masters: 3",
+			"messages: 402",
+			"address 03: master #11, ebusd",
+			"address 08: slave #11, scanned \"MF=Vaillant;ID=HMU00;SW=0902;HW=5103\", loaded \"vaillant/08.hmu.csv\"",
+			"address 10: master #2",
+			"address 15: slave #2, scanned \"MF=Vaillant;ID=CTLV2;SW=0514;HW=1704\", loaded \"vaillant/15.ctlv2.csv\"",
+			"address 31: master #8, ebusd",
+			"address 36: slave #8, ebusd",
+			"address 76: slave, scanned \"MF=Vaillant;ID=VWZ00;SW=0419;HW=5103\", loaded \"vaillant/76.vwz00.csv\"",
+		},
+		state: "signal acquired, 24 symbols/sec (122 max), 3 masters",
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the address of the server to be passed to sensonetEbus.NewConnection()
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops the server and closes all client connections
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+// Set defines the element name of circuit with value. Elements are writable unless SetReadOnly() is called.
+// Changed values are pushed to the clients in listen mode.
+func (s *Server) Set(circuit, name, value string) {
+	s.mu.Lock()
+	s.set(circuit, name, value)
+	s.mu.Unlock()
+	s.pushUpdates()
+}
+
+// set defines or changes the element name of circuit. The caller must hold s.mu.
+func (s *Server) set(circuit, name, value string) {
+	if s.elements[circuit] == nil {
+		s.elements[circuit] = make(map[string]*element)
+	}
+	if e, ok := s.elements[circuit][name]; ok {
+		if e.value != value {
+			e.value = value
+			s.updated(circuit, name, value)
+		}
+	} else {
+		s.elements[circuit][name] = &element{value: value}
+		s.updated(circuit, name, value)
+	}
+}
+
+// updated queues the update of an element for the clients in listen mode. The caller must hold s.mu.
+func (s *Server) updated(circuit, name, value string) {
+	s.updates = append(s.updates, fmt.Sprintf("%s %s = %s\n", circuit, name, value))
+}
+
+// pushUpdates sends the queued updates to all clients in listen mode
+func (s *Server) pushUpdates() {
+	s.mu.Lock()
+	updates := strings.Join(s.updates, "")
+	s.updates = nil
+	var listeners []*client
+	for _, cl := range s.conns {
+		if cl.listening {
+			listeners = append(listeners, cl)
+		}
+	}
+	s.mu.Unlock()
+	if updates == "" {
+		return
+	}
+	for _, cl := range listeners {
+		_ = cl.write(updates)
+	}
+}
+
+// SetReadOnly makes writes to the element name of circuit fail with "ERR: element not found", like ebusd does
+// for messages without write definition
+func (s *Server) SetReadOnly(circuit, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.elements[circuit][name]; ok {
+		e.readOnly = true
+	}
+}
+
+// Delete removes the element name of circuit
+func (s *Server) Delete(circuit, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.elements[circuit], name)
+}
+
+// Value returns the current value of the element name of circuit
+func (s *Server) Value(circuit, name string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.elements[circuit][name]
+	if !ok {
+		return "", false
+	}
+	return e.value, true
+}
+
+// SetError makes all reads and writes of the element name answer with reply, e.g. REPLY_NOSIGNAL.
+// An empty reply removes the injected error.
+func (s *Server) SetError(name, reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if reply == "" {
+		delete(s.errors, name)
+		return
+	}
+	s.errors[name] = reply
+}
+
+// IgnoreWrites makes writes of the element name answer "done" without changing the value, like a controller
+// silently rejecting a value
+func (s *Server) IgnoreWrites(name string, ignore bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ignored[name] = ignore
+}
+
+// SetGlobalError makes all reads and writes answer with reply, e.g. REPLY_NOSIGNAL for a bus without signal.
+// An empty reply removes the injected error.
+func (s *Server) SetGlobalError(reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.globalError = reply
+}
+
+// SetDelay delays every answer by delay
+func (s *Server) SetDelay(delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = delay
+}
+
+// DropNext makes the server close the client connection instead of answering the next n commands
+func (s *Server) DropNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dropNext = n
+}
+
+// DropConnections closes all open client connections. New connections are accepted.
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+		delete(s.conns, conn)
+	}
+}
+
+// InjectGarbage sends lines in front of the next answer
+func (s *Server) InjectGarbage(lines ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.garbage = append(s.garbage, lines...)
+}
+
+// SetDefinitions sets the lines returned for "find -F ... -c circuit". The lines are returned as given,
+// so they must have the columns requested by the client, e.g. "r,ctlv2,HwcOpMode,15,B524,02000100,,,opmode2,,,"
+func (s *Server) SetDefinitions(circuit string, lines ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.definitions[circuit] = lines
+}
+
+// SetHexEnabled enables the command "hex" like the option --enablehex of ebusd. It is disabled by default.
+func (s *Server) SetHexEnabled(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hexEnabled = enabled
+}
+
+// SetHexReply sets the reply to "hex telegram", e.g. "0a0000000000000000803f" for "15b52406020003002600".
+// Telegrams without reply are answered with REPLY_READTIMEOUT.
+func (s *Server) SetHexReply(telegram, reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hexReplies[strings.ToLower(telegram)] = reply
+}
+
+// SetScanResult sets the lines returned for "scan result", e.g. "15;Vaillant;CTLV2;0514;1704"
+func (s *Server) SetScanResult(lines ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scanResult = lines
+}
+
+// SetInfo sets the lines returned for "info", e.g. "signal: acquired"
+func (s *Server) SetInfo(lines ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.info = lines
+}
+
+// SetState sets the line returned for "state", e.g. "no signal"
+func (s *Server) SetState(line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = line
+}
+
+// Commands returns all commands received so far
+func (s *Server) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.commands...)
+}
+
+// ResetCommands clears the list of received commands
+func (s *Server) ResetCommands() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands = nil
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		cl := &client{conn: conn}
+		s.conns[conn] = cl
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.handle(cl)
+	}
+}
+
+func (s *Server) handle(cl *client) {
+	conn := cl.conn
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		s.mu.Lock()
+		s.commands = append(s.commands, line)
+		drop := s.dropNext > 0
+		if drop {
+			s.dropNext--
+		}
+		delay := s.delay
+		garbage := s.garbage
+		s.garbage = nil
+		s.mu.Unlock()
+
+		if drop {
+			return
+		}
+		if delay > 0 {
+			time.Sleep(delay)
+		}
+		var answer strings.Builder
+		for _, g := range garbage {
+			answer.WriteString(g + "\n")
+		}
+		for _, l := range s.answer(cl, line) {
+			answer.WriteString(l + "\n")
+		}
+		// every answer of ebusd is terminated by an empty line
+		answer.WriteString("\n")
+		if err := cl.write(answer.String()); err != nil {
+			return
+		}
+		s.pushUpdates()
+	}
+}
+
+// answer returns the lines answering the command line of the client cl
+func (s *Server) answer(cl *client, line string) []string {
+	args := strings.Fields(line)
+	switch strings.ToLower(args[0]) {
+	case "read", "r":
+		return []string{s.read(args[1:])}
+	case "write", "w":
+		return []string{s.write(args[1:])}
+	case "find", "f":
+		return s.find(args[1:])
+	case "hex":
+		return []string{s.hex(args[1:])}
+	case "scan":
+		if len(args) > 1 && args[1] == "result" {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return append([]string(nil), s.scanResult...)
+		}
+		return []string{REPLY_DONE}
+	case "info", "i":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return append([]string(nil), s.info...)
+	case "state":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return []string{s.state}
+	case "listen", "l":
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if len(args) > 1 && args[1] == "stop" {
+			cl.listening = false
+			return []string{"listen stopped"}
+		}
+		cl.listening = true
+		return []string{"listen started"}
+	default:
+		return []string{REPLY_COMMANDNOTFOUND}
+	}
+}
+
+// parseArgs separates the options -c CIRCUIT and -m/-i/-l VALUE (ignored) and the flags from the remaining arguments
+func parseArgs(args []string) (circuit string, rest []string) {
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-c":
+			if i+1 < len(args) {
+				circuit = args[i+1]
+				i++
+			}
+		case "-m", "-i", "-l", "-def", "-s", "-d", "-p", "-F":
+			i++
+		default:
+			if strings.HasPrefix(args[i], "-") && len(rest) == 0 {
+				continue
+			}
+			rest = append(rest, args[i])
+		}
+	}
+	return circuit, rest
+}
+
+// findDefinitions answers "find -F" with the definitions set by SetDefinitions(). The caller must hold s.mu.
+func (s *Server) findDefinitions(circuit string) []string {
+	var lines []string
+	for c, definitions := range s.definitions {
+		if circuit == "" || c == circuit {
+			lines = append(lines, definitions...)
+		}
+	}
+	if len(lines) == 0 {
+		return []string{REPLY_ELEMENTNOTFOUND}
+	}
+	return lines
+}
+
+// hex answers "hex [-s QQ] ZZPBSBNNDD..." with the reply set by SetHexReply()
+func (s *Server) hex(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.hexEnabled {
+		return REPLY_NOTENABLED
+	}
+	if s.globalError != "" {
+		return s.globalError
+	}
+	_, rest := parseArgs(args)
+	if len(rest) == 0 {
+		return REPLY_MISSINGARGUMENT
+	}
+	reply, ok := s.hexReplies[strings.ToLower(strings.Join(rest, ""))]
+	if !ok {
+		return REPLY_READTIMEOUT
+	}
+	return reply
+}
+
+// lookup returns the element name of circuit. If circuit is empty, the first circuit defining name is used.
+// The caller must hold s.mu.
+func (s *Server) lookup(circuit, name string) (string, *element) {
+	if circuit != "" {
+		return circuit, s.elements[circuit][name]
+	}
+	circuits := make([]string, 0, len(s.elements))
+	for c := range s.elements {
+		circuits = append(circuits, c)
+	}
+	sort.Strings(circuits)
+	for _, c := range circuits {
+		if e, ok := s.elements[c][name]; ok {
+			return c, e
+		}
+	}
+	return "", nil
+}
+
+func (s *Server) read(args []string) string {
+	circuit, rest := parseArgs(args)
+	if len(rest) == 0 {
+		return REPLY_MISSINGARGUMENT
+	}
+	name := rest[0]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.globalError != "" {
+		return s.globalError
+	}
+	if reply, ok := s.errors[name]; ok {
+		return reply
+	}
+	_, e := s.lookup(circuit, name)
+	if e == nil {
+		return REPLY_ELEMENTNOTFOUND
+	}
+	return e.value
+}
+
+func (s *Server) write(args []string) string {
+	circuit, rest := parseArgs(args)
+	if len(rest) < 2 {
+		return REPLY_MISSINGARGUMENT
+	}
+	name := rest[0]
+	value := strings.Join(rest[1:], " ")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.globalError != "" {
+		return s.globalError
+	}
+	if reply, ok := s.errors[name]; ok {
+		return reply
+	}
+	c, e := s.lookup(circuit, name)
+	if e == nil || e.readOnly {
+		return REPLY_ELEMENTNOTFOUND
+	}
+	if s.ignored[name] {
+		return REPLY_DONE
+	}
+	if e.value != value {
+		e.value = value
+		s.updated(c, name, value)
+	}
+	s.writeTimer(c, name, value)
+	return REPLY_DONE
+}
+
+func (s *Server) find(args []string) []string {
+	circuit, rest := parseArgs(args)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slices.Contains(args, "-F") {
+		return s.findDefinitions(circuit)
+	}
+	var lines []string
+	for c, elements := range s.elements {
+		if circuit != "" && c != circuit {
+			continue
+		}
+		for name, e := range elements {
+			if len(rest) > 0 && !strings.Contains(strings.ToLower(name), strings.ToLower(rest[0])) {
+				continue
+			}
+			lines = append(lines, fmt.Sprintf("%s %s = %s", c, name, e.value))
+		}
+	}
+	if len(lines) == 0 {
+		return []string{REPLY_ELEMENTNOTFOUND}
+	}
+	sort.Strings(lines)
+	return lines
+}
@@ -0,0 +1,64 @@
+package ebusdtest
+
+import "fmt"
+
+// PopulateVaillantSystem defines the elements read by sensonetEbus for a Vaillant heat pump system
+// with a VRC720 controller (circuit "ctlv2"), one heat circuit and the given number of zones, filled with plausible values
+func (s *Server) PopulateVaillantSystem(zones int) {
+	s.Set("broadcast", "vdatetime", "10:15:30;18.10.2026")
+	s.Set("broadcast", "outsidetemp", "8.500")
+	s.Set("hmu", "CurrentConsumedPower", "1.2")
+	s.Set("hmu", "Status01", "38.0;33.5;-;-;-;on")
+	s.Set("hmu", "State", "heating")
+	s.Set("vwz00", "ImmersionHeaterPower", "0.0")
+	s.Set(DEFAULT_CONTROLLER_NAME, "SystemFlowTemp", "37.25")
+	s.Set(DEFAULT_CONTROLLER_NAME, "WaterPressure", "1.8")
+	s.Set(DEFAULT_CONTROLLER_NAME, "HwcOpMode", "auto")
+	s.Set(DEFAULT_CONTROLLER_NAME, "HwcTempDesired", "50.0")
+	s.Set(DEFAULT_CONTROLLER_NAME, "HwcStorageTemp", "44.5")
+	s.Set(DEFAULT_CONTROLLER_NAME, "HwcSFMode", "auto")
+	s.Set(DEFAULT_CONTROLLER_NAME, "HwcHolidayStartPeriod", "01.01.2015") // no holiday period set
+	s.Set(DEFAULT_CONTROLLER_NAME, "HwcHolidayEndPeriod", "01.01.2015")
+	s.Set(DEFAULT_CONTROLLER_NAME, "Hc1CircuitType", "mixer")
+	s.Set(DEFAULT_CONTROLLER_NAME, "Hc1ActualFlowTempDesired", "35.5")
+	s.Set(DEFAULT_CONTROLLER_NAME, "Hc1FlowTemp", "34.875")
+	s.Set(DEFAULT_CONTROLLER_NAME, "Hc1Status", "1")
+	s.Set(DEFAULT_CONTROLLER_NAME, "Hc1PumpStatus", "1")
+	s.Set(DEFAULT_CONTROLLER_NAME, "Hc1HeatCurve", "0.6")
+	s.Set(DEFAULT_CONTROLLER_NAME, "Hc1SummerTempLimit", "21.0")
+	s.Set(DEFAULT_CONTROLLER_NAME, "Hc1MinFlowTempDesired", "15.0")
+	s.Set(DEFAULT_CONTROLLER_NAME, "Hc1MaxFlowTempDesired", "50.0")
+	for _, name := range []string{"Hc1CircuitType", "Hc1ActualFlowTempDesired", "Hc1FlowTemp"} {
+		s.SetReadOnly(DEFAULT_CONTROLLER_NAME, name)
+	}
+	for i := 1; i <= zones; i++ {
+		prefix := fmt.Sprintf("z%d", i)
+		s.Set(DEFAULT_CONTROLLER_NAME, prefix+"OpMode", "auto")
+		s.Set(DEFAULT_CONTROLLER_NAME, prefix+"SFMode", "auto")
+		s.Set(DEFAULT_CONTROLLER_NAME, prefix+"ActualRoomTempDesired", "20.0")
+		s.Set(DEFAULT_CONTROLLER_NAME, prefix+"RoomTemp", "20.5")
+		s.Set(DEFAULT_CONTROLLER_NAME, prefix+"QuickVetoTemp", "21.0")
+		s.Set(DEFAULT_CONTROLLER_NAME, prefix+"QuickVetoEndDate", "-")
+		s.Set(DEFAULT_CONTROLLER_NAME, prefix+"QuickVetoEndTime", "-")
+		s.Set(DEFAULT_CONTROLLER_NAME, prefix+"QuickVetoDuration", "0.0")
+		s.Set(DEFAULT_CONTROLLER_NAME, prefix+"Shortname", fmt.Sprintf("ZONE%d", i))
+		s.Set(DEFAULT_CONTROLLER_NAME, prefix+"Name1", fmt.Sprintf("Zone %d", i))
+		s.Set(DEFAULT_CONTROLLER_NAME, prefix+"Name2", "")
+		if i == 1 {
+			s.Set(DEFAULT_CONTROLLER_NAME, prefix+"RoomZoneMapping", "VRC720")
+		} else {
+			s.Set(DEFAULT_CONTROLLER_NAME, prefix+"RoomZoneMapping", fmt.Sprintf("VR91_%d", i-1))
+		}
+		s.Set(DEFAULT_CONTROLLER_NAME, prefix+"DayTemp", "20.0")
+		s.Set(DEFAULT_CONTROLLER_NAME, prefix+"NightTemp", "17.0")
+		s.Set(DEFAULT_CONTROLLER_NAME, prefix+"HolidayStartPeriod", "01.01.2015")
+		s.Set(DEFAULT_CONTROLLER_NAME, prefix+"HolidayEndPeriod", "01.01.2015")
+		s.Set(DEFAULT_CONTROLLER_NAME, prefix+"HolidayTemp", "15.0")
+		s.SetReadOnly(DEFAULT_CONTROLLER_NAME, prefix+"RoomTemp")
+		s.SetReadOnly(DEFAULT_CONTROLLER_NAME, prefix+"QuickVetoEndDate")
+		s.SetReadOnly(DEFAULT_CONTROLLER_NAME, prefix+"QuickVetoEndTime")
+	}
+	s.SetReadOnly(DEFAULT_CONTROLLER_NAME, "HwcStorageTemp")
+	s.SetReadOnly(DEFAULT_CONTROLLER_NAME, "SystemFlowTemp")
+	s.SetReadOnly(DEFAULT_CONTROLLER_NAME, "WaterPressure")
+}
@@ -0,0 +1,56 @@
+package ebusdtest
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// timerDays are the days of the time programs of the VRC720 in the order of <target>Timer.Timeframes
+var timerDays = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+// PopulateVaillantTimers defines the time programs of the hotwater (hwc), the circulation pump (cc) and the zones
+// z1 to z<zones> on the controller with one time slot per day from 06:00 to 22:00. The time slots of the hotwater
+// have a setpoint of 50 °C, the ones of the zones 20 °C. Writes to <target>Timer.<Day> change the time slots and
+// the slot counts like the VRC720 does.
+func (s *Server) PopulateVaillantTimers(zones int) {
+	setpoints := map[string]string{"hwc": ";50.0", "cc": ""}
+	for i := 1; i <= zones; i++ {
+		setpoints[fmt.Sprintf("z%d", i)] = ";20.0"
+	}
+	for target, setpoint := range setpoints {
+		s.Set(DEFAULT_CONTROLLER_NAME, target+"Timer.Timeframes", "1;1;1;1;1;1;1")
+		for _, day := range timerDays {
+			s.Set(DEFAULT_CONTROLLER_NAME, target+"Timer."+day+"0", "06:00;22:00"+setpoint)
+			s.SetReadOnly(DEFAULT_CONTROLLER_NAME, target+"Timer."+day+"0")
+			s.Set(DEFAULT_CONTROLLER_NAME, target+"Timer."+day, "")
+		}
+		s.SetReadOnly(DEFAULT_CONTROLLER_NAME, target+"Timer.Timeframes")
+	}
+}
+
+// writeTimer applies a write of "count;index;start;end[;setpoint]" to <target>Timer.<Day> to the slot count of the
+// day and to the slot <target>Timer.<Day><index>. The caller must hold s.mu.
+func (s *Server) writeTimer(circuit, name, value string) {
+	target, day, ok := strings.Cut(name, "Timer.")
+	dayIndex := slices.Index(timerDays, day)
+	timeframes := s.elements[circuit][target+"Timer.Timeframes"]
+	if !ok || dayIndex < 0 || timeframes == nil {
+		return
+	}
+	fields := strings.Split(value, ";")
+	counts := strings.Split(timeframes.value, ";")
+	if len(fields) < 4 || len(counts) != len(timerDays) {
+		return
+	}
+	count, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return
+	}
+	counts[dayIndex] = fields[0]
+	s.set(circuit, target+"Timer.Timeframes", strings.Join(counts, ";"))
+	if count > 0 {
+		s.set(circuit, fmt.Sprintf("%sTimer.%s%s", target, day, fields[1]), strings.Join(fields[2:], ";"))
+	}
+}
@@ -0,0 +1,148 @@
+package sensonetEbus
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors for the ERR: replies of ebusd. Use errors.Is() to check for them.
+var (
+	ErrGeneric           = errors.New("generic error")
+	ErrDevice            = errors.New("device error")
+	ErrNoSignal          = errors.New("no signal")
+	ErrTimeout           = errors.New("read timeout")
+	ErrArbitrationLost   = errors.New("arbitration lost")
+	ErrSend              = errors.New("send error")
+	ErrWrongSymbol       = errors.New("wrong symbol received")
+	ErrSYNReceived       = errors.New("SYN received")
+	ErrCRC               = errors.New("CRC error")
+	ErrACK               = errors.New("ACK error")
+	ErrNAK               = errors.New("NAK received")
+	ErrInvalidEscape     = errors.New("invalid escape sequence")
+	ErrElementNotFound   = errors.New("element not found")
+	ErrReadOnly          = errors.New("element is read-only")
+	ErrNotAuthorized     = errors.New("not authorized")
+	ErrInvalidValue      = errors.New("invalid value")
+	ErrOutOfRange        = errors.New("value out of valid range")
+	ErrInvalidArgument   = errors.New("invalid argument")
+	ErrMissingArgument   = errors.New("missing argument")
+	ErrInvalidAddress    = errors.New("invalid address")
+	ErrInvalidPosition   = errors.New("invalid position")
+	ErrDuplicate         = errors.New("duplicate entry")
+	ErrEndOfInput        = errors.New("end of input reached")
+	ErrNotEnabled        = errors.New("command not enabled")
+	ErrUnknownEbusdReply = errors.New("unknown ebusd error")
+)
+
+// Errors returned by the setters before anything is sent to ebusd, if an argument is invalid. They are distinct from
+// the sentinels of the ERR: replies, so that errors.Is(err, ErrInvalidArgument) only matches replies of ebusd.
+var (
+	ErrInvalidParameter    = errors.New("invalid parameter")
+	ErrParameterOutOfRange = errors.New("parameter out of range")
+)
+
+// ErrCircuitOpen is returned without contacting ebusd while the circuit breaker of the retry policy is open
+var ErrCircuitOpen = errors.New("circuit breaker open after repeated failures")
+
+// ErrNotConfirmed is returned by the setters, if the value read back after writing differs from the value written
+var ErrNotConfirmed = errors.New("written value not confirmed by read-back")
+
+// ebusdErrorReplies maps the text following "ERR: " in an ebusd reply to the sentinel error.
+// The first matching prefix wins, so longer prefixes have to be listed before shorter ones.
+var ebusdErrorReplies = []struct {
+	prefix string
+	err    error
+}{
+	{"generic device error", ErrDevice},
+	{"generic error", ErrGeneric},
+	{"no signal", ErrNoSignal},
+	{"read timeout", ErrTimeout},
+	{"timeout", ErrTimeout},
+	{"arbitration lost", ErrArbitrationLost},
+	{"send error", ErrSend},
+	{"wrong symbol received", ErrWrongSymbol},
+	{"SYN received", ErrSYNReceived},
+	{"CRC error", ErrCRC},
+	{"ACK error", ErrACK},
+	{"NAK received", ErrNAK},
+	{"invalid escape sequence", ErrInvalidEscape},
+	{"element not found", ErrElementNotFound},
+	{"read-only", ErrReadOnly},
+	{"read only", ErrReadOnly},
+	{"not authorized", ErrNotAuthorized},
+	{"invalid value list", ErrInvalidValue},
+	{"invalid numeric argument", ErrInvalidValue},
+	{"invalid value", ErrInvalidValue},
+	{"argument value out of valid range", ErrOutOfRange},
+	{"out of valid range", ErrOutOfRange},
+	{"invalid argument", ErrInvalidArgument},
+	{"missing argument", ErrMissingArgument},
+	{"invalid address", ErrInvalidAddress},
+	{"invalid position", ErrInvalidPosition},
+	{"invalid part type", ErrInvalidArgument},
+	{"duplicate", ErrDuplicate},
+	{"end of input reached", ErrEndOfInput},
+	{"command not enabled", ErrNotEnabled},
+}
+
+// EbusdError is returned when ebusd answers a command with an ERR: reply.
+// errors.Is(err, ErrNoSignal) etc. can be used to check for the kind of error.
+type EbusdError struct {
+	Command string // command sent to ebusd, e.g. "read -m 0 HwcSFMode"
+	Element string // name of the ebusd element, e.g. "HwcSFMode"
+	Circuit string // circuit given with -c, if any
+	Reply   string // complete reply of ebusd, e.g. "ERR: no signal"
+}
+
+func (e *EbusdError) Error() string {
+	if e.Circuit != "" {
+		return fmt.Sprintf("ebusd answered %q for %s.%s (command: %s)", e.Reply, e.Circuit, e.Element, e.Command)
+	}
+	if e.Element == "" {
+		return fmt.Sprintf("ebusd answered %q (command: %s)", e.Reply, e.Command)
+	}
+	return fmt.Sprintf("ebusd answered %q for %s (command: %s)", e.Reply, e.Element, e.Command)
+}
+
+// Unwrap returns the sentinel error matching the reply of ebusd
+func (e *EbusdError) Unwrap() error {
+	text := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(e.Reply), "ERR:"))
+	for _, r := range ebusdErrorReplies {
+		if strings.HasPrefix(text, r.prefix) {
+			return r.err
+		}
+	}
+	return ErrUnknownEbusdReply
+}
+
+// isEbusdErrorReply returns true, if the reply of ebusd is an ERR: reply
+func isEbusdErrorReply(reply string) bool {
+	return strings.HasPrefix(strings.TrimSpace(reply), "ERR:")
+}
+
+// isEbusdError returns true, if err is an ERR: reply of ebusd and not e.g. a network error
+func isEbusdError(err error) bool {
+	var ebusdErr *EbusdError
+	return errors.As(err, &ebusdErr)
+}
+
+// HolidayError is returned by SetHoliday() and ClearHoliday(), if the holiday period could not be written to all
+// targets. The holiday periods already written are not rolled back.
+type HolidayError struct {
+	Written []string // targets whose holiday period was written and confirmed, e.g. "zone 1" or "hotwater"
+	Failed  []string // targets whose holiday period was not or not completely written
+	Err     error    // error writing the first failed target
+}
+
+func (e *HolidayError) Error() string {
+	written := "none"
+	if len(e.Written) > 0 {
+		written = strings.Join(e.Written, ", ")
+	}
+	return fmt.Sprintf("holiday period written for %s, but not for %s: %s", written, strings.Join(e.Failed, ", "), e.Err)
+}
+
+func (e *HolidayError) Unwrap() error {
+	return e.Err
+}
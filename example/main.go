@@ -136,6 +136,13 @@ func main() {
 	} else {
 		fmt.Printf("   CheckEbusdConfig() returned: Details: \n%s , (Last) Error: %s \n", details, err)
 	}
+	report, err := conn.CheckEbusdConfigReport()
+	if err == nil {
+		fmt.Printf("   CheckEbusdConfigReport() returned verdict %s: %s\n", report.Verdict, report.Summary)
+		for _, element := range report.Failed() {
+			fmt.Printf("      %s (circuit '%s', required: %t): %s\n", element.Name, element.Circuit, element.Required, element.Reply)
+		}
+	}
 
 	var heatingPar sensonetEbus.HeatingParStruct
 	heatingPar.ZoneIndex = 1
@@ -260,6 +267,7 @@ func main() {
 				printKeyBinding()
 			case i == rune('q'):
 				_ = keyboard.Close()
+				_ = conn.Close()
 				os.Exit(0)
 			default:
 				fmt.Println("You pressed a key without a function. Press h to get help")
@@ -281,6 +289,11 @@ func main() {
 					fmt.Printf("\"%s\":%.1f°C (Setpoint=%.1f°C), ", z.Name1+z.Name2, z.RoomTemp, z.ActualRoomTempDesired)
 				}
 				fmt.Println("")
+				fmt.Print("   Heat circuits: ")
+				for _, hc := range state.HeatCircuits {
+					fmt.Printf("Hc%d:%.1f°C (Setpoint=%.1f°C, Pump=%s), ", hc.Index, hc.FlowTemp, hc.ActualFlowTempDesired, hc.PumpStatus)
+				}
+				fmt.Println("")
 				fmt.Printf("   HotWaterTemperature: %.1f°C (Setpoint=%.1f°C)\n", state.Hotwater.HwcStorageTemp, state.Hotwater.HwcTempDesired)
 				zoneData := sensonetEbus.GetZoneData(state.Zones, heatingPar.ZoneIndex)
 				quickModeExpiresAt := conn.GetQuickModeExpiresAt()
@@ -1,14 +1,39 @@
 package sensonetEbus
 
+import "context"
+
 func GetZoneData(zones []VaillantRelDataZones, index int) *VaillantRelDataZones {
 	// Extracting correct Zones element
 	if len(zones) == 0 {
 		return nil
 	}
+	if index < 0 || index == ZONEINDEX_DEFAULT {
+		return &zones[0] // the first zone is the default zone, like in the zone functions of Connection
+	}
 	for _, zone := range zones {
-		if zone.Index == index || (zone.Index == ZONEINDEX_DEFAULT && index < 0) {
+		if zone.Index == index {
 			return &zone
 		}
 	}
 	return nil
 }
+
+// ctxMutex is a mutex whose Lock can be abandoned when the context is done
+type ctxMutex chan struct{}
+
+func newCtxMutex() ctxMutex {
+	return make(ctxMutex, 1)
+}
+
+func (m ctxMutex) Lock(ctx context.Context) error {
+	select {
+	case m <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (m ctxMutex) Unlock() {
+	<-m
+}
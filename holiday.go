@@ -0,0 +1,186 @@
+package sensonetEbus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+const (
+	// The limits of the holiday setpoint are the defaults of the VRC720. They are not read from the controller.
+	HOLIDAY_MIN_TEMP = 5.0  // default lowest setpoint of the zones during a holiday period of the VRC720
+	HOLIDAY_MAX_TEMP = 30.0 // default highest setpoint of the zones during a holiday period of the VRC720
+)
+
+// holidayWrite is a value written by setHoliday() or clearHoliday() for a target (hotwater or zone)
+type holidayWrite struct {
+	name      string
+	value     string
+	confirmed func(readBack string) bool
+}
+
+// holidayTarget is the hotwater or a zone together with the values of its holiday period
+type holidayTarget struct {
+	name   string // e.g. "hotwater" or "zone 1"
+	writes []holidayWrite
+}
+
+// holidayActive returns true, if the day of now is within the holiday period from startDate to endDate.
+// Periods with invalid dates are not active.
+func holidayActive(startDate, endDate string, now time.Time) bool {
+	start, err := time.ParseInLocation(HOLIDAY_DATE_LAYOUT, startDate, now.Location())
+	if err != nil {
+		return false
+	}
+	end, err := time.ParseInLocation(HOLIDAY_DATE_LAYOUT, endDate, now.Location())
+	if err != nil {
+		return false
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return !today.Before(start) && !today.After(end)
+}
+
+// updateHolidayActive sets the Active flags of the holiday section of relData for today
+func updateHolidayActive(relData *VaillantRelData) {
+	now := time.Now()
+	holiday := &relData.Holiday
+	holiday.Hotwater.Active = holidayActive(holiday.Hotwater.StartDate, holiday.Hotwater.EndDate, now)
+	holiday.Active = holiday.Hotwater.Active
+	for i := range holiday.Zones {
+		holiday.Zones[i].Active = holidayActive(holiday.Zones[i].StartDate, holiday.Zones[i].EndDate, now)
+		holiday.Active = holiday.Active || holiday.Zones[i].Active
+	}
+}
+
+// getHolidayDataFromEbus sets the holiday section of relData from the results of getSystem()
+func (c *EbusConnection) getHolidayDataFromEbus(results readResults, relData *VaillantRelData) error {
+	setDate := func(name string, field *string) error {
+		findResult, err := results.get(name)
+		if err != nil && !isEbusdError(err) {
+			c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", name, err))
+			return err
+		} else if err == nil {
+			if _, err := time.Parse(HOLIDAY_DATE_LAYOUT, findResult); err != nil {
+				c.debug(fmt.Sprintf("Value '%s' returned from ebusd for %s invalid and therefore ignored", findResult, name))
+			} else {
+				*field = findResult
+			}
+		}
+		return nil
+	}
+	holiday := &relData.Holiday
+	holiday.Hotwater.Index = 0
+	if err := setDate(EBUSDREAD_HOTWATER_HOLIDAYSTARTPERIOD, &holiday.Hotwater.StartDate); err != nil {
+		return err
+	}
+	if err := setDate(EBUSDREAD_HOTWATER_HOLIDAYENDPERIOD, &holiday.Hotwater.EndDate); err != nil {
+		return err
+	}
+	if len(holiday.Zones) != len(c.zones) {
+		holiday.Zones = make([]VaillantRelDataHoliday, len(c.zones))
+	}
+	for i, zone := range c.zones {
+		zonePrefix := fmt.Sprintf("z%01d", zone)
+		zoneHoliday := &holiday.Zones[i]
+		zoneHoliday.Index = zone
+		if err := setDate(zonePrefix+EBUSDREAD_ZONE_HOLIDAYSTARTPERIOD, &zoneHoliday.StartDate); err != nil {
+			return err
+		}
+		if err := setDate(zonePrefix+EBUSDREAD_ZONE_HOLIDAYENDPERIOD, &zoneHoliday.EndDate); err != nil {
+			return err
+		}
+		findResult, err := results.get(zonePrefix + EBUSDREAD_ZONE_HOLIDAYTEMP)
+		if err != nil && !isEbusdError(err) {
+			c.debug(fmt.Sprintf("Error when reading '%s' from ebusd: %s. Leaving getSystem()", EBUSDREAD_ZONE_HOLIDAYTEMP, err))
+			return err
+		} else if err == nil {
+			convertedValue, err := convertToFloat(findResult, 0.0, 50.0)
+			if err != nil {
+				c.debug(fmt.Sprintf("Value '%s' returned from ebusd for %s invalid and therefore ignored. Error: %s", findResult, zonePrefix+EBUSDREAD_ZONE_HOLIDAYTEMP, err))
+			} else {
+				zoneHoliday.Temp = convertedValue
+			}
+		}
+	}
+	updateHolidayActive(relData)
+	return nil
+}
+
+// setHoliday writes the holiday period from the day of from to the day of to to the hotwater and the zones.
+// If no zones are given, all configured zones are used.
+func (c *EbusConnection) setHoliday(ctx context.Context, from, to time.Time, temp float64, zones []int) error {
+	startDate, endDate := from.Format(HOLIDAY_DATE_LAYOUT), to.Format(HOLIDAY_DATE_LAYOUT)
+	startDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	endDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	if endDay.Before(startDay) {
+		return fmt.Errorf("%w: end of the holiday period %s before its start %s", ErrInvalidParameter, endDate, startDate)
+	}
+	if temp < HOLIDAY_MIN_TEMP || temp > HOLIDAY_MAX_TEMP {
+		return fmt.Errorf("%w: holiday setpoint %.1f not in range [%.1f,%.1f]", ErrParameterOutOfRange, temp, HOLIDAY_MIN_TEMP, HOLIDAY_MAX_TEMP)
+	}
+	configured := c.getZones()
+	if len(zones) == 0 {
+		zones = configured
+	}
+	for _, zone := range zones {
+		if !slices.Contains(configured, zone) {
+			return fmt.Errorf("%w: zone %d is not configured", ErrInvalidParameter, zone)
+		}
+	}
+	var targets []holidayTarget
+	for _, zone := range zones {
+		zonePrefix := fmt.Sprintf("z%01d", zone)
+		writes := []holidayWrite{{name: zonePrefix + EBUSDREAD_ZONE_HOLIDAYTEMP, value: fmt.Sprintf("%2.1f", temp), confirmed: sameFloat(temp)}}
+		writes = append(writes, holidayPeriod(zonePrefix+EBUSDREAD_ZONE_HOLIDAYSTARTPERIOD, zonePrefix+EBUSDREAD_ZONE_HOLIDAYENDPERIOD,
+			startDate, endDate)...)
+		targets = append(targets, holidayTarget{name: fmt.Sprintf("zone %d", zone), writes: writes})
+	}
+	targets = append(targets, holidayTarget{name: "hotwater", writes: holidayPeriod(EBUSDREAD_HOTWATER_HOLIDAYSTARTPERIOD,
+		EBUSDREAD_HOTWATER_HOLIDAYENDPERIOD, startDate, endDate)})
+	return c.writeHolidayTargets(ctx, targets)
+}
+
+// clearHoliday resets the holiday periods of the hotwater and of all configured zones
+func (c *EbusConnection) clearHoliday(ctx context.Context) error {
+	var targets []holidayTarget
+	for _, zone := range c.getZones() {
+		zonePrefix := fmt.Sprintf("z%01d", zone)
+		targets = append(targets, holidayTarget{name: fmt.Sprintf("zone %d", zone), writes: holidayPeriod(zonePrefix+EBUSDREAD_ZONE_HOLIDAYSTARTPERIOD,
+			zonePrefix+EBUSDREAD_ZONE_HOLIDAYENDPERIOD, HOLIDAY_CLEARED_DATE, HOLIDAY_CLEARED_DATE)})
+	}
+	targets = append(targets, holidayTarget{name: "hotwater", writes: holidayPeriod(EBUSDREAD_HOTWATER_HOLIDAYSTARTPERIOD,
+		EBUSDREAD_HOTWATER_HOLIDAYENDPERIOD, HOLIDAY_CLEARED_DATE, HOLIDAY_CLEARED_DATE)})
+	return c.writeHolidayTargets(ctx, targets)
+}
+
+// holidayPeriod returns the writes of the start and end date of a holiday period
+func holidayPeriod(startName, endName, startDate, endDate string) []holidayWrite {
+	return []holidayWrite{
+		{name: startName, value: startDate, confirmed: sameString(startDate)},
+		{name: endName, value: endDate, confirmed: sameString(endDate)},
+	}
+}
+
+// writeHolidayTargets writes and confirms the values of the targets one after the other. If a write fails,
+// the remaining targets are skipped and a *HolidayError listing the written and failed targets is returned.
+func (c *EbusConnection) writeHolidayTargets(ctx context.Context, targets []holidayTarget) error {
+	circuit := c.controllerForSFMode
+	var written []string
+	for i, target := range targets {
+		for _, write := range target.writes {
+			err := c.ebusdWriteConfirmed(ctx, circuit, write.name, write.value, write.confirmed)
+			if err != nil {
+				c.debug(fmt.Sprintf("could not write %s. Error: %s", write.name, err))
+				holidayErr := &HolidayError{Written: written, Err: err}
+				for _, failed := range targets[i:] {
+					holidayErr.Failed = append(holidayErr.Failed, failed.name)
+				}
+				return holidayErr
+			}
+		}
+		written = append(written, target.name)
+	}
+	return nil
+}
@@ -1,5 +1,10 @@
 package sensonetEbus
 
+import (
+	"io"
+	"net/http"
+)
+
 type ConnOption func(*Connection)
 
 func WithLogger(logger Logger) ConnOption {
@@ -8,6 +13,60 @@ func WithLogger(logger Logger) ConnOption {
 	}
 }
 
+// WithTransport makes the connection use transport instead of the TCP command port of ebusd.
+// The ebusd address given to NewConnection() is ignored then.
+func WithTransport(transport Transport) ConnOption {
+	return func(c *Connection) {
+		c.transport = transport
+	}
+}
+
+// WithHTTPTransport makes the connection use the HTTP JSON API of ebusd. The ebusd address given to
+// NewConnection() has to be the base URL of the ebusd HTTP port then, e.g. "http://192.168.1.10:8889".
+// If client is nil, the transport creates a client of its own.
+func WithHTTPTransport(client *http.Client) ConnOption {
+	return func(c *Connection) {
+		c.httpAPI = true
+		c.httpClient = client
+	}
+}
+
+// WithAdapterTransport makes the connection talk to an eBUS adapter with the enhanced protocol (e.g. the ebusd
+// adapter v5 via TCP) directly instead of ebusd. The ebusd address given to NewConnection() has to be the address
+// of the adapter then, e.g. "192.168.1.20:9999". master is the own master address on the bus (ADAPTER_MASTER_ADDRESS
+// is the one ebusd uses). Only the elements of the bundled configuration files are available.
+func WithAdapterTransport(master byte) ConnOption {
+	return func(c *Connection) {
+		c.adapter = true
+		c.adapterMaster = master
+	}
+}
+
+// WithRecorder makes the connection write every command sent to ebusd and its reply with timestamp
+// and latency as JSON lines to w. A recording can be replayed with WithTransport(NewReplayTransport(...)).
+func WithRecorder(w io.Writer) ConnOption {
+	return func(c *Connection) {
+		c.recorder = w
+	}
+}
+
+// WithRetryPolicy replaces the default retry policy (see DefaultRetryPolicy()) for all commands sent to ebusd
+func WithRetryPolicy(policy RetryPolicy) ConnOption {
+	return func(c *Connection) {
+		c.retryPolicy = &policy
+	}
+}
+
+// WithNativeFallback enables or disables the native fallback for elements that are defined in the bundled
+// configuration files, but unknown to ebusd (e.g. with a stock configuration of ebusd). The messages of such elements
+// are sent with the command "hex" of ebusd, which has to be enabled with the option --enablehex of ebusd.
+// The fallback is enabled by default.
+func WithNativeFallback(enabled bool) ConnOption {
+	return func(c *Connection) {
+		c.noNativeFallback = !enabled
+	}
+}
+
 type EbusConnOption func(*EbusConnection)
 
 func withConnLogger(logger Logger) EbusConnOption {
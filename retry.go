@@ -0,0 +1,381 @@
+package sensonetEbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+type BreakerState int
+
+const (
+	BREAKER_CLOSED    BreakerState = iota // commands are sent to ebusd
+	BREAKER_OPEN                          // commands fail with ErrCircuitOpen without contacting ebusd
+	BREAKER_HALF_OPEN                     // one trial command is sent to ebusd to check if it works again
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BREAKER_CLOSED:
+		return "closed"
+	case BREAKER_OPEN:
+		return "open"
+	case BREAKER_HALF_OPEN:
+		return "half-open"
+	default:
+		return fmt.Sprintf("BreakerState(%d)", int(s))
+	}
+}
+
+// RetryPolicy controls how failed commands to ebusd are retried. Network errors are always retried,
+// ERR: replies of ebusd only if they match RetryableErrors. Errors of the context are never retried.
+type RetryPolicy struct {
+	MaxAttempts     int           // attempts per command including the first one
+	InitialBackoff  time.Duration // wait time before the second attempt
+	MaxBackoff      time.Duration // upper limit of the wait time between attempts
+	Multiplier      float64       // factor the wait time grows by with every attempt
+	Jitter          float64       // fraction of the wait time that is randomized (0.0 to 1.0)
+	RetryableErrors []error       // ERR: replies of ebusd that are retried, e.g. ErrArbitrationLost
+
+	// Writes and raw hex telegrams (which may write, e.g. WriteB524()) are sent only once unless RetryWrites
+	// is set, because a write whose reply got lost may have been executed by the controller already
+	RetryWrites bool
+
+	// The circuit breaker opens after BreakerThreshold consecutive failed commands (0 disables it).
+	// While it is open, commands fail with ErrCircuitOpen. After BreakerCooldown one trial command is
+	// let through, which closes the breaker again if it succeeds.
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// DefaultRetryPolicy returns the policy used unless WithRetryPolicy() is given: three attempts for network
+// errors and errors on the bus (e.g. arbitration lost), no circuit breaker
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:     3,
+		InitialBackoff:  100 * time.Millisecond,
+		MaxBackoff:      2 * time.Second,
+		Multiplier:      2.0,
+		Jitter:          0.2,
+		RetryableErrors: []error{ErrTimeout, ErrArbitrationLost, ErrSend, ErrWrongSymbol, ErrSYNReceived, ErrCRC, ErrACK, ErrNAK},
+		BreakerCooldown: 60 * time.Second,
+	}
+}
+
+// BreakerStatus is the state of the circuit breaker of the retry policy
+type BreakerStatus struct {
+	State               BreakerState
+	ConsecutiveFailures int
+	OpenedAt            time.Time // time the breaker opened the last time, zero if it never opened
+}
+
+// retryTransport applies a retry policy and a circuit breaker to all commands of the inner transport
+type retryTransport struct {
+	inner  Transport
+	logger Logger
+	policy RetryPolicy
+
+	mu                  sync.Mutex // guards the breaker state
+	state               BreakerState
+	consecutiveFailures int
+	openedAt            time.Time
+	trialRunning        bool
+}
+
+func newRetryTransport(inner Transport, policy RetryPolicy, logger Logger) *retryTransport {
+	if policy.MaxAttempts < 1 {
+		policy.MaxAttempts = 1
+	}
+	if policy.Multiplier < 1.0 {
+		policy.Multiplier = 1.0
+	}
+	return &retryTransport{inner: inner, policy: policy, logger: logger}
+}
+
+func (t *retryTransport) debug(fmt string, arg ...any) {
+	if t.logger != nil {
+		t.logger.Printf(fmt, arg...)
+	}
+}
+
+// retryable returns true, if the command failing with err should be tried again
+func (t *retryTransport) retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, errors.ErrUnsupported) || errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	if !isEbusdError(err) {
+		// network errors
+		return true
+	}
+	for _, retryableErr := range t.policy.RetryableErrors {
+		if errors.Is(err, retryableErr) {
+			return true
+		}
+	}
+	return false
+}
+
+// status returns the current state of the circuit breaker
+func (t *retryTransport) status() BreakerStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state := t.state
+	if state == BREAKER_OPEN && time.Now().After(t.openedAt.Add(t.policy.BreakerCooldown)) {
+		state = BREAKER_HALF_OPEN
+	}
+	return BreakerStatus{State: state, ConsecutiveFailures: t.consecutiveFailures, OpenedAt: t.openedAt}
+}
+
+// allow returns ErrCircuitOpen, if the breaker does not let the command through
+func (t *retryTransport) allow() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch t.state {
+	case BREAKER_OPEN:
+		if time.Now().Before(t.openedAt.Add(t.policy.BreakerCooldown)) {
+			return ErrCircuitOpen
+		}
+		t.state = BREAKER_HALF_OPEN
+		t.trialRunning = true
+		return nil
+	case BREAKER_HALF_OPEN:
+		if t.trialRunning {
+			return ErrCircuitOpen
+		}
+		t.trialRunning = true
+	}
+	return nil
+}
+
+// done updates the breaker with the final error of a command
+func (t *retryTransport) done(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.trialRunning = false
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		if t.state == BREAKER_HALF_OPEN {
+			// the trial was not conclusive
+			t.state = BREAKER_OPEN
+		}
+		return
+	}
+	if !t.retryable(err) {
+		// ebusd is reachable, even if it answered with an error like "element not found"
+		if t.state != BREAKER_CLOSED {
+			t.debug("Circuit breaker closed")
+		}
+		t.state = BREAKER_CLOSED
+		t.consecutiveFailures = 0
+		return
+	}
+	t.consecutiveFailures++
+	if t.state == BREAKER_HALF_OPEN || (t.policy.BreakerThreshold > 0 && t.consecutiveFailures >= t.policy.BreakerThreshold) {
+		if t.state != BREAKER_OPEN {
+			t.debug(fmt.Sprintf("Circuit breaker opened after %d consecutive failures. Last error: %s", t.consecutiveFailures, err))
+		}
+		t.state = BREAKER_OPEN
+		t.openedAt = time.Now()
+	}
+}
+
+// backoff returns the wait time before the attempt following attempt
+func (t *retryTransport) backoff(attempt int) time.Duration {
+	wait := float64(t.policy.InitialBackoff)
+	for i := 1; i < attempt; i++ {
+		wait = wait * t.policy.Multiplier
+	}
+	if t.policy.MaxBackoff > 0 && wait > float64(t.policy.MaxBackoff) {
+		wait = float64(t.policy.MaxBackoff)
+	}
+	if t.policy.Jitter > 0 {
+		wait = wait * (1.0 - t.policy.Jitter*rand.Float64())
+	}
+	return time.Duration(wait)
+}
+
+// do runs command until it succeeds, fails with an error that is not retryable or the attempts are used up
+func (t *retryTransport) do(ctx context.Context, what string, command func() error) error {
+	return t.doAttempts(ctx, what, t.policy.MaxAttempts, command)
+}
+
+// doAttempts is like do, but tries command at most maxAttempts times
+func (t *retryTransport) doAttempts(ctx context.Context, what string, maxAttempts int, command func() error) error {
+	err := t.allow()
+	if err != nil {
+		return err
+	}
+	for attempt := 1; ; attempt++ {
+		err = command()
+		if !t.retryable(err) || attempt >= maxAttempts {
+			break
+		}
+		wait := t.backoff(attempt)
+		t.debug(fmt.Sprintf("%s failed (attempt %d of %d): %s. Retrying in %s", what, attempt, maxAttempts, err, wait))
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-time.After(wait):
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	t.done(err)
+	return err
+}
+
+func (t *retryTransport) Read(ctx context.Context, circuit, name string, maxAge int) (string, error) {
+	var value string
+	err := t.do(ctx, "Reading "+name, func() error {
+		var err error
+		value, err = t.inner.Read(ctx, circuit, name, maxAge)
+		return err
+	})
+	return value, err
+}
+
+// ReadMany retries the elements whose read failed with a retryable error
+func (t *retryTransport) ReadMany(ctx context.Context, refs []ElementRef) ([]ReadResult, error) {
+	results := make([]ReadResult, len(refs))
+	for i, ref := range refs {
+		results[i].Ref = ref
+	}
+	pending := make([]int, len(refs))
+	for i := range pending {
+		pending[i] = i
+	}
+	err := t.do(ctx, "Reading a batch of elements", func() error {
+		batch := make([]ElementRef, len(pending))
+		for j, i := range pending {
+			batch[j] = refs[i]
+		}
+		batchResults, err := ReadMany(ctx, t.inner, batch)
+		var retry []int
+		var retryErr error
+		for j, i := range pending {
+			results[i] = batchResults[j]
+			if t.retryable(results[i].Err) {
+				retry = append(retry, i)
+				if retryErr == nil {
+					retryErr = results[i].Err
+				}
+			}
+		}
+		pending = retry
+		if err != nil {
+			return err
+		}
+		return retryErr
+	})
+	if err != nil && !isEbusdError(err) {
+		for _, i := range pending {
+			if results[i].Err == nil {
+				results[i].Err = err
+			}
+		}
+		return results, err
+	}
+	// the remaining ERR: replies are returned per element
+	return results, nil
+}
+
+// Write retries the write only if the policy has RetryWrites set
+func (t *retryTransport) Write(ctx context.Context, circuit, name, value string) error {
+	return t.doAttempts(ctx, "Writing "+name, t.writeAttempts(), func() error {
+		return t.inner.Write(ctx, circuit, name, value)
+	})
+}
+
+// writeAttempts returns the number of attempts of commands that may change the state of the controller
+func (t *retryTransport) writeAttempts() int {
+	if t.policy.RetryWrites {
+		return t.policy.MaxAttempts
+	}
+	return 1
+}
+
+func (t *retryTransport) Find(ctx context.Context, name string) ([]string, error) {
+	var circuits []string
+	err := t.do(ctx, "Finding "+name, func() error {
+		var err error
+		circuits, err = t.inner.Find(ctx, name)
+		return err
+	})
+	return circuits, err
+}
+
+func (t *retryTransport) Scan(ctx context.Context) (string, error) {
+	var result string
+	err := t.do(ctx, "Getting the scan result", func() error {
+		var err error
+		result, err = t.inner.Scan(ctx)
+		return err
+	})
+	return result, err
+}
+
+func (t *retryTransport) Info(ctx context.Context) (string, error) {
+	var result string
+	err := t.do(ctx, "Getting the ebusd info", func() error {
+		var err error
+		result, err = t.inner.Info(ctx)
+		return err
+	})
+	return result, err
+}
+
+func (t *retryTransport) State(ctx context.Context) (string, error) {
+	var result string
+	err := t.do(ctx, "Getting the ebusd state", func() error {
+		var err error
+		result, err = t.inner.State(ctx)
+		return err
+	})
+	return result, err
+}
+
+func (t *retryTransport) FindDefinitions(ctx context.Context, circuit string) ([]string, error) {
+	finder, ok := t.inner.(DefinitionFinder)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+	var definitions []string
+	err := t.do(ctx, "Finding the definitions of "+circuit, func() error {
+		var err error
+		definitions, err = finder.FindDefinitions(ctx, circuit)
+		return err
+	})
+	return definitions, err
+}
+
+// Hex retries the telegram only if the policy has RetryWrites set, as a raw telegram may write
+func (t *retryTransport) Hex(ctx context.Context, telegram string) (string, error) {
+	sender, ok := t.inner.(HexSender)
+	if !ok {
+		return "", errors.ErrUnsupported
+	}
+	var reply string
+	err := t.doAttempts(ctx, "Sending "+telegram, t.writeAttempts(), func() error {
+		var err error
+		reply, err = sender.Hex(ctx, telegram)
+		return err
+	})
+	return reply, err
+}
+
+// Listen passes the listen session to the inner transport. Reopening a failed session is left to the caller.
+func (t *retryTransport) Listen(ctx context.Context, fn func(circuit, name, value string)) error {
+	listener, ok := t.inner.(Listener)
+	if !ok {
+		return errors.ErrUnsupported
+	}
+	return listener.Listen(ctx, fn)
+}
+
+func (t *retryTransport) Close() error {
+	return t.inner.Close()
+}
@@ -0,0 +1,111 @@
+package sensonetEbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	LISTEN_RECONNECT_INTERVAL = 10 // seconds to wait before a failed listen session to ebusd is reopened
+	SUBSCRIPTION_BUFFER_SIZE  = 64 // updates buffered per subscriber before further updates are dropped
+)
+
+// Update is a change of an element value pushed by ebusd
+type Update struct {
+	Circuit  string
+	Element  string
+	OldValue string // empty, if the value was not known before
+	NewValue string
+	Time     time.Time
+}
+
+// Subscribe returns a channel receiving the changes of element values pushed by ebusd ("listen" mode of ebusd).
+// The cached system data returned by GetSystem() are kept current with them. All subscribers share one listen
+// session, which is opened by the first subscription and ended with "listen stop" when the last subscription ends.
+// The channel is closed when ctx is done. Updates are dropped, if the receiver does not keep up.
+// If the transport does not support the listen mode, the channel is closed immediately.
+func (c *Connection) Subscribe(ctx context.Context) <-chan Update {
+	updates := make(chan Update, SUBSCRIPTION_BUFFER_SIZE)
+	listener, ok := c.ebusdConn.transport.(Listener)
+	if !ok {
+		c.debug("Transport does not support the listen mode of ebusd")
+		close(updates)
+		return updates
+	}
+	c.subMu.Lock()
+	if c.subscribers == nil {
+		c.subscribers = make(map[chan Update]struct{})
+	}
+	c.subscribers[updates] = struct{}{}
+	if c.stopListen == nil {
+		listenCtx, cancel := context.WithCancel(context.Background())
+		c.stopListen = cancel
+		go c.listen(listenCtx, listener)
+	}
+	c.subMu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		c.subMu.Lock()
+		defer c.subMu.Unlock()
+		delete(c.subscribers, updates)
+		close(updates)
+		if len(c.subscribers) == 0 && c.stopListen != nil {
+			c.stopListen()
+			c.stopListen = nil
+		}
+	}()
+	return updates
+}
+
+// listen keeps a listen session to ebusd open until ctx is done
+func (c *Connection) listen(ctx context.Context, listener Listener) {
+	for {
+		err := listener.Listen(ctx, func(circuit, name, value string) {
+			c.dispatchUpdate(ctx, circuit, name, value)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if errors.Is(err, errors.ErrUnsupported) {
+			c.debug("Transport does not support the listen mode of ebusd")
+			return
+		}
+		c.debug(fmt.Sprintf("Listen session to ebusd ended: %s. Reopening it in %d seconds", err, LISTEN_RECONNECT_INTERVAL))
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(LISTEN_RECONNECT_INTERVAL * time.Second):
+		}
+	}
+}
+
+// dispatchUpdate applies a changed element value to the cached system data and sends it to all subscribers
+func (c *Connection) dispatchUpdate(ctx context.Context, circuit, name, value string) {
+	oldValue, known := c.ebusdConn.setValue(name, value)
+	if known && oldValue == value {
+		return
+	}
+	if c.mu.Lock(ctx) == nil {
+		if c.ebusdConn.applyElementValue(&c.relData, name, value) {
+			c.refreshCurrentQuickMode()
+		}
+		c.mu.Unlock()
+	}
+	update := Update{Circuit: circuit, Element: name, OldValue: oldValue, NewValue: value, Time: time.Now()}
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	if ctx.Err() != nil {
+		// the listen session was stopped meanwhile
+		return
+	}
+	for subscriber := range c.subscribers {
+		select {
+		case subscriber <- update:
+		default:
+			c.debug(fmt.Sprintf("Subscriber does not keep up. Update of %s dropped", name))
+		}
+	}
+}
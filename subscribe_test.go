@@ -0,0 +1,57 @@
+package sensonetEbus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/WulfgarW/sensonetEbus/ebusdtest"
+	"golang.org/x/exp/slices"
+)
+
+// waitFor polls cond until it is true and fails the test, if that takes longer than a second
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); !cond(); time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	server, conn := newTestSystem(t)
+	if _, err := conn.GetSystem(true); err != nil {
+		t.Fatalf("GetSystem() failed: %s", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := conn.Subscribe(ctx)
+	waitFor(t, "the listen session", func() bool { return slices.Contains(server.Commands(), "listen") })
+
+	server.Set(ebusdtest.DEFAULT_CONTROLLER_NAME, "z1"+EBUSDREAD_ZONE_OPMODE, OPERATIONMODE_OFF)
+	select {
+	case update := <-updates:
+		if update.Circuit != ebusdtest.DEFAULT_CONTROLLER_NAME || update.Element != "z1"+EBUSDREAD_ZONE_OPMODE ||
+			update.OldValue != OPERATIONMODE_AUTO || update.NewValue != OPERATIONMODE_OFF {
+			t.Errorf("update %+v, expected z1%s changed from %q to %q", update, EBUSDREAD_ZONE_OPMODE, OPERATIONMODE_AUTO, OPERATIONMODE_OFF)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no update received")
+	}
+	// the cached system data are updated by applyElementValue()
+	if err := conn.mu.Lock(ctx); err != nil {
+		t.Fatalf("Lock() failed: %s", err)
+	}
+	opMode := GetZoneData(conn.relData.Zones, 1).OpMode
+	conn.mu.Unlock()
+	if opMode != OPERATIONMODE_OFF {
+		t.Errorf("cached OpMode of zone 1 = %q, expected %q", opMode, OPERATIONMODE_OFF)
+	}
+
+	cancel()
+	for range updates {
+		// wait for the channel to be closed
+	}
+	waitFor(t, "listen stop", func() bool { return slices.Contains(server.Commands(), "listen stop") })
+}
@@ -0,0 +1,296 @@
+package sensonetEbus
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TIMER_HOTWATER    TimerTarget = "hwc" // time program of the hotwater (with setpoint)
+	TIMER_CIRCULATION TimerTarget = "cc"  // time program of the circulation pump (without setpoint)
+
+	TIMER_MAX_ZONES         = 3  // zones with a time program in the bundled 15.ctlv2.csv (z1Timer to z3Timer)
+	TIMER_MAX_SLOTS_PER_DAY = 3  // time slots per day supported by the VRC720
+	TIMER_GRANULARITY       = 10 // minutes, start and end of the time slots have to be a multiple of it
+
+	EBUSDREAD_TIMER_TIMEFRAMES = "Timer.Timeframes" //To be added by the timer target, number of slots per day (Monday to Sunday)
+)
+
+// timerDays are the days in the order of the values of <target>Timer.Timeframes
+var timerDays = []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday, time.Sunday}
+
+// TimerTarget is the prefix of the timer elements of a time program of the VRC720, e.g. "hwc" for hwcTimer.Monday0
+type TimerTarget string
+
+// ZoneTimer returns the target of the time program of the zone (1 to TIMER_MAX_ZONES), e.g. "z1" for z1Timer.Monday0
+func ZoneTimer(zone int) TimerTarget {
+	return TimerTarget(fmt.Sprintf("z%01d", zone))
+}
+
+// HasSetpoint returns true for the targets, whose time slots have a setpoint
+func (t TimerTarget) HasSetpoint() bool {
+	return t != TIMER_CIRCULATION
+}
+
+func (t TimerTarget) validate() error {
+	switch t {
+	case TIMER_HOTWATER, TIMER_CIRCULATION:
+		return nil
+	}
+	zone, err := strconv.Atoi(strings.TrimPrefix(string(t), "z"))
+	if !strings.HasPrefix(string(t), "z") || err != nil || zone < 1 || zone > TIMER_MAX_ZONES {
+		return fmt.Errorf("%w: unknown timer target %q", ErrInvalidParameter, t)
+	}
+	return nil
+}
+
+// setpointRange returns the valid range of the setpoints of the time slots, like in getSystem()
+func (t TimerTarget) setpointRange() (float64, float64) {
+	if t == TIMER_HOTWATER {
+		return 0.0, 75.0
+	}
+	return 0.0, 50.0
+}
+
+// dayElement returns the element writing the slots of day, e.g. "hwcTimer.Monday"
+func (t TimerTarget) dayElement(day time.Weekday) string {
+	return fmt.Sprintf("%sTimer.%s", t, day)
+}
+
+// slotElement returns the element of the slot of day, e.g. "hwcTimer.Monday0"
+func (t TimerTarget) slotElement(day time.Weekday, slot int) string {
+	return fmt.Sprintf("%sTimer.%s%d", t, day, slot)
+}
+
+// TimeOfDay is a time of day in minutes after midnight. The end of the day is 24:00 (1440).
+type TimeOfDay int
+
+const END_OF_DAY TimeOfDay = 24 * 60
+
+// ParseTimeOfDay parses a time of day as returned by ebusd, e.g. "06:30" or "24:00:00"
+func ParseTimeOfDay(value string) (TimeOfDay, error) {
+	parts := strings.Split(strings.TrimSpace(value), ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("%w: time of day %q", ErrInvalidValue, value)
+	}
+	var numbers []int
+	for _, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return 0, fmt.Errorf("%w: time of day %q", ErrInvalidValue, value)
+		}
+		numbers = append(numbers, number)
+	}
+	t := TimeOfDay(numbers[0]*60 + numbers[1])
+	if numbers[1] > 59 || t > END_OF_DAY || (len(numbers) == 3 && numbers[2] != 0) {
+		return 0, fmt.Errorf("%w: time of day %q", ErrInvalidValue, value)
+	}
+	return t, nil
+}
+
+// String returns the time of day as "hh:mm"
+func (t TimeOfDay) String() string {
+	return fmt.Sprintf("%02d:%02d", int(t)/60, int(t)%60)
+}
+
+// TimeSlot is a time slot of a time program, in which the target is active (or heats up to Setpoint)
+type TimeSlot struct {
+	Start    TimeOfDay
+	End      TimeOfDay
+	Setpoint float64 // °C, 0 for targets without setpoint
+}
+
+func (s TimeSlot) String() string {
+	return fmt.Sprintf("%s-%s", s.Start, s.End)
+}
+
+// WeeklySchedule is the time program of a target of the VRC720 with up to TIMER_MAX_SLOTS_PER_DAY time slots per day
+type WeeklySchedule struct {
+	Target TimerTarget
+	Days   map[time.Weekday][]TimeSlot
+}
+
+// Validate checks the time slots of the days in the schedule as required by the VRC720: at most
+// TIMER_MAX_SLOTS_PER_DAY slots per day, start and end at multiples of TIMER_GRANULARITY minutes,
+// slots ordered by time without overlap and setpoints in the valid range of the target. Targets without setpoint
+// (TIMER_CIRCULATION) require a setpoint of 0, as the setpoint is not written and would never be read back.
+func (s WeeklySchedule) Validate() error {
+	if err := s.Target.validate(); err != nil {
+		return err
+	}
+	minSetpoint, maxSetpoint := s.Target.setpointRange()
+	for _, day := range timerDays {
+		slots := s.Days[day]
+		if len(slots) > TIMER_MAX_SLOTS_PER_DAY {
+			return fmt.Errorf("%w: %d time slots on %s, at most %d allowed", ErrInvalidParameter, len(slots), day, TIMER_MAX_SLOTS_PER_DAY)
+		}
+		for i, slot := range slots {
+			switch {
+			case slot.Start < 0 || slot.End > END_OF_DAY || slot.Start >= slot.End:
+				return fmt.Errorf("%w: time slot %s on %s", ErrInvalidParameter, slot, day)
+			case slot.Start%TIMER_GRANULARITY != 0 || slot.End%TIMER_GRANULARITY != 0:
+				return fmt.Errorf("%w: time slot %s on %s is not a multiple of %d minutes", ErrInvalidParameter, slot, day, TIMER_GRANULARITY)
+			case i > 0 && slot.Start < slots[i-1].End:
+				return fmt.Errorf("%w: time slot %s on %s overlaps or precedes %s", ErrInvalidParameter, slot, day, slots[i-1])
+			case !s.Target.HasSetpoint() && slot.Setpoint != 0:
+				return fmt.Errorf("%w: time slot %s on %s has setpoint %.1f, but %s has no setpoints", ErrInvalidParameter, slot, day, slot.Setpoint, s.Target)
+			case s.Target.HasSetpoint() && (slot.Setpoint < minSetpoint || slot.Setpoint > maxSetpoint):
+				return fmt.Errorf("%w: setpoint %.1f of time slot %s on %s not in range [%.1f,%.1f]", ErrParameterOutOfRange, slot.Setpoint, slot, day, minSetpoint, maxSetpoint)
+			}
+		}
+	}
+	return nil
+}
+
+// formatTimeSlot returns the value of <target>Timer.<Day> writing the slot with the given index of count slots,
+// e.g. "2;0;06:00;08:00;50.0"
+func formatTimeSlot(count, index int, slot TimeSlot, withSetpoint bool) string {
+	value := fmt.Sprintf("%d;%d;%s;%s", count, index, slot.Start, slot.End)
+	if withSetpoint {
+		value += fmt.Sprintf(";%.1f", slot.Setpoint)
+	}
+	return value
+}
+
+// sameTimeSlots returns true, if the slots read back equal the slots written
+func sameTimeSlots(written, read []TimeSlot) bool {
+	if len(written) != len(read) {
+		return false
+	}
+	for i := range written {
+		if written[i].Start != read[i].Start || written[i].End != read[i].End || math.Abs(written[i].Setpoint-read[i].Setpoint) > 0.05 {
+			return false
+		}
+	}
+	return true
+}
+
+// parseTimeSlot parses the value of a timer slot element returned by ebusd, e.g. "06:00;22:00;50" or "06:00;22:00"
+func parseTimeSlot(value string, withSetpoint bool) (TimeSlot, error) {
+	fields := strings.Split(value, ";")
+	if len(fields) < 2 || (withSetpoint && len(fields) < 3) {
+		return TimeSlot{}, fmt.Errorf("%w: time slot %q", ErrInvalidValue, value)
+	}
+	var slot TimeSlot
+	var err error
+	if slot.Start, err = ParseTimeOfDay(fields[0]); err != nil {
+		return TimeSlot{}, err
+	}
+	if slot.End, err = ParseTimeOfDay(fields[1]); err != nil {
+		return TimeSlot{}, err
+	}
+	if withSetpoint {
+		if slot.Setpoint, err = convertToFloat(strings.TrimSpace(fields[2]), 0.0, 99.0); err != nil {
+			return TimeSlot{}, fmt.Errorf("%w: setpoint of time slot %q", ErrInvalidValue, value)
+		}
+	}
+	return slot, nil
+}
+
+// parseSlotCounts parses the value of <target>Timer.Timeframes, the number of slots per day from Monday to Sunday
+func parseSlotCounts(value string) (map[time.Weekday]int, error) {
+	fields := strings.Split(value, ";")
+	if len(fields) != len(timerDays) {
+		return nil, fmt.Errorf("%w: slot counts %q", ErrInvalidValue, value)
+	}
+	counts := make(map[time.Weekday]int)
+	for i, field := range fields {
+		count, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || count < 0 || count > TIMER_MAX_SLOTS_PER_DAY {
+			return nil, fmt.Errorf("%w: slot counts %q", ErrInvalidValue, value)
+		}
+		counts[timerDays[i]] = count
+	}
+	return counts, nil
+}
+
+// getTimerProgram reads the number of slots per day of the time program of target and then the slots in one batch
+func (c *EbusConnection) getTimerProgram(ctx context.Context, target TimerTarget) (WeeklySchedule, error) {
+	if err := target.validate(); err != nil {
+		return WeeklySchedule{}, err
+	}
+	circuit := c.controllerForSFMode
+	value, err := c.transport.Read(ctx, circuit, string(target)+EBUSDREAD_TIMER_TIMEFRAMES, 0)
+	if err != nil {
+		c.debug(fmt.Sprintf("could not read time slot counts of %s. Error: %s", target, err))
+		return WeeklySchedule{}, err
+	}
+	counts, err := parseSlotCounts(value)
+	if err != nil {
+		return WeeklySchedule{}, err
+	}
+	var refs []ElementRef
+	for _, day := range timerDays {
+		for slot := 0; slot < counts[day]; slot++ {
+			refs = append(refs, ElementRef{Circuit: circuit, Name: target.slotElement(day, slot), MaxAge: 0})
+		}
+	}
+	results, err := ReadMany(ctx, c.transport, refs)
+	if err != nil {
+		return WeeklySchedule{}, err
+	}
+	schedule := WeeklySchedule{Target: target, Days: make(map[time.Weekday][]TimeSlot)}
+	i := 0
+	for _, day := range timerDays {
+		schedule.Days[day] = []TimeSlot{}
+		for slot := 0; slot < counts[day]; slot++ {
+			result := results[i]
+			i++
+			if result.Err != nil {
+				c.debug(fmt.Sprintf("could not read %s. Error: %s", result.Ref.Name, result.Err))
+				return WeeklySchedule{}, result.Err
+			}
+			timeSlot, err := parseTimeSlot(result.Value, target.HasSetpoint())
+			if err != nil {
+				return WeeklySchedule{}, fmt.Errorf("%s: %w", result.Ref.Name, err)
+			}
+			schedule.Days[day] = append(schedule.Days[day], timeSlot)
+		}
+	}
+	return schedule, nil
+}
+
+// setTimerProgram writes the slots of the days in schedule, one telegram per slot. A day without slots is written
+// as a single empty slot with count 0. Afterwards the time program is read back and compared.
+func (c *EbusConnection) setTimerProgram(ctx context.Context, schedule WeeklySchedule) error {
+	if err := schedule.Validate(); err != nil {
+		return err
+	}
+	target := schedule.Target
+	circuit := c.controllerForSFMode
+	for _, day := range timerDays {
+		slots, ok := schedule.Days[day]
+		if !ok {
+			continue
+		}
+		values := []string{formatTimeSlot(0, 0, TimeSlot{}, target.HasSetpoint())}
+		if len(slots) > 0 {
+			values = values[:0]
+			for i, slot := range slots {
+				values = append(values, formatTimeSlot(len(slots), i, slot, target.HasSetpoint()))
+			}
+		}
+		for _, value := range values {
+			err := c.ebusdWrite(ctx, circuit, target.dayElement(day), value)
+			if err != nil {
+				c.debug(fmt.Sprintf("could not write %s. Error: %s", target.dayElement(day), err))
+				return err
+			}
+		}
+	}
+	readBack, err := c.getTimerProgram(ctx, target)
+	if err != nil {
+		return err
+	}
+	for day, slots := range schedule.Days {
+		if !sameTimeSlots(slots, readBack.Days[day]) {
+			c.debug(fmt.Sprintf("time program of %s on %s: written %v, read back %v", target, day, slots, readBack.Days[day]))
+			return fmt.Errorf("%w: time program of %s on %s", ErrNotConfirmed, target, day)
+		}
+	}
+	return nil
+}
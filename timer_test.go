@@ -0,0 +1,170 @@
+package sensonetEbus
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WulfgarW/sensonetEbus/ebusdtest"
+)
+
+func TestWeeklyScheduleValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		target TimerTarget
+		slots  []TimeSlot
+		err    error
+	}{
+		{"hotwater", TIMER_HOTWATER, []TimeSlot{{Start: 360, End: 480, Setpoint: 50}, {Start: 1020, End: 1320, Setpoint: 45}}, nil},
+		{"circulation", TIMER_CIRCULATION, []TimeSlot{{Start: 360, End: 480}}, nil},
+		{"zone", ZoneTimer(1), []TimeSlot{{Start: 0, End: END_OF_DAY, Setpoint: 20}}, nil},
+		{"no slots", TIMER_HOTWATER, []TimeSlot{}, nil},
+		{"unknown target", TimerTarget("x1"), nil, ErrInvalidParameter},
+		{"zone without time program", ZoneTimer(TIMER_MAX_ZONES + 1), nil, ErrInvalidParameter},
+		{"too many slots", TIMER_CIRCULATION, []TimeSlot{{Start: 0, End: 60}, {Start: 60, End: 120}, {Start: 120, End: 180}, {Start: 180, End: 240}}, ErrInvalidParameter},
+		{"end before start", TIMER_CIRCULATION, []TimeSlot{{Start: 480, End: 360}}, ErrInvalidParameter},
+		{"end after end of day", TIMER_CIRCULATION, []TimeSlot{{Start: 480, End: END_OF_DAY + 10}}, ErrInvalidParameter},
+		{"not a multiple of the granularity", TIMER_CIRCULATION, []TimeSlot{{Start: 365, End: 480}}, ErrInvalidParameter},
+		{"overlap", TIMER_CIRCULATION, []TimeSlot{{Start: 360, End: 480}, {Start: 470, End: 600}}, ErrInvalidParameter},
+		{"setpoint out of range", TIMER_HOTWATER, []TimeSlot{{Start: 360, End: 480, Setpoint: 80}}, ErrParameterOutOfRange},
+		{"setpoint of circulation", TIMER_CIRCULATION, []TimeSlot{{Start: 360, End: 480, Setpoint: 50}}, ErrInvalidParameter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule := WeeklySchedule{Target: tt.target, Days: map[time.Weekday][]TimeSlot{time.Tuesday: tt.slots}}
+			if err := schedule.Validate(); !errors.Is(err, tt.err) {
+				t.Errorf("Validate() = %v, expected %v", err, tt.err)
+			}
+		})
+	}
+}
+
+// weekSchedule returns a schedule of target with the same slots on every day
+func weekSchedule(target TimerTarget, slots ...TimeSlot) WeeklySchedule {
+	schedule := WeeklySchedule{Target: target, Days: make(map[time.Weekday][]TimeSlot)}
+	for _, day := range timerDays {
+		schedule.Days[day] = slots
+	}
+	return schedule
+}
+
+func TestGetTimerProgram(t *testing.T) {
+	ctl := ebusdtest.DEFAULT_CONTROLLER_NAME
+	tests := []struct {
+		name     string
+		target   TimerTarget
+		setup    func(server *ebusdtest.Server)
+		schedule func() WeeklySchedule
+		err      error
+	}{
+		{"hotwater", TIMER_HOTWATER, nil, func() WeeklySchedule {
+			return weekSchedule(TIMER_HOTWATER, TimeSlot{Start: 360, End: 1320, Setpoint: 50})
+		}, nil},
+		{"circulation with two slots on Tuesday and none on Sunday", TIMER_CIRCULATION, func(server *ebusdtest.Server) {
+			server.Set(ctl, "ccTimer.Timeframes", "1;2;1;1;1;1;0")
+			server.Set(ctl, "ccTimer.Tuesday0", "06:00;08:00")
+			server.Set(ctl, "ccTimer.Tuesday1", "17:30;22:00")
+		}, func() WeeklySchedule {
+			schedule := weekSchedule(TIMER_CIRCULATION, TimeSlot{Start: 360, End: 1320})
+			schedule.Days[time.Tuesday] = []TimeSlot{{Start: 360, End: 480}, {Start: 1050, End: 1320}}
+			schedule.Days[time.Sunday] = []TimeSlot{}
+			return schedule
+		}, nil},
+		{"zone 2", ZoneTimer(2), func(server *ebusdtest.Server) {
+			server.Set(ctl, "z2Timer.Monday0", "05:30;24:00;21.5")
+		}, func() WeeklySchedule {
+			schedule := weekSchedule(ZoneTimer(2), TimeSlot{Start: 360, End: 1320, Setpoint: 20})
+			schedule.Days[time.Monday] = []TimeSlot{{Start: 330, End: END_OF_DAY, Setpoint: 21.5}}
+			return schedule
+		}, nil},
+		{"zone without time program", ZoneTimer(TIMER_MAX_ZONES + 1), nil, nil, ErrInvalidParameter},
+		{"ERR: reply for a slot", TIMER_HOTWATER, func(server *ebusdtest.Server) {
+			server.SetError("hwcTimer.Wednesday0", ebusdtest.REPLY_NOSIGNAL)
+		}, nil, ErrNoSignal},
+		{"invalid slot", TIMER_HOTWATER, func(server *ebusdtest.Server) {
+			server.Set(ctl, "hwcTimer.Friday0", "06:00")
+		}, nil, ErrInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, conn := newTestSystem(t)
+			server.PopulateVaillantTimers(2)
+			if tt.setup != nil {
+				tt.setup(server)
+			}
+			schedule, err := conn.GetTimerProgram(tt.target)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("GetTimerProgram() = %v, expected %v", err, tt.err)
+			}
+			if tt.err == nil && !reflect.DeepEqual(schedule, tt.schedule()) {
+				t.Errorf("GetTimerProgram() = %v, expected %v", schedule, tt.schedule())
+			}
+		})
+	}
+}
+
+func TestSetTimerProgram(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule WeeklySchedule
+		setup    func(server *ebusdtest.Server)
+		writes   []string // values written to the day element
+		err      error
+	}{
+		{"hotwater with setpoints", WeeklySchedule{Target: TIMER_HOTWATER, Days: map[time.Weekday][]TimeSlot{
+			time.Monday: {{Start: 360, End: 480, Setpoint: 50}, {Start: 1020, End: 1320, Setpoint: 45.5}},
+		}}, nil, []string{"hwcTimer.Monday 2;0;06:00;08:00;50.0", "hwcTimer.Monday 2;1;17:00;22:00;45.5"}, nil},
+		{"circulation without setpoints", WeeklySchedule{Target: TIMER_CIRCULATION, Days: map[time.Weekday][]TimeSlot{
+			time.Saturday: {{Start: 420, End: 540}},
+			time.Sunday:   {},
+		}}, nil, []string{"ccTimer.Saturday 1;0;07:00;09:00", "ccTimer.Sunday 0;0;00:00;00:00"}, nil},
+		{"zone until the end of the day", WeeklySchedule{Target: ZoneTimer(2), Days: map[time.Weekday][]TimeSlot{
+			time.Friday: {{Start: 300, End: END_OF_DAY, Setpoint: 21}},
+		}}, nil, []string{"z2Timer.Friday 1;0;05:00;24:00;21.0"}, nil},
+		{"write not applied by the controller", WeeklySchedule{Target: TIMER_HOTWATER, Days: map[time.Weekday][]TimeSlot{
+			time.Monday: {{Start: 360, End: 480, Setpoint: 50}},
+		}}, func(server *ebusdtest.Server) {
+			server.IgnoreWrites("hwcTimer.Monday", true)
+		}, []string{"hwcTimer.Monday 1;0;06:00;08:00;50.0"}, ErrNotConfirmed},
+		{"invalid schedule", WeeklySchedule{Target: TIMER_CIRCULATION, Days: map[time.Weekday][]TimeSlot{
+			time.Monday: {{Start: 360, End: 480, Setpoint: 50}},
+		}}, nil, nil, ErrInvalidParameter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, conn := newTestSystem(t)
+			server.PopulateVaillantTimers(2)
+			if tt.setup != nil {
+				tt.setup(server)
+			}
+			server.ResetCommands()
+			err := conn.SetTimerProgram(tt.schedule.Target, tt.schedule)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("SetTimerProgram() = %v, expected %v", err, tt.err)
+			}
+			var writes []string
+			for _, command := range server.Commands() {
+				if strings.HasPrefix(command, "write ") {
+					writes = append(writes, strings.TrimPrefix(command, "write -c "+ebusdtest.DEFAULT_CONTROLLER_NAME+" "))
+				}
+			}
+			if !reflect.DeepEqual(writes, tt.writes) {
+				t.Errorf("written %q, expected %q", writes, tt.writes)
+			}
+			if tt.err != nil {
+				return
+			}
+			schedule, err := conn.GetTimerProgram(tt.schedule.Target)
+			if err != nil {
+				t.Fatalf("GetTimerProgram() failed: %s", err)
+			}
+			for day, slots := range tt.schedule.Days {
+				if !reflect.DeepEqual(schedule.Days[day], slots) {
+					t.Errorf("%s read back as %v, expected %v", day, schedule.Days[day], slots)
+				}
+			}
+		})
+	}
+}
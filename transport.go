@@ -0,0 +1,95 @@
+package sensonetEbus
+
+import "context"
+
+// Transport is the access to ebusd (or to the ebus itself) used by the connection.
+// Implementations have to be safe for concurrent use. ERR: replies of ebusd are returned as *EbusdError.
+type Transport interface {
+	// Read returns the value of element name. If circuit is empty, ebusd picks the circuit.
+	// maxAge is the maximum age of a cached value in seconds. A negative maxAge uses the default of ebusd.
+	Read(ctx context.Context, circuit, name string, maxAge int) (string, error)
+	// Write writes value to the element name of circuit
+	Write(ctx context.Context, circuit, name, value string) error
+	// Find returns the circuits, that define the element name
+	Find(ctx context.Context, name string) ([]string, error)
+	// Scan returns the result of the device scan of ebusd ("scan result")
+	Scan(ctx context.Context) (string, error)
+	// Info returns the output of the ebusd command "info"
+	Info(ctx context.Context) (string, error)
+	// State returns the output of the ebusd command "state", e.g. "signal acquired, 24 symbols/sec (122 max), 3 masters"
+	State(ctx context.Context) (string, error)
+	// Close releases all resources of the transport
+	Close() error
+}
+
+// ElementRef identifies an element to be read by ReadMany()
+type ElementRef struct {
+	Circuit string // if empty, ebusd picks the circuit
+	Name    string
+	MaxAge  int // maximum age of a cached value in seconds, a negative value uses the default of ebusd
+}
+
+// ReadResult is the value read for Ref or the error returned for it
+type ReadResult struct {
+	Ref   ElementRef
+	Value string
+	Err   error
+}
+
+// BatchReader is implemented by transports that read several elements faster than one after another,
+// e.g. by pipelining the commands over one session
+type BatchReader interface {
+	// ReadMany returns one result per ref in the order of refs. See ReadMany() for the returned error.
+	ReadMany(ctx context.Context, refs []ElementRef) ([]ReadResult, error)
+}
+
+// ReadMany reads all refs over transport and returns one result per ref in the order of refs.
+// ERR: replies of ebusd are returned per element. If the session to ebusd fails (e.g. network error,
+// ctx done), the error is returned and set for all elements that could not be read.
+// Transports implementing BatchReader are used for that, all others read the elements one after another.
+func ReadMany(ctx context.Context, transport Transport, refs []ElementRef) ([]ReadResult, error) {
+	if batchReader, ok := transport.(BatchReader); ok {
+		return batchReader.ReadMany(ctx, refs)
+	}
+	return readSequentially(ctx, transport, refs)
+}
+
+func readSequentially(ctx context.Context, transport Transport, refs []ElementRef) ([]ReadResult, error) {
+	results := make([]ReadResult, len(refs))
+	var sessionErr error
+	for i, ref := range refs {
+		results[i].Ref = ref
+		if sessionErr != nil {
+			results[i].Err = sessionErr
+			continue
+		}
+		results[i].Value, results[i].Err = transport.Read(ctx, ref.Circuit, ref.Name, ref.MaxAge)
+		if results[i].Err != nil && !isEbusdError(results[i].Err) {
+			sessionErr = results[i].Err
+		}
+	}
+	return results, sessionErr
+}
+
+// Listener is implemented by transports that get the changes of element values pushed by ebusd
+// (the "listen" mode of ebusd)
+type Listener interface {
+	// Listen calls fn for every update of an element value sent by ebusd. It blocks until ctx is done
+	// (returning ctx.Err()) or the session fails.
+	Listen(ctx context.Context, fn func(circuit, name, value string)) error
+}
+
+// DefinitionFinder is implemented by transports that can list the message definitions loaded by ebusd
+type DefinitionFinder interface {
+	// FindDefinitions returns the message definitions of circuit as CSV lines with the columns
+	// of "find -F type,circuit,name,zz,pbsb,id,fields"
+	FindDefinitions(ctx context.Context, circuit string) ([]string, error)
+}
+
+// HexSender is implemented by transports that can send raw telegrams to the bus (the command "hex" of ebusd,
+// which has to be enabled with the option --enablehex of ebusd)
+type HexSender interface {
+	// Hex sends the master part of a telegram given as hex (ZZ PB SB NN data, without QQ and CRC) and returns
+	// the response of the slave as hex (NN data)
+	Hex(ctx context.Context, telegram string) (string, error)
+}
@@ -0,0 +1,642 @@
+package sensonetEbus
+
+import (
+	"bufio"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/WulfgarW/sensonetEbus/ebus"
+	"github.com/WulfgarW/sensonetEbus/ebusdcsv"
+)
+
+const (
+	ADAPTER_MASTER_ADDRESS      = 0x31 // default master address, the same as the one of ebusd
+	ADAPTER_SYMBOL_TIMEOUT      = 1000 // milliseconds to wait for the echo of a symbol or the next symbol of the slave
+	ADAPTER_ARBITRATION_TIMEOUT = 3000 // milliseconds to wait for the bus
+	ADAPTER_SIGNAL_TIMEOUT      = 1000 // milliseconds to wait for a symbol on the bus when checking the signal
+
+	// requests of the enhanced protocol sent to the adapter
+	ENH_REQ_INIT  = 0x0 // data: features of the host
+	ENH_REQ_SEND  = 0x1 // data: symbol to send
+	ENH_REQ_START = 0x2 // data: master address to arbitrate with, SYN to cancel
+	ENH_REQ_INFO  = 0x3
+
+	// responses of the adapter in the enhanced protocol
+	ENH_RES_RESETTED   = 0x0 // data: features of the adapter
+	ENH_RES_RECEIVED   = 0x1 // data: symbol received from the bus
+	ENH_RES_STARTED    = 0x2 // data: master address that won the arbitration
+	ENH_RES_INFO       = 0x3
+	ENH_RES_FAILED     = 0xa // data: master address that won the arbitration instead
+	ENH_RES_ERROR_EBUS = 0xb // data: error code
+	ENH_RES_ERROR_HOST = 0xc // data: error code
+)
+
+// adapterScanAddresses are the slaves identified by Scan(): heat pump (hmu), controller (ctlv2) and the indoor unit (vwz00)
+var adapterScanAddresses = []byte{0x08, 0x15, 0x76}
+
+// manufacturers are the names ebusd shows in the scan result for the manufacturer byte of the identification
+var manufacturers = map[byte]string{0xb5: "Vaillant", 0x19: "Wolf", 0x50: "Kromschroeder", 0xc5: "Weishaupt"}
+
+// enhancedEncode returns the two bytes of a request of the enhanced protocol: 11cccc dd, 10 dddddd
+func enhancedEncode(command, data byte) []byte {
+	return []byte{0xc0 | command<<2 | data>>6, 0x80 | data&0x3f}
+}
+
+// readEnhanced reads the next message of the adapter. Bytes below 0x80 are symbols received from the bus,
+// all other messages take two bytes. Incomplete messages are skipped.
+func readEnhanced(r *bufio.Reader) (command, data byte, err error) {
+	for {
+		first, err := r.ReadByte()
+		if err != nil {
+			return 0, 0, err
+		}
+		if first&0x80 == 0 {
+			return ENH_RES_RECEIVED, first, nil
+		}
+		if first&0xc0 != 0xc0 {
+			continue
+		}
+		second, err := r.ReadByte()
+		if err != nil {
+			return 0, 0, err
+		}
+		if second&0xc0 != 0x80 {
+			_ = r.UnreadByte()
+			continue
+		}
+		return first >> 2 & 0x0f, first<<6 | second&0x3f, nil
+	}
+}
+
+// busError is an error on the bus reported like ebusd does, e.g. "ERR: arbitration lost"
+func busError(reply string) error {
+	return &EbusdError{Reply: reply}
+}
+
+// adapterTransport talks the enhanced protocol of the ebusd adapters (e.g. the adapter v5 via TCP) directly, without ebusd.
+// It arbitrates for the bus itself and sends the messages of the bundled configuration files, which are decoded
+// by the package ebus. Errors on the bus are returned as *EbusdError with the reply ebusd would give,
+// so that the retry policy handles them the same way.
+type adapterTransport struct {
+	mu             ctxMutex // serializes the access to the bus
+	logger         Logger
+	adapterAddress string
+	master         byte
+	messages       nativeMessages
+	files          map[string]string // name of the bundled file per slave address
+	conn           net.Conn
+	reader         *bufio.Reader
+	scanned        map[byte]string // identification per slave address found by Scan()
+	closed         bool
+}
+
+// NewAdapterTransport returns a transport using an eBUS adapter with the enhanced protocol, e.g. "192.168.1.20:9999".
+// master is the own master address on the bus, e.g. ADAPTER_MASTER_ADDRESS.
+func NewAdapterTransport(adapterAddress string, master byte) (Transport, error) {
+	return newAdapterTransport(adapterAddress, master, nil)
+}
+
+func newAdapterTransport(adapterAddress string, master byte, logger Logger) (*adapterTransport, error) {
+	if !ebus.IsMaster(master) {
+		return nil, fmt.Errorf("%w: %02x is no master address", ErrInvalidAddress, master)
+	}
+	t := &adapterTransport{}
+	t.mu = newCtxMutex()
+	t.logger = logger
+	t.adapterAddress = adapterAddress
+	t.master = master
+	t.scanned = make(map[byte]string)
+	t.files = make(map[string]string)
+	var err error
+	t.messages, err = bundledNativeMessages()
+	if t.messages == nil {
+		return nil, err
+	}
+	if err != nil {
+		t.debug(fmt.Sprintf("Messages of the bundled configuration files not supported:\n%s", err))
+	}
+	files, err := bundledFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		t.files[file.ZZ] = file.Name
+	}
+	return t, nil
+}
+
+func (t *adapterTransport) debug(fmt string, arg ...any) {
+	if t.logger != nil {
+		t.logger.Printf(fmt, arg...)
+	}
+}
+
+// withCommand adds the command and the element to bus errors
+func withCommand(err error, command, circuit, name string) error {
+	var ebusdErr *EbusdError
+	if errors.As(err, &ebusdErr) {
+		return &EbusdError{Command: command, Element: name, Circuit: circuit, Reply: ebusdErr.Reply}
+	}
+	return err
+}
+
+func (t *adapterTransport) Read(ctx context.Context, circuit, name string, maxAge int) (string, error) {
+	command := readCommand(circuit, name, maxAge)
+	message, ok := t.messages.lookup(ebusdcsv.TYPE_READ, circuit, name)
+	if !ok {
+		return "", &EbusdError{Command: command, Element: name, Circuit: circuit, Reply: "ERR: element not found"}
+	}
+	err := t.mu.Lock(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer t.mu.Unlock()
+	response, err := t.transfer(ctx, message.zz, message.pbsb, message.id)
+	if err != nil {
+		err = withCommand(err, command, circuit, name)
+		t.debug(fmt.Sprintf("Reading from the adapter failed: %s", err))
+		return "", err
+	}
+	value, err := ebus.DecodeFields(message.fields, response)
+	if err != nil {
+		t.debug(fmt.Sprintf("Command: %s, response %x cannot be decoded: %s", command, response, err))
+		return "", &EbusdError{Command: command, Element: name, Circuit: circuit, Reply: "ERR: invalid position in decode"}
+	}
+	return value, nil
+}
+
+func (t *adapterTransport) Write(ctx context.Context, circuit, name, value string) error {
+	command := "write -c " + circuit + " " + name + " " + value
+	message, ok := t.messages.lookup(ebusdcsv.TYPE_WRITE, circuit, name)
+	if !ok {
+		return &EbusdError{Command: command, Element: name, Circuit: circuit, Reply: "ERR: element not found"}
+	}
+	data, err := message.request(value)
+	if err != nil {
+		t.debug(fmt.Sprintf("Command: %s, value cannot be encoded: %s", command, err))
+		return valueError(err, command, circuit, name)
+	}
+	err = t.mu.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer t.mu.Unlock()
+	_, err = t.transfer(ctx, message.zz, message.pbsb, data)
+	t.debug(fmt.Sprintf("Command sent to the adapter: %s", command))
+	if err != nil {
+		err = withCommand(err, command, circuit, name)
+		t.debug(fmt.Sprintf("Writing to the adapter failed: %s", err))
+		return err
+	}
+	return nil
+}
+
+// Find returns the circuit of the bundled configuration files defining the element name
+func (t *adapterTransport) Find(ctx context.Context, name string) ([]string, error) {
+	message, ok := t.messages.lookup(ebusdcsv.TYPE_READ, "", name)
+	if !ok {
+		return nil, &EbusdError{Command: "find " + name, Element: name, Reply: "ERR: element not found"}
+	}
+	return []string{message.circuit}, nil
+}
+
+// Scan identifies the slaves in adapterScanAddresses and returns them like the ebusd command "scan result"
+func (t *adapterTransport) Scan(ctx context.Context) (string, error) {
+	err := t.mu.Lock(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer t.mu.Unlock()
+	var result strings.Builder
+	for _, zz := range adapterScanAddresses {
+		response, err := t.transfer(ctx, zz, ebus.PB_IDENTIFICATION<<8|ebus.SB_IDENTIFICATION, nil)
+		if isEbusdError(err) {
+			t.debug(fmt.Sprintf("Identification of slave %02x failed: %s", zz, err))
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if len(response) < 10 {
+			t.debug(fmt.Sprintf("Invalid identification %x of slave %02x", response, zz))
+			continue
+		}
+		manufacturer, ok := manufacturers[response[0]]
+		if !ok {
+			manufacturer = fmt.Sprintf("%02x", response[0])
+		}
+		id := strings.TrimRight(string(response[1:6]), " \x00")
+		t.scanned[zz] = fmt.Sprintf("MF=%s;ID=%s;SW=%04x;HW=%04x", manufacturer, id, response[6:8], response[8:10])
+		result.WriteString(fmt.Sprintf("%02x;%s;%s;%04x;%04x\n", zz, manufacturer, id, response[6:8], response[8:10]))
+	}
+	return result.String(), nil
+}
+
+// Info renders the state of the adapter and the slaves identified by Scan() like the output of the ebusd command "info"
+func (t *adapterTransport) Info(ctx context.Context) (string, error) {
+	err := t.mu.Lock(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer t.mu.Unlock()
+	signal, err := t.signal(ctx)
+	if err != nil {
+		return "", err
+	}
+	var info strings.Builder
+	info.WriteString("version: sensonetEbus adapter transport\n")
+	info.WriteString(fmt.Sprintf("device: %s, enhanced\n", t.adapterAddress))
+	if signal {
+		info.WriteString("signal: acquired\n")
+	} else {
+		info.WriteString("signal: no signal\n")
+	}
+	info.WriteString(fmt.Sprintf("messages: %d\n", len(t.messages)))
+	info.WriteString(fmt.Sprintf("address %02x: master, ebusd\n", t.master))
+	for _, zz := range adapterScanAddresses {
+		scanned, ok := t.scanned[zz]
+		if !ok {
+			continue
+		}
+		line := fmt.Sprintf("address %02x: slave, scanned \"%s\"", zz, scanned)
+		if file, ok := t.files[fmt.Sprintf("%02x", zz)]; ok {
+			line += fmt.Sprintf(", loaded \"%s\"", file)
+		}
+		info.WriteString(line + "\n")
+	}
+	return info.String(), nil
+}
+
+// State returns "signal acquired" or "no signal", depending on symbols being received from the bus
+func (t *adapterTransport) State(ctx context.Context) (string, error) {
+	err := t.mu.Lock(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer t.mu.Unlock()
+	signal, err := t.signal(ctx)
+	if err != nil {
+		return "", err
+	}
+	if signal {
+		return "signal acquired", nil
+	}
+	return "no signal", nil
+}
+
+// Hex sends the telegram (ZZ PB SB NN data) and returns the response of the slave (NN data). Telegrams
+// to masters and broadcasts have no response, so an empty one ("00") is returned for them.
+func (t *adapterTransport) Hex(ctx context.Context, telegram string) (string, error) {
+	command := "hex " + telegram
+	data, err := hex.DecodeString(telegram)
+	if err != nil || len(data) < 4 || int(data[3]) != len(data)-4 {
+		return "", &EbusdError{Command: command, Reply: "ERR: invalid argument"}
+	}
+	err = t.mu.Lock(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer t.mu.Unlock()
+	response, err := t.transfer(ctx, data[0], uint16(data[1])<<8|uint16(data[2]), data[4:])
+	if err != nil {
+		err = withCommand(err, command, "", "")
+		t.debug(fmt.Sprintf("Sending to the adapter failed: %s", err))
+		return "", err
+	}
+	return hex.EncodeToString(append([]byte{byte(len(response))}, response...)), nil
+}
+
+func (t *adapterTransport) Close() error {
+	_ = t.mu.Lock(context.Background())
+	defer t.mu.Unlock()
+	t.closed = true
+	t.dropConnection()
+	return nil
+}
+
+// ensureConnection opens the session to the adapter and initializes it. The caller must hold t.mu.
+func (t *adapterTransport) ensureConnection(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if t.closed {
+		return errors.New("adapter connection already closed")
+	}
+	if t.conn != nil {
+		return nil
+	}
+	dialer := net.Dialer{KeepAlive: EBUSD_KEEPALIVE_INTERVAL * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", t.adapterAddress)
+	if err != nil {
+		return err
+	}
+	t.conn = conn
+	t.reader = bufio.NewReader(conn)
+	_, err = t.conn.Write(enhancedEncode(ENH_REQ_INIT, 0x00))
+	if err != nil {
+		t.dropConnection()
+		return err
+	}
+	// adapters without INIT support do not answer, so a missing RESETTED is not an error
+	for {
+		command, data, err := t.receive(ctx, ADAPTER_SYMBOL_TIMEOUT)
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			t.debug("Adapter did not answer INIT")
+			return nil
+		}
+		if err != nil {
+			t.dropConnection()
+			return err
+		}
+		if command == ENH_RES_RESETTED {
+			t.debug(fmt.Sprintf("Adapter initialized, features %02x", data))
+			return nil
+		}
+	}
+}
+
+// dropConnection closes the session after an error that leaves it in an unknown state.
+// The next command reopens it. The caller must hold t.mu.
+func (t *adapterTransport) dropConnection() {
+	if t.conn != nil {
+		t.conn.Close()
+		t.conn = nil
+		t.reader = nil
+	}
+}
+
+// receive returns the next message of the adapter. It waits at most timeout milliseconds and not beyond the deadline of ctx.
+func (t *adapterTransport) receive(ctx context.Context, timeout int) (command, data byte, err error) {
+	if ctx.Err() != nil {
+		return 0, 0, ctx.Err()
+	}
+	deadline := time.Now().Add(time.Duration(timeout) * time.Millisecond)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	_ = t.conn.SetReadDeadline(deadline)
+	return readEnhanced(t.reader)
+}
+
+// signal returns true, if a symbol is received from the bus in ADAPTER_SIGNAL_TIMEOUT. The caller must hold t.mu.
+func (t *adapterTransport) signal(ctx context.Context) (bool, error) {
+	err := t.ensureConnection(ctx)
+	if err != nil {
+		return false, err
+	}
+	_, _ = t.reader.Discard(t.reader.Buffered())
+	deadline := time.Now().Add(ADAPTER_SIGNAL_TIMEOUT * time.Millisecond)
+	for time.Now().Before(deadline) {
+		command, _, err := t.receive(ctx, int(time.Until(deadline)/time.Millisecond)+1)
+		if errors.Is(err, os.ErrDeadlineExceeded) && ctx.Err() == nil {
+			return false, nil
+		}
+		if err != nil {
+			t.dropConnection()
+			return false, err
+		}
+		if command == ENH_RES_RECEIVED {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// transfer sends a telegram with data to the slave zz and returns the data of its response. Errors on the bus are
+// returned as *EbusdError, after other errors the session is dropped. The caller must hold t.mu.
+func (t *adapterTransport) transfer(ctx context.Context, zz byte, pbsb uint16, data []byte) ([]byte, error) {
+	if len(data) > 0xff || zz == ebus.SYN || zz == ebus.ESC {
+		return nil, busError("ERR: invalid argument")
+	}
+	err := t.ensureConnection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conn := t.conn
+	_ = conn.SetDeadline(time.Time{})
+	stop := context.AfterFunc(ctx, func() {
+		// a deadline in the past unblocks a pending read immediately
+		_ = conn.SetDeadline(time.Unix(1, 0))
+	})
+	defer stop()
+	master := ebus.Telegram{QQ: t.master, ZZ: zz, PBSB: pbsb, Data: data}.Master()
+	response, err := t.sendTelegram(ctx, master)
+	if err != nil && !isEbusdError(err) {
+		t.debug(fmt.Sprintf("Error in session to the adapter: %s", err))
+		t.dropConnection()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	return response, err
+}
+
+// sendTelegram arbitrates for the bus, sends the master part (QQ ZZ PB SB NN data CRC), receives the response
+// of the slave and releases the bus again with SYN
+func (t *adapterTransport) sendTelegram(ctx context.Context, master []byte) ([]byte, error) {
+	err := t.arbitrate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	response, err := t.exchangeTelegram(ctx, master)
+	if err == nil || isEbusdError(err) {
+		_, releaseErr := t.conn.Write(enhancedEncode(ENH_REQ_SEND, ebus.SYN))
+		if releaseErr != nil {
+			return nil, releaseErr
+		}
+	}
+	return response, err
+}
+
+// arbitrate makes the adapter send the own master address after the next SYN and waits for the result
+func (t *adapterTransport) arbitrate(ctx context.Context) error {
+	_, err := t.conn.Write(enhancedEncode(ENH_REQ_START, t.master))
+	if err != nil {
+		return err
+	}
+	received := false
+	deadline := time.Now().Add(ADAPTER_ARBITRATION_TIMEOUT * time.Millisecond)
+	for {
+		command, data, err := t.receive(ctx, max(int(time.Until(deadline)/time.Millisecond), 1))
+		if errors.Is(err, os.ErrDeadlineExceeded) && ctx.Err() == nil {
+			// cancel the arbitration
+			_, err = t.conn.Write(enhancedEncode(ENH_REQ_START, ebus.SYN))
+			if err != nil {
+				return err
+			}
+			if received {
+				return busError("ERR: arbitration lost")
+			}
+			return busError("ERR: no signal")
+		}
+		if err != nil {
+			return err
+		}
+		switch command {
+		case ENH_RES_RECEIVED:
+			received = true
+		case ENH_RES_STARTED:
+			if data != t.master {
+				return busError("ERR: arbitration lost")
+			}
+			return nil
+		case ENH_RES_FAILED:
+			return busError("ERR: arbitration lost")
+		case ENH_RES_ERROR_EBUS, ENH_RES_ERROR_HOST:
+			t.debug(fmt.Sprintf("Adapter reported error %x/%02x during arbitration", command, data))
+			return busError("ERR: send error")
+		}
+	}
+}
+
+// exchangeTelegram sends the master part after the won arbitration and receives the response. A master part
+// answered with NAK is repeated once, as well as a response of the slave with wrong CRC.
+func (t *adapterTransport) exchangeTelegram(ctx context.Context, master []byte) ([]byte, error) {
+	zz := master[1]
+	// the master address was already sent by the arbitration
+	symbols := ebus.Escape(master[1:])
+	for attempt := 0; ; attempt++ {
+		err := t.sendSymbols(ctx, symbols)
+		if err != nil {
+			return nil, err
+		}
+		if zz == ebus.BROADCAST {
+			return nil, nil
+		}
+		ack, err := t.receiveSymbol(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if ack == ebus.ACK {
+			break
+		}
+		if ack != ebus.NAK {
+			return nil, busError("ERR: ACK error")
+		}
+		if attempt > 0 {
+			return nil, busError("ERR: NAK received")
+		}
+		symbols = ebus.Escape(master)
+	}
+	if ebus.IsMaster(zz) {
+		return nil, nil
+	}
+	for attempt := 0; ; attempt++ {
+		response, err := t.receiveResponse(ctx)
+		if err == nil {
+			return response, t.sendSymbols(ctx, []byte{ebus.ACK})
+		}
+		if !errors.Is(err, ErrCRC) {
+			return nil, err
+		}
+		nakErr := t.sendSymbols(ctx, []byte{ebus.NAK})
+		if nakErr != nil {
+			return nil, nakErr
+		}
+		if attempt > 0 {
+			return nil, err
+		}
+	}
+}
+
+// sendSymbols sends the (escaped) symbols one by one and checks their echo from the bus
+func (t *adapterTransport) sendSymbols(ctx context.Context, symbols []byte) error {
+	for _, symbol := range symbols {
+		_, err := t.conn.Write(enhancedEncode(ENH_REQ_SEND, symbol))
+		if err != nil {
+			return err
+		}
+		echo, err := t.receiveSymbol(ctx)
+		if errors.Is(err, ErrTimeout) {
+			return busError("ERR: send error")
+		}
+		if err != nil {
+			return err
+		}
+		if echo != symbol {
+			if echo == ebus.SYN {
+				return busError("ERR: SYN received")
+			}
+			return busError("ERR: wrong symbol received")
+		}
+	}
+	return nil
+}
+
+// receiveSymbol returns the next symbol received from the bus
+func (t *adapterTransport) receiveSymbol(ctx context.Context) (byte, error) {
+	for {
+		command, data, err := t.receive(ctx, ADAPTER_SYMBOL_TIMEOUT)
+		if errors.Is(err, os.ErrDeadlineExceeded) && ctx.Err() == nil {
+			return 0, busError("ERR: read timeout")
+		}
+		if err != nil {
+			return 0, err
+		}
+		switch command {
+		case ENH_RES_RECEIVED:
+			return data, nil
+		case ENH_RES_ERROR_EBUS, ENH_RES_ERROR_HOST:
+			t.debug(fmt.Sprintf("Adapter reported error %x/%02x", command, data))
+			return 0, busError("ERR: send error")
+		}
+	}
+}
+
+// receiveResponse receives the response of the slave (NN data CRC) and returns its data
+func (t *adapterTransport) receiveResponse(ctx context.Context) ([]byte, error) {
+	var crc byte
+	nextByte := func() (byte, error) {
+		symbol, err := t.receiveSymbol(ctx)
+		if err != nil {
+			return 0, err
+		}
+		crc = ebus.CRCUpdate(crc, symbol)
+		switch symbol {
+		case ebus.SYN:
+			return 0, busError("ERR: SYN received")
+		case ebus.ESC:
+			symbol, err = t.receiveSymbol(ctx)
+			if err != nil {
+				return 0, err
+			}
+			crc = ebus.CRCUpdate(crc, symbol)
+			switch symbol {
+			case 0x00:
+				return ebus.ESC, nil
+			case 0x01:
+				return ebus.SYN, nil
+			}
+			return 0, busError("ERR: invalid escape sequence")
+		}
+		return symbol, nil
+	}
+	length, err := nextByte()
+	if err != nil {
+		return nil, err
+	}
+	response := make([]byte, length)
+	for i := range response {
+		response[i], err = nextByte()
+		if err != nil {
+			return nil, err
+		}
+	}
+	expected := crc
+	received, err := nextByte()
+	if err != nil {
+		return nil, err
+	}
+	if received != expected {
+		t.debug(fmt.Sprintf("CRC %02x of the response %x is wrong, expected %02x", received, response, expected))
+		return nil, busError("ERR: CRC error")
+	}
+	return response, nil
+}
@@ -0,0 +1,100 @@
+package sensonetEbus
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/WulfgarW/sensonetEbus/ebusdtest"
+)
+
+// newTestAdapter starts a fake adapter and returns a transport talking to it
+func newTestAdapter(t *testing.T) (*ebusdtest.Adapter, *adapterTransport) {
+	t.Helper()
+	adapter, err := ebusdtest.NewAdapter()
+	if err != nil {
+		t.Fatalf("NewAdapter() failed: %s", err)
+	}
+	t.Cleanup(func() { adapter.Close() })
+	transport, err := newAdapterTransport(adapter.Addr(), ADAPTER_MASTER_ADDRESS, nil)
+	if err != nil {
+		t.Fatalf("newAdapterTransport() failed: %s", err)
+	}
+	t.Cleanup(func() { transport.Close() })
+	return adapter, transport
+}
+
+func TestAdapterTransportHex(t *testing.T) {
+	tests := []struct {
+		name      string
+		telegram  string // ZZ PB SB NN data
+		response  string // NN data
+		setup     func(adapter *ebusdtest.Adapter)
+		err       error
+		telegrams int // master parts received by the slave with correct CRC
+	}{
+		{"plain", "15b5090124", "020102", nil, nil, 1},
+		{"escaped data", "15b50903a9aa01", "04a9aa00a9", nil, nil, 1},
+		{"broadcast", "feb516080030151018102601", "00", nil, nil, 1},
+		{"master", "10b5090124", "00", nil, nil, 1},
+		{"arbitration lost", "15b5090124", "020102", func(adapter *ebusdtest.Adapter) { adapter.LoseArbitration(1) }, ErrArbitrationLost, 0},
+		{"master part NAKed once", "15b5090124", "020102", func(adapter *ebusdtest.Adapter) { adapter.NAKNext(1) }, nil, 2},
+		{"master part NAKed twice", "15b5090124", "020102", func(adapter *ebusdtest.Adapter) { adapter.NAKNext(2) }, ErrNAK, 2},
+		{"response with wrong CRC", "15b5090124", "020102", func(adapter *ebusdtest.Adapter) { adapter.CorruptNext(1) }, nil, 1},
+		{"escaped response with wrong CRC", "15b50903a9aa01", "04a9aa00a9", func(adapter *ebusdtest.Adapter) { adapter.CorruptNext(1) }, nil, 1},
+		{"no response", "15b50901ff", "", nil, ErrTimeout, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter, transport := newTestAdapter(t)
+			if tt.response != "" {
+				adapter.SetResponse(tt.telegram, tt.response)
+			}
+			if tt.setup != nil {
+				tt.setup(adapter)
+			}
+			response, err := transport.Hex(context.Background(), tt.telegram)
+			switch {
+			case tt.err != nil && !errors.Is(err, tt.err):
+				t.Errorf("Hex() = %q, %v, expected %v", response, err, tt.err)
+			case tt.err == nil && err != nil:
+				t.Errorf("Hex() failed: %s", err)
+			case tt.err == nil && response != tt.response:
+				t.Errorf("Hex() = %q, expected %q", response, tt.response)
+			}
+			telegrams := 0
+			for _, telegram := range adapter.Telegrams() {
+				if telegram == tt.telegram {
+					telegrams++
+				}
+			}
+			if telegrams != tt.telegrams {
+				t.Errorf("slave received the telegram %d times, expected %d. Telegrams: %v", telegrams, tt.telegrams, adapter.Telegrams())
+			}
+		})
+	}
+}
+
+func TestAdapterTransportRetry(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(adapter *ebusdtest.Adapter)
+	}{
+		{"arbitration lost", func(adapter *ebusdtest.Adapter) { adapter.LoseArbitration(2) }},
+		{"NAK received", func(adapter *ebusdtest.Adapter) { adapter.NAKNext(2) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter, transport := newTestAdapter(t)
+			adapter.SetResponse("15b5090124", "020102")
+			tt.setup(adapter)
+			policy := testRetryPolicy()
+			policy.RetryWrites = true // raw telegrams are only retried with RetryWrites
+			retry := newRetryTransport(transport, policy, nil)
+			response, err := retry.Hex(context.Background(), "15b5090124")
+			if err != nil || response != "020102" {
+				t.Errorf("Hex() = %q, %v, expected \"020102\"", response, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,370 @@
+package sensonetEbus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const HTTP_READ_CONCURRENCY = 4
+
+// httpTransport uses the HTTP JSON API of ebusd ("/data/..." on the ebusd HTTP port)
+type httpTransport struct {
+	logger    Logger
+	baseURL   string
+	client    *http.Client
+	ownClient bool              // client was created by the transport, so Close() closes its idle connections
+	mu        sync.Mutex        // guards circuits
+	circuits  map[string]string // circuit found for an element name
+}
+
+// NewHTTPTransport returns a transport using the HTTP JSON API of ebusd, e.g. "http://192.168.1.10:8889".
+// If client is nil, the transport creates a client of its own. Close() closes the idle connections of this
+// client only, a client passed in is left to the caller.
+func NewHTTPTransport(baseURL string, client *http.Client) Transport {
+	return newHTTPTransport(baseURL, client, nil)
+}
+
+func newHTTPTransport(baseURL string, client *http.Client, logger Logger) *httpTransport {
+	t := &httpTransport{}
+	if client == nil {
+		client = &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+		t.ownClient = true
+	}
+	t.logger = logger
+	t.baseURL = strings.TrimSuffix(baseURL, "/")
+	t.client = client
+	t.circuits = make(map[string]string)
+	return t
+}
+
+func (t *httpTransport) debug(fmt string, arg ...any) {
+	if t.logger != nil {
+		t.logger.Printf(fmt, arg...)
+	}
+}
+
+// get requests path with the query parameters and returns the body of the answer.
+// ERR: answers of ebusd are returned as error reply.
+func (t *httpTransport) get(ctx context.Context, path string, query url.Values) ([]byte, string, error) {
+	reqURL := t.baseURL + path
+	if len(query) > 0 {
+		reqURL = reqURL + "?" + encodeQuery(query)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	if isEbusdErrorReply(string(body)) {
+		return nil, strings.TrimSpace(string(body)), nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("ebusd http request %s returned status %s", reqURL, resp.Status)
+	}
+	return body, "", nil
+}
+
+// encodeQuery encodes the query parameters. Parameters with an empty value are sent as flags, e.g. "required".
+func encodeQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, key := range keys {
+		for _, value := range query[key] {
+			if value == "" {
+				parts = append(parts, url.QueryEscape(key))
+			} else {
+				parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(value))
+			}
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+type httpMessage struct {
+	Name   string          `json:"name"`
+	Lastup int64           `json:"lastup"`
+	Fields json.RawMessage `json:"fields"`
+}
+
+type httpCircuit struct {
+	Messages map[string]httpMessage `json:"messages"`
+}
+
+// decodeCircuits decodes the answer of ebusd for /data. The global section is skipped.
+func decodeCircuits(body []byte) (map[string]httpCircuit, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	circuits := make(map[string]httpCircuit)
+	for name, data := range raw {
+		if name == "global" {
+			continue
+		}
+		var circuit httpCircuit
+		if err := json.Unmarshal(data, &circuit); err != nil {
+			return nil, err
+		}
+		circuits[name] = circuit
+	}
+	return circuits, nil
+}
+
+// fieldValues returns the values of the fields in the order sent by ebusd, formatted like the TCP answers
+func fieldValues(fields json.RawMessage) ([]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(fields))
+	dec.UseNumber()
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	var values []string
+	for dec.More() {
+		// field name
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		var field struct {
+			Value any `json:"value"`
+		}
+		if err := dec.Decode(&field); err != nil {
+			return nil, err
+		}
+		switch v := field.Value.(type) {
+		case nil:
+			values = append(values, "-")
+		case string:
+			values = append(values, v)
+		default:
+			values = append(values, fmt.Sprint(v))
+		}
+	}
+	return values, nil
+}
+
+func (t *httpTransport) Read(ctx context.Context, circuit, name string, maxAge int) (string, error) {
+	var err error
+	if circuit == "" {
+		circuit, err = t.circuitFor(ctx, name)
+		if err != nil {
+			return "", err
+		}
+	}
+	query := url.Values{}
+	query.Set("required", "")
+	if maxAge >= 0 {
+		query.Set("maxage", fmt.Sprint(maxAge))
+	}
+	path := "/data/" + url.PathEscape(circuit) + "/" + url.PathEscape(name)
+	body, reply, err := t.get(ctx, path, query)
+	if err != nil {
+		return "", err
+	}
+	if reply != "" {
+		t.debug(fmt.Sprintf("Request: %s, ebusd answered: %s", path, reply))
+		return "", &EbusdError{Command: "GET " + path, Element: name, Circuit: circuit, Reply: reply}
+	}
+	circuits, err := decodeCircuits(body)
+	if err != nil {
+		return "", err
+	}
+	message, ok := circuits[circuit].Messages[name]
+	if !ok {
+		return "", &EbusdError{Command: "GET " + path, Element: name, Circuit: circuit, Reply: EBUSD_ERROR_ELEMENTNOTFOUND}
+	}
+	if message.Lastup == 0 || len(message.Fields) == 0 {
+		return "", &EbusdError{Command: "GET " + path, Element: name, Circuit: circuit, Reply: "ERR: no value available"}
+	}
+	values, err := fieldValues(message.Fields)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(values, ";"), nil
+}
+
+// ReadMany reads the elements with up to HTTP_READ_CONCURRENCY requests in parallel
+func (t *httpTransport) ReadMany(ctx context.Context, refs []ElementRef) ([]ReadResult, error) {
+	results := make([]ReadResult, len(refs))
+	sem := make(chan struct{}, HTTP_READ_CONCURRENCY)
+	var wg sync.WaitGroup
+	for i, ref := range refs {
+		results[i].Ref = ref
+		wg.Add(1)
+		go func(result *ReadResult) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			result.Value, result.Err = t.Read(ctx, result.Ref.Circuit, result.Ref.Name, result.Ref.MaxAge)
+		}(&results[i])
+	}
+	wg.Wait()
+	for _, result := range results {
+		if result.Err != nil && !isEbusdError(result.Err) {
+			return results, result.Err
+		}
+	}
+	return results, nil
+}
+
+func (t *httpTransport) Write(ctx context.Context, circuit, name, value string) error {
+	query := url.Values{}
+	query.Set("write", "")
+	query.Set("value", value)
+	path := "/data/" + url.PathEscape(circuit) + "/" + url.PathEscape(name)
+	_, reply, err := t.get(ctx, path, query)
+	if err != nil {
+		t.debug(fmt.Sprintf("Error writing to ebusd: %s", err))
+		return err
+	}
+	t.debug(fmt.Sprintf("Request sent to ebusd: %s value=%s", path, value))
+	if reply != "" {
+		t.debug(fmt.Sprintf("ebusd answered: %s", reply))
+		return &EbusdError{Command: "GET " + path + "?write", Element: name, Circuit: circuit, Reply: reply}
+	}
+	return nil
+}
+
+func (t *httpTransport) Find(ctx context.Context, name string) ([]string, error) {
+	body, reply, err := t.get(ctx, "/data", nil)
+	if err != nil {
+		return nil, err
+	}
+	if reply != "" {
+		return nil, &EbusdError{Command: "GET /data", Element: name, Reply: reply}
+	}
+	circuits, err := decodeCircuits(body)
+	if err != nil {
+		return nil, err
+	}
+	var found []string
+	for circuit, data := range circuits {
+		if _, ok := data.Messages[name]; ok {
+			found = append(found, circuit)
+		}
+	}
+	if len(found) == 0 {
+		return nil, &EbusdError{Command: "GET /data", Element: name, Reply: EBUSD_ERROR_ELEMENTNOTFOUND}
+	}
+	sort.Strings(found)
+	return found, nil
+}
+
+// circuitFor returns the (cached) circuit defining the element name
+func (t *httpTransport) circuitFor(ctx context.Context, name string) (string, error) {
+	t.mu.Lock()
+	circuit, ok := t.circuits[name]
+	t.mu.Unlock()
+	if ok {
+		return circuit, nil
+	}
+	found, err := t.Find(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	t.mu.Lock()
+	t.circuits[name] = found[0]
+	t.mu.Unlock()
+	return found[0], nil
+}
+
+// Scan is not supported by the HTTP JSON API of ebusd
+func (t *httpTransport) Scan(ctx context.Context) (string, error) {
+	return "", errors.ErrUnsupported
+}
+
+// Info renders the global section of the HTTP JSON API like the output of the ebusd command "info"
+func (t *httpTransport) Info(ctx context.Context) (string, error) {
+	body, reply, err := t.get(ctx, "/data", nil)
+	if err != nil {
+		return "", err
+	}
+	if reply != "" {
+		return "", &EbusdError{Command: "GET /data", Reply: reply}
+	}
+	var data struct {
+		Global map[string]any `json:"global"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return "", err
+	}
+	infoKeys := []struct{ key, line string }{
+		{"version", "version"},
+		{"signal", "signal"},
+		{"symbolrate", "symbol rate"},
+		{"maxsymbolrate", "max symbol rate"},
+		{"reconnects", "reconnects"},
+		{"masters", "masters"},
+		{"messages", "messages"},
+	}
+	var info strings.Builder
+	for _, k := range infoKeys {
+		value, ok := data.Global[k.key]
+		if !ok {
+			continue
+		}
+		if k.key == "signal" {
+			if signal, _ := value.(bool); signal {
+				value = "acquired"
+			} else {
+				value = "no signal"
+			}
+		}
+		fmt.Fprintf(&info, "%s: %v\n", k.line, value)
+	}
+	return info.String(), nil
+}
+
+// State renders the signal state of the global section of the HTTP JSON API like the output of the ebusd command "state"
+func (t *httpTransport) State(ctx context.Context) (string, error) {
+	body, reply, err := t.get(ctx, "/data", nil)
+	if err != nil {
+		return "", err
+	}
+	if reply != "" {
+		return "", &EbusdError{Command: "GET /data", Reply: reply}
+	}
+	var data struct {
+		Global struct {
+			Signal     bool `json:"signal"`
+			SymbolRate int  `json:"symbolrate"`
+			MaxSymRate int  `json:"maxsymbolrate"`
+			Masters    int  `json:"masters"`
+		} `json:"global"`
+	}
+	if err := json.Unmarshal(body, &data); err != nil {
+		return "", err
+	}
+	if !data.Global.Signal {
+		return "no signal", nil
+	}
+	return fmt.Sprintf("signal acquired, %d symbols/sec (%d max), %d masters", data.Global.SymbolRate, data.Global.MaxSymRate, data.Global.Masters), nil
+}
+
+// Close closes the idle connections of the client created by the transport. A client passed to NewHTTPTransport()
+// may be shared with other code (e.g. http.DefaultClient), so its connections are not touched.
+func (t *httpTransport) Close() error {
+	if t.ownClient {
+		t.client.CloseIdleConnections()
+	}
+	return nil
+}
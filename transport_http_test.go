@@ -0,0 +1,303 @@
+package sensonetEbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/exp/slices"
+)
+
+// idleCounter is a round tripper counting the calls of CloseIdleConnections()
+type idleCounter struct {
+	http.RoundTripper
+	closed int
+}
+
+func (c *idleCounter) CloseIdleConnections() {
+	c.closed++
+}
+
+func TestHTTPTransportCloseLeavesClientOfCaller(t *testing.T) {
+	counter := &idleCounter{RoundTripper: http.DefaultTransport}
+	transport := NewHTTPTransport("http://127.0.0.1:8889", &http.Client{Transport: counter})
+	if err := transport.Close(); err != nil {
+		t.Fatalf("Close() failed: %s", err)
+	}
+	if counter.closed != 0 {
+		t.Errorf("Close() closed the idle connections of the client of the caller %d times", counter.closed)
+	}
+}
+
+func TestHTTPTransportOwnClient(t *testing.T) {
+	transport := newHTTPTransport("http://127.0.0.1:8889", nil, nil)
+	if !transport.ownClient || transport.client == http.DefaultClient {
+		t.Fatalf("transport without client uses a shared client")
+	}
+	if transport.client.Transport == http.DefaultTransport {
+		t.Errorf("client of the transport uses http.DefaultTransport")
+	}
+	if err := transport.Close(); err != nil {
+		t.Errorf("Close() failed: %s", err)
+	}
+}
+
+// fakeHTTPEbusd serves the "/data" paths of the HTTP JSON API of ebusd for a few elements
+type fakeHTTPEbusd struct {
+	mu        sync.Mutex
+	values    map[string]map[string]string // circuit -> element -> value, "" for an element without a value yet
+	replies   map[string]string            // element -> ERR: reply
+	statuses  map[string]int               // element -> HTTP status answered
+	requests  []string                     // paths and queries requested
+	delay     time.Duration
+	active    int
+	maxActive int
+}
+
+func newFakeHTTPEbusd(t *testing.T) (*fakeHTTPEbusd, *httpTransport) {
+	t.Helper()
+	f := &fakeHTTPEbusd{
+		values: map[string]map[string]string{
+			"ctlv2": {"HwcTempDesired": "50.0", "HwcSFMode": "auto", "z1QuickVetoEndDate": "-", "z1Name2": ""},
+			"hmu":   {"Status01": "38.0;33.5;-;-;-;on", "State": "heating"},
+			"vwz00": {"State": "standby"},
+		},
+		replies:  map[string]string{},
+		statuses: map[string]int{},
+	}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	transport := newHTTPTransport(server.URL, nil, nil)
+	t.Cleanup(func() { transport.Close() })
+	return f, transport
+}
+
+func (f *fakeHTTPEbusd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r.URL.RequestURI())
+	f.active++
+	f.maxActive = max(f.maxActive, f.active)
+	delay := f.delay
+	f.mu.Unlock()
+	defer func() {
+		f.mu.Lock()
+		f.active--
+		f.mu.Unlock()
+	}()
+	time.Sleep(delay)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/data"), "/")
+	if len(parts) != 3 {
+		fmt.Fprint(w, f.data("", ""))
+		return
+	}
+	circuit, name := parts[1], parts[2]
+	if status, ok := f.statuses[name]; ok {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+	if reply, ok := f.replies[name]; ok {
+		fmt.Fprintln(w, reply)
+		return
+	}
+	if _, ok := f.values[circuit][name]; !ok {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintln(w, "ERR: element not found")
+		return
+	}
+	if r.URL.Query().Has("write") {
+		f.values[circuit][name] = r.URL.Query().Get("value")
+	}
+	fmt.Fprint(w, f.data(circuit, name))
+}
+
+// data renders the circuits like ebusd, limited to one element if name is set. The caller must hold f.mu.
+func (f *fakeHTTPEbusd) data(circuit, name string) string {
+	var sections []string
+	if name == "" {
+		sections = append(sections, `"global":{"version":"ebusd 23.2.p20230716","signal":true,"symbolrate":23,"maxsymbolrate":109,"reconnects":0,"masters":3,"messages":316}`)
+	}
+	for c, elements := range f.values {
+		if circuit != "" && c != circuit {
+			continue
+		}
+		var messages []string
+		for n, value := range elements {
+			if name != "" && n != name {
+				continue
+			}
+			if value == "" {
+				messages = append(messages, fmt.Sprintf(`%q:{"name":%q,"lastup":0,"fields":{}}`, n, n))
+				continue
+			}
+			var fields []string
+			for i, v := range strings.Split(value, ";") {
+				encoded := strconv.Quote(v)
+				if v == "-" {
+					encoded = "null"
+				} else if _, err := strconv.ParseFloat(v, 64); err == nil {
+					encoded = v
+				}
+				fields = append(fields, fmt.Sprintf(`"%d":{"value":%s}`, i, encoded))
+			}
+			messages = append(messages, fmt.Sprintf(`%q:{"name":%q,"lastup":1760782530,"fields":{%s}}`, n, n, strings.Join(fields, ",")))
+		}
+		sections = append(sections, fmt.Sprintf(`%q:{"messages":{%s}}`, c, strings.Join(messages, ",")))
+	}
+	return "{" + strings.Join(sections, ",") + "}"
+}
+
+func TestHTTPTransportRead(t *testing.T) {
+	tests := []struct {
+		name     string
+		circuit  string
+		element  string
+		maxAge   int
+		value    string
+		fails    bool
+		ebusdErr error // expected ERR: reply of ebusd, nil for other errors
+		request  string
+	}{
+		{"value", "ctlv2", "HwcTempDesired", -1, "50.0", false, nil, "/data/ctlv2/HwcTempDesired?required"},
+		{"max age", "ctlv2", "HwcSFMode", 30, "auto", false, nil, "/data/ctlv2/HwcSFMode?maxage=30&required"},
+		{"several fields", "hmu", "Status01", -1, "38.0;33.5;-;-;-;on", false, nil, "/data/hmu/Status01?required"},
+		{"null field", "ctlv2", "z1QuickVetoEndDate", -1, "-", false, nil, "/data/ctlv2/z1QuickVetoEndDate?required"},
+		{"no value yet", "ctlv2", "z1Name2", -1, "", true, ErrUnknownEbusdReply, "/data/ctlv2/z1Name2?required"},
+		{"element not found", "ctlv2", "z9OpMode", -1, "", true, ErrElementNotFound, "/data/ctlv2/z9OpMode?required"},
+		{"error reply", "ctlv2", "HwcStorageTemp", -1, "", true, ErrNoSignal, "/data/ctlv2/HwcStorageTemp?required"},
+		{"status not ok", "ctlv2", "HwcOpMode", -1, "", true, nil, "/data/ctlv2/HwcOpMode?required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, transport := newFakeHTTPEbusd(t)
+			f.replies["HwcStorageTemp"] = "ERR: no signal"
+			f.statuses["HwcOpMode"] = http.StatusServiceUnavailable
+			value, err := transport.Read(context.Background(), tt.circuit, tt.element, tt.maxAge)
+			switch {
+			case !tt.fails:
+				if err != nil || value != tt.value {
+					t.Errorf("Read() = %q, %v, expected %q", value, err, tt.value)
+				}
+			case tt.ebusdErr != nil:
+				if !isEbusdError(err) || !errors.Is(err, tt.ebusdErr) {
+					t.Errorf("Read() = %q, %v, expected EbusdError %v", value, err, tt.ebusdErr)
+				}
+			default:
+				if err == nil || isEbusdError(err) {
+					t.Errorf("Read() = %q, %v, expected a status error", value, err)
+				}
+			}
+			if !slices.Equal(f.requests, []string{tt.request}) {
+				t.Errorf("requests %q, expected %q", f.requests, tt.request)
+			}
+		})
+	}
+}
+
+func TestHTTPTransportReadFindsCircuit(t *testing.T) {
+	f, transport := newFakeHTTPEbusd(t)
+	for i := 0; i < 2; i++ {
+		value, err := transport.Read(context.Background(), "", "HwcSFMode", -1)
+		if err != nil || value != "auto" {
+			t.Fatalf("Read() = %q, %v, expected \"auto\"", value, err)
+		}
+	}
+	expected := []string{"/data", "/data/ctlv2/HwcSFMode?required", "/data/ctlv2/HwcSFMode?required"}
+	if !slices.Equal(f.requests, expected) {
+		t.Errorf("requests %q, expected %q (circuit looked up once)", f.requests, expected)
+	}
+}
+
+func TestHTTPTransportWrite(t *testing.T) {
+	f, transport := newFakeHTTPEbusd(t)
+	f.replies["HwcStorageTemp"] = "ERR: invalid argument"
+	f.statuses["HwcOpMode"] = http.StatusInternalServerError
+	ctx := context.Background()
+
+	if err := transport.Write(ctx, "ctlv2", "HwcSFMode", "load"); err != nil {
+		t.Fatalf("Write() failed: %s", err)
+	}
+	if value := f.values["ctlv2"]["HwcSFMode"]; value != "load" {
+		t.Errorf("HwcSFMode = %q after Write(), expected \"load\"", value)
+	}
+	if f.requests[0] != "/data/ctlv2/HwcSFMode?value=load&write" {
+		t.Errorf("request %q, expected \"/data/ctlv2/HwcSFMode?value=load&write\"", f.requests[0])
+	}
+	if err := transport.Write(ctx, "ctlv2", "z1Name1", "Wohnen"); !errors.Is(err, ErrElementNotFound) {
+		t.Errorf("Write() of an unknown element = %v, expected %v", err, ErrElementNotFound)
+	}
+	if err := transport.Write(ctx, "ctlv2", "HwcStorageTemp", "40"); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Write() with error reply = %v, expected %v", err, ErrInvalidArgument)
+	}
+	if err := transport.Write(ctx, "ctlv2", "HwcOpMode", "day"); err == nil || isEbusdError(err) {
+		t.Errorf("Write() with status %d = %v, expected a status error", http.StatusInternalServerError, err)
+	}
+}
+
+func TestHTTPTransportFind(t *testing.T) {
+	f, transport := newFakeHTTPEbusd(t)
+	ctx := context.Background()
+	circuits, err := transport.Find(ctx, "State")
+	if err != nil {
+		t.Fatalf("Find() failed: %s", err)
+	}
+	if !slices.Equal(circuits, []string{"hmu", "vwz00"}) {
+		t.Errorf("Find() = %q, expected [hmu vwz00]", circuits)
+	}
+	if _, err := transport.Find(ctx, "z9OpMode"); !errors.Is(err, ErrElementNotFound) {
+		t.Errorf("Find() of an unknown element = %v, expected %v", err, ErrElementNotFound)
+	}
+	if _, err := transport.Find(ctx, "global"); !errors.Is(err, ErrElementNotFound) {
+		t.Errorf("Find() of the global section = %v, expected %v", err, ErrElementNotFound)
+	}
+	if !slices.Equal(f.requests, []string{"/data", "/data", "/data"}) {
+		t.Errorf("requests %q, expected three requests of /data", f.requests)
+	}
+}
+
+func TestHTTPTransportReadMany(t *testing.T) {
+	f, transport := newFakeHTTPEbusd(t)
+	f.delay = 20 * time.Millisecond
+	var refs []ElementRef
+	for _, name := range []string{"HwcTempDesired", "HwcSFMode", "z1QuickVetoEndDate", "z9OpMode", "HwcTempDesired", "HwcSFMode",
+		"HwcTempDesired", "HwcSFMode", "HwcTempDesired", "HwcSFMode"} {
+		refs = append(refs, ElementRef{Circuit: "ctlv2", Name: name, MaxAge: -1})
+	}
+	results, err := transport.ReadMany(context.Background(), refs)
+	if err != nil {
+		t.Fatalf("ReadMany() failed: %s", err)
+	}
+	for i, result := range results {
+		if result.Ref != refs[i] {
+			t.Errorf("result %d for %+v, expected %+v", i, result.Ref, refs[i])
+		}
+	}
+	if results[2].Value != "-" || results[2].Err != nil {
+		t.Errorf("result for z1QuickVetoEndDate = %q, %v", results[2].Value, results[2].Err)
+	}
+	if !errors.Is(results[3].Err, ErrElementNotFound) {
+		t.Errorf("result for z9OpMode = %q, %v, expected %v", results[3].Value, results[3].Err, ErrElementNotFound)
+	}
+	if f.maxActive < 2 || f.maxActive > HTTP_READ_CONCURRENCY {
+		t.Errorf("%d requests in parallel, expected 2 to %d", f.maxActive, HTTP_READ_CONCURRENCY)
+	}
+
+	// errors other than ERR: replies of ebusd abort ReadMany
+	f.statuses["HwcSFMode"] = http.StatusServiceUnavailable
+	results, err = transport.ReadMany(context.Background(), refs)
+	if err == nil || isEbusdError(err) {
+		t.Fatalf("ReadMany() with status %d = %v, expected a status error", http.StatusServiceUnavailable, err)
+	}
+	if results[0].Value != "50.0" || results[1].Err == nil {
+		t.Errorf("ReadMany() results %+v, expected the values of the elements read", results[:2])
+	}
+}
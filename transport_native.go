@@ -0,0 +1,346 @@
+package sensonetEbus
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/WulfgarW/sensonetEbus/ebus"
+	"github.com/WulfgarW/sensonetEbus/ebusdcsv"
+)
+
+// errNativeReply is returned, if the reply to a message sent natively cannot be decoded
+var errNativeReply = errors.New("invalid reply")
+
+// nativeMessage is a message of the bundled configuration files that can be sent with the command "hex" of ebusd
+type nativeMessage struct {
+	circuit string
+	zz      byte
+	pbsb    uint16
+	id      []byte
+	fields  []ebusdcsv.Field // resolved to base types. For reads the fields of the response, for writes the ones of the request.
+}
+
+// nativeMessages are the messages of the bundled configuration files supported by the package ebus, by messageKey()
+type nativeMessages map[string]nativeMessage
+
+// bundledNativeMessages returns the messages of the bundled configuration files. Messages with data types
+// not supported by the package ebus are skipped and returned as error together with the other messages.
+func bundledNativeMessages() (nativeMessages, error) {
+	files, err := bundledFiles()
+	if err != nil {
+		return nil, err
+	}
+	messages := make(nativeMessages)
+	templates := ebusdcsv.DefaultTemplates()
+	var skipped []error
+	for _, file := range files {
+		for _, message := range file.Messages {
+			native, err := newNativeMessage(templates, message)
+			if err != nil {
+				skipped = append(skipped, fmt.Errorf("%s line %d: %s %s: %w", file.Name, message.Line, message.Type, message.Name, err))
+				continue
+			}
+			messages[messageKey(message.Type, message.Name)] = native
+		}
+	}
+	return messages, errors.Join(skipped...)
+}
+
+// lookup returns the message of type messageType named name. If circuit is not empty, it has to match as well.
+func (m nativeMessages) lookup(messageType, circuit, name string) (nativeMessage, bool) {
+	message, ok := m[messageKey(messageType, name)]
+	if !ok || (circuit != "" && circuit != message.circuit) {
+		return nativeMessage{}, false
+	}
+	return message, true
+}
+
+// nativeTransport answers reads and writes of elements unknown to ebusd ("ERR: element not found") by sending
+// the messages defined in the bundled configuration files with the command "hex" of ebusd and decoding the
+// replies itself. So the elements work with a stock configuration of ebusd, too, if hex is enabled in ebusd.
+type nativeTransport struct {
+	inner    Transport
+	logger   Logger
+	messages nativeMessages
+
+	mu       sync.Mutex
+	disabled bool // ebusd answered "ERR: command not enabled"
+}
+
+func newNativeTransport(inner Transport, logger Logger) *nativeTransport {
+	t := &nativeTransport{inner: inner, logger: logger}
+	messages, err := bundledNativeMessages()
+	if err != nil {
+		t.debug(fmt.Sprintf("Messages of the bundled configuration files not available natively:\n%s", err))
+	}
+	t.messages = messages
+	return t
+}
+
+// newNativeMessage returns the message for the hex command, if all its fields are supported by the package ebus
+func newNativeMessage(templates ebusdcsv.Templates, message ebusdcsv.Message) (nativeMessage, error) {
+	zz, err := strconv.ParseUint(message.ZZ, 16, 8)
+	if err != nil {
+		return nativeMessage{}, fmt.Errorf("invalid ZZ %q", message.ZZ)
+	}
+	pbsb, err := strconv.ParseUint(message.PBSB, 16, 16)
+	if err != nil {
+		return nativeMessage{}, fmt.Errorf("invalid PBSB %q", message.PBSB)
+	}
+	id, err := hex.DecodeString(message.ID)
+	if err != nil {
+		return nativeMessage{}, fmt.Errorf("invalid ID %q", message.ID)
+	}
+	// reads carry the value in the response of the slave, writes in the request of the master
+	part := ebusdcsv.PART_MASTER
+	if message.Type == ebusdcsv.TYPE_READ {
+		part = ebusdcsv.PART_SLAVE
+	}
+	for _, field := range message.Fields {
+		if field.Part != "" && field.Part != part {
+			return nativeMessage{}, fmt.Errorf("fields in both parts of %s", message.Name)
+		}
+	}
+	fields, err := resolveFields(templates, message.Fields)
+	if err != nil {
+		return nativeMessage{}, err
+	}
+	return nativeMessage{circuit: message.Circuit, zz: byte(zz), pbsb: uint16(pbsb), id: id, fields: fields}, nil
+}
+
+// request returns the data of the write request setting value (ID and encoded value)
+func (m nativeMessage) request(value string) ([]byte, error) {
+	data, err := ebus.EncodeFields(m.fields, value)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte(nil), m.id...), data...), nil
+}
+
+// resolveFields resolves the templates of fields to base types supported by the package ebus
+func resolveFields(templates ebusdcsv.Templates, fields []ebusdcsv.Field) ([]ebusdcsv.Field, error) {
+	var resolved []ebusdcsv.Field
+	for _, field := range fields {
+		baseFields, err := templates.Resolve(field)
+		if err != nil {
+			return nil, err
+		}
+		for _, baseField := range baseFields {
+			if _, err := ebus.ParseDataType(baseField.Types[0]); err != nil {
+				return nil, err
+			}
+		}
+		resolved = append(resolved, baseFields...)
+	}
+	return resolved, nil
+}
+
+func messageKey(messageType, name string) string {
+	return messageType + "|" + strings.ToLower(name)
+}
+
+func (t *nativeTransport) debug(fmt string, arg ...any) {
+	if t.logger != nil {
+		t.logger.Printf(fmt, arg...)
+	}
+}
+
+// fallback returns the native message for an element that ebusd answered with err, if there is one
+func (t *nativeTransport) fallback(messageType, circuit, name string, err error) (nativeMessage, bool) {
+	if !errors.Is(err, ErrElementNotFound) {
+		return nativeMessage{}, false
+	}
+	if _, ok := t.inner.(HexSender); !ok {
+		return nativeMessage{}, false
+	}
+	t.mu.Lock()
+	disabled := t.disabled
+	t.mu.Unlock()
+	if disabled {
+		return nativeMessage{}, false
+	}
+	return t.messages.lookup(messageType, circuit, name)
+}
+
+// hex sends data to the slave of message and returns the data of the response
+func (t *nativeTransport) hex(ctx context.Context, message nativeMessage, data []byte) ([]byte, error) {
+	reply, err := t.inner.(HexSender).Hex(ctx, hexTelegram(message.zz, message.pbsb, data))
+	if errors.Is(err, ErrNotEnabled) {
+		t.debug("The command hex is not enabled in ebusd (option --enablehex). Elements missing in the configuration of ebusd cannot be read natively.")
+		t.mu.Lock()
+		t.disabled = true
+		t.mu.Unlock()
+	}
+	if err != nil {
+		return nil, err
+	}
+	response, err := parseHexReply(reply)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errNativeReply, err)
+	}
+	return response, nil
+}
+
+// valueError returns the error for a value that cannot be encoded, as ebusd reports it
+func valueError(err error, command, circuit, name string) error {
+	reply := "ERR: invalid value"
+	if errors.Is(err, ebus.ErrOutOfRange) {
+		reply = "ERR: argument value out of valid range"
+	}
+	return &EbusdError{Command: command, Element: name, Circuit: circuit, Reply: reply}
+}
+
+// isSessionError returns true, if err is neither an ERR: reply of ebusd nor an invalid reply
+func isSessionError(err error) bool {
+	return err != nil && !isEbusdError(err) && !errors.Is(err, errNativeReply)
+}
+
+func (t *nativeTransport) readNative(ctx context.Context, message nativeMessage, name string) (string, error) {
+	data, err := t.hex(ctx, message, message.id)
+	if err != nil {
+		return "", err
+	}
+	value, err := ebus.DecodeFields(message.fields, data)
+	if err != nil {
+		return "", fmt.Errorf("%w for %s: %s", errNativeReply, name, err)
+	}
+	t.debug(fmt.Sprintf("Read %s natively: %s", name, value))
+	return value, nil
+}
+
+// Read reads the element natively, if ebusd does not know it. If that fails, the error of ebusd is returned,
+// unless the session failed.
+func (t *nativeTransport) Read(ctx context.Context, circuit, name string, maxAge int) (string, error) {
+	value, err := t.inner.Read(ctx, circuit, name, maxAge)
+	message, ok := t.fallback(ebusdcsv.TYPE_READ, circuit, name, err)
+	if !ok {
+		return value, err
+	}
+	nativeValue, nativeErr := t.readNative(ctx, message, name)
+	if nativeErr != nil {
+		t.debug(fmt.Sprintf("Reading %s natively failed: %s", name, nativeErr))
+		if isSessionError(nativeErr) {
+			return "", nativeErr
+		}
+		return value, err
+	}
+	return nativeValue, nil
+}
+
+// ReadMany reads the elements unknown to ebusd natively one after another
+func (t *nativeTransport) ReadMany(ctx context.Context, refs []ElementRef) ([]ReadResult, error) {
+	results, err := ReadMany(ctx, t.inner, refs)
+	if err != nil {
+		return results, err
+	}
+	for i, result := range results {
+		message, ok := t.fallback(ebusdcsv.TYPE_READ, result.Ref.Circuit, result.Ref.Name, result.Err)
+		if !ok {
+			continue
+		}
+		value, nativeErr := t.readNative(ctx, message, result.Ref.Name)
+		if nativeErr != nil {
+			t.debug(fmt.Sprintf("Reading %s natively failed: %s", result.Ref.Name, nativeErr))
+			if isSessionError(nativeErr) {
+				results[i].Err = nativeErr
+			}
+			continue
+		}
+		results[i].Value, results[i].Err = value, nil
+	}
+	return results, nil
+}
+
+// Write writes the element natively, if ebusd does not know it
+func (t *nativeTransport) Write(ctx context.Context, circuit, name, value string) error {
+	err := t.inner.Write(ctx, circuit, name, value)
+	message, ok := t.fallback(ebusdcsv.TYPE_WRITE, circuit, name, err)
+	if !ok {
+		return err
+	}
+	data, encodeErr := message.request(value)
+	if encodeErr != nil {
+		t.debug(fmt.Sprintf("Writing %s natively failed: %s", name, encodeErr))
+		return valueError(encodeErr, "write -c "+circuit+" "+name+" "+value, circuit, name)
+	}
+	_, nativeErr := t.hex(ctx, message, data)
+	if nativeErr != nil {
+		t.debug(fmt.Sprintf("Writing %s natively failed: %s", name, nativeErr))
+		if isSessionError(nativeErr) {
+			return nativeErr
+		}
+		return err
+	}
+	t.debug(fmt.Sprintf("Wrote %s natively: %s", name, value))
+	return nil
+}
+
+func (t *nativeTransport) Hex(ctx context.Context, telegram string) (string, error) {
+	sender, ok := t.inner.(HexSender)
+	if !ok {
+		return "", errors.ErrUnsupported
+	}
+	return sender.Hex(ctx, telegram)
+}
+
+func (t *nativeTransport) Listen(ctx context.Context, fn func(circuit, name, value string)) error {
+	listener, ok := t.inner.(Listener)
+	if !ok {
+		return errors.ErrUnsupported
+	}
+	return listener.Listen(ctx, fn)
+}
+
+func (t *nativeTransport) FindDefinitions(ctx context.Context, circuit string) ([]string, error) {
+	finder, ok := t.inner.(DefinitionFinder)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+	return finder.FindDefinitions(ctx, circuit)
+}
+
+// Find returns the circuit of the bundled configuration files for an element unknown to ebusd, if it can be
+// read natively. So the controller is found with a stock configuration of ebusd, too.
+func (t *nativeTransport) Find(ctx context.Context, name string) ([]string, error) {
+	circuits, err := t.inner.Find(ctx, name)
+	notFound := err
+	if err == nil && len(circuits) == 0 {
+		// an empty result means that ebusd does not know the element either
+		notFound = ErrElementNotFound
+	}
+	message, ok := t.fallback(ebusdcsv.TYPE_READ, "", name, notFound)
+	if !ok {
+		return circuits, err
+	}
+	_, nativeErr := t.readNative(ctx, message, name)
+	if nativeErr != nil {
+		t.debug(fmt.Sprintf("Reading %s natively failed: %s", name, nativeErr))
+		if isSessionError(nativeErr) {
+			return nil, nativeErr
+		}
+		return circuits, err
+	}
+	t.debug(fmt.Sprintf("%s unknown to ebusd. Using circuit %s of the bundled configuration files", name, message.circuit))
+	return []string{message.circuit}, nil
+}
+
+func (t *nativeTransport) Scan(ctx context.Context) (string, error) {
+	return t.inner.Scan(ctx)
+}
+
+func (t *nativeTransport) Info(ctx context.Context) (string, error) {
+	return t.inner.Info(ctx)
+}
+
+func (t *nativeTransport) State(ctx context.Context) (string, error) {
+	return t.inner.State(ctx)
+}
+
+func (t *nativeTransport) Close() error {
+	return t.inner.Close()
+}
@@ -0,0 +1,74 @@
+package sensonetEbus
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/WulfgarW/sensonetEbus/ebusdcsv"
+	"github.com/WulfgarW/sensonetEbus/ebusdtest"
+)
+
+func TestBundledNativeMessages(t *testing.T) {
+	messages, err := bundledNativeMessages()
+	if err != nil {
+		t.Errorf("bundledNativeMessages() skipped messages:\n%s", err)
+	}
+	for _, name := range []string{EBUSDREAD_HOTWATER_SFMODE, "z1" + EBUSDREAD_ZONE_QUICKVETODURATION, "Hc1" + EBUSDREAD_HC_CIRCUITTYPE} {
+		if _, ok := messages.lookup(ebusdcsv.TYPE_READ, ebusdtest.DEFAULT_CONTROLLER_NAME, name); !ok {
+			t.Errorf("r %s missing", name)
+		}
+	}
+}
+
+// TestNativeFind connects to an ebusd with a stock configuration lacking HwcSFMode
+func TestNativeFind(t *testing.T) {
+	messages, err := bundledNativeMessages()
+	if err != nil {
+		t.Fatalf("bundledNativeMessages() failed: %s", err)
+	}
+	read, _ := messages.lookup(ebusdcsv.TYPE_READ, "", EBUSDREAD_HOTWATER_SFMODE)
+	write, _ := messages.lookup(ebusdcsv.TYPE_WRITE, "", EBUSDREAD_HOTWATER_SFMODE)
+	request, err := write.request(HWC_SFMODE_BOOST)
+	if err != nil {
+		t.Fatalf("request() failed: %s", err)
+	}
+	readTelegram, writeTelegram := hexTelegram(read.zz, read.pbsb, read.id), hexTelegram(write.zz, write.pbsb, request)
+
+	tests := []struct {
+		name       string
+		hexEnabled bool
+		wantErr    bool
+	}{
+		{"hex enabled", true, false},
+		{"hex not enabled", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := ebusdtest.NewServer()
+			if err != nil {
+				t.Fatalf("NewServer() failed: %s", err)
+			}
+			defer server.Close()
+			server.PopulateVaillantSystem(1)
+			server.Delete(ebusdtest.DEFAULT_CONTROLLER_NAME, EBUSDREAD_HOTWATER_SFMODE)
+			server.SetHexEnabled(tt.hexEnabled)
+			server.SetHexReply(readTelegram, "050000000000") // IGN:4 and sfmode auto
+			server.SetHexReply(writeTelegram, "00")
+
+			conn, err := NewConnection(server.Addr(), WithRetryPolicy(testRetryPolicy()))
+			defer conn.Close()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewConnection() = %v, expected error: %t", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if err := conn.StartHotWaterBoost(); err != nil {
+				t.Fatalf("StartHotWaterBoost() failed: %s", err)
+			}
+			if !slices.Contains(server.Commands(), "hex "+writeTelegram) {
+				t.Errorf("HwcSFMode not written natively. Commands: %v", server.Commands())
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package sensonetEbus
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	RECORD_OP_READ  = "read"
+	RECORD_OP_WRITE = "write"
+	RECORD_OP_FIND  = "find"
+	RECORD_OP_SCAN  = "scan"
+	RECORD_OP_INFO  = "info"
+	RECORD_OP_STATE = "state"
+	RECORD_OP_DEFS  = "definitions"
+	RECORD_OP_HEX   = "hex"
+)
+
+// Record is one command sent to ebusd and its reply, as written by the recorder (one JSON object per line)
+type Record struct {
+	Time       time.Time `json:"time"`
+	Op         string    `json:"op"`
+	Circuit    string    `json:"circuit,omitempty"`
+	Name       string    `json:"name,omitempty"`
+	MaxAge     int       `json:"maxage"`
+	Value      string    `json:"value,omitempty"`      // value written by a write command, telegram sent by hex
+	Reply      string    `json:"reply,omitempty"`      // value read, answer of scan or info
+	Circuits   []string  `json:"circuits,omitempty"`   // circuits returned by find, definitions returned by find -F
+	EbusdReply string    `json:"ebusdreply,omitempty"` // ERR: reply of ebusd
+	Err        string    `json:"err,omitempty"`        // other errors, e.g. network errors
+	LatencyMs  float64   `json:"latencyms"`
+}
+
+// recordingTransport passes all commands to the inner transport and writes them and their replies to w
+type recordingTransport struct {
+	inner Transport
+	mu    sync.Mutex // serializes the writes to enc
+	enc   *json.Encoder
+}
+
+// NewRecordingTransport returns a transport that passes all commands to inner and writes every command
+// and its reply with timestamp and latency as JSON lines to w. The output can be fed to NewReplayTransport().
+func NewRecordingTransport(inner Transport, w io.Writer) Transport {
+	return &recordingTransport{inner: inner, enc: json.NewEncoder(w)}
+}
+
+func (t *recordingTransport) record(rec Record, started time.Time, err error) {
+	rec.Time = started
+	rec.LatencyMs = float64(time.Since(started).Microseconds()) / 1000.0
+	var ebusdErr *EbusdError
+	if errors.As(err, &ebusdErr) {
+		rec.EbusdReply = ebusdErr.Reply
+	} else if err != nil {
+		rec.Err = err.Error()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_ = t.enc.Encode(rec)
+}
+
+func (t *recordingTransport) Read(ctx context.Context, circuit, name string, maxAge int) (string, error) {
+	started := time.Now()
+	value, err := t.inner.Read(ctx, circuit, name, maxAge)
+	t.record(Record{Op: RECORD_OP_READ, Circuit: circuit, Name: name, MaxAge: maxAge, Reply: value}, started, err)
+	return value, err
+}
+
+// ReadMany passes the batch to the inner transport, so that it keeps its pipelining. The latency
+// recorded for each element is the one of the whole batch.
+func (t *recordingTransport) ReadMany(ctx context.Context, refs []ElementRef) ([]ReadResult, error) {
+	started := time.Now()
+	results, err := ReadMany(ctx, t.inner, refs)
+	for _, result := range results {
+		t.record(Record{Op: RECORD_OP_READ, Circuit: result.Ref.Circuit, Name: result.Ref.Name, MaxAge: result.Ref.MaxAge, Reply: result.Value}, started, result.Err)
+	}
+	return results, err
+}
+
+// Listen passes the listen session to the inner transport. The updates are not recorded.
+func (t *recordingTransport) Listen(ctx context.Context, fn func(circuit, name, value string)) error {
+	listener, ok := t.inner.(Listener)
+	if !ok {
+		return errors.ErrUnsupported
+	}
+	return listener.Listen(ctx, fn)
+}
+
+func (t *recordingTransport) FindDefinitions(ctx context.Context, circuit string) ([]string, error) {
+	finder, ok := t.inner.(DefinitionFinder)
+	if !ok {
+		return nil, errors.ErrUnsupported
+	}
+	started := time.Now()
+	definitions, err := finder.FindDefinitions(ctx, circuit)
+	t.record(Record{Op: RECORD_OP_DEFS, Circuit: circuit, Circuits: definitions}, started, err)
+	return definitions, err
+}
+
+func (t *recordingTransport) Hex(ctx context.Context, telegram string) (string, error) {
+	sender, ok := t.inner.(HexSender)
+	if !ok {
+		return "", errors.ErrUnsupported
+	}
+	started := time.Now()
+	reply, err := sender.Hex(ctx, telegram)
+	t.record(Record{Op: RECORD_OP_HEX, Value: telegram, Reply: reply}, started, err)
+	return reply, err
+}
+
+func (t *recordingTransport) Write(ctx context.Context, circuit, name, value string) error {
+	started := time.Now()
+	err := t.inner.Write(ctx, circuit, name, value)
+	t.record(Record{Op: RECORD_OP_WRITE, Circuit: circuit, Name: name, Value: value}, started, err)
+	return err
+}
+
+func (t *recordingTransport) Find(ctx context.Context, name string) ([]string, error) {
+	started := time.Now()
+	circuits, err := t.inner.Find(ctx, name)
+	t.record(Record{Op: RECORD_OP_FIND, Name: name, Circuits: circuits}, started, err)
+	return circuits, err
+}
+
+func (t *recordingTransport) Scan(ctx context.Context) (string, error) {
+	started := time.Now()
+	result, err := t.inner.Scan(ctx)
+	t.record(Record{Op: RECORD_OP_SCAN, Reply: result}, started, err)
+	return result, err
+}
+
+func (t *recordingTransport) Info(ctx context.Context) (string, error) {
+	started := time.Now()
+	result, err := t.inner.Info(ctx)
+	t.record(Record{Op: RECORD_OP_INFO, Reply: result}, started, err)
+	return result, err
+}
+
+func (t *recordingTransport) State(ctx context.Context) (string, error) {
+	started := time.Now()
+	result, err := t.inner.State(ctx)
+	t.record(Record{Op: RECORD_OP_STATE, Reply: result}, started, err)
+	return result, err
+}
+
+func (t *recordingTransport) Close() error {
+	return t.inner.Close()
+}
+
+// replayTransport answers all commands from a recording made by the recording transport
+type replayTransport struct {
+	mu      sync.Mutex
+	records map[string][]Record // recorded replies per command, in the order of the recording
+}
+
+// NewReplayTransport returns a transport that answers all commands with the replies recorded in r
+// (JSON lines written by NewRecordingTransport). Repeated commands get the recorded replies in order.
+// When they are used up, the last one is repeated. Commands not found in the recording are
+// answered with "ERR: element not found".
+func NewReplayTransport(r io.Reader) (Transport, error) {
+	t := &replayTransport{records: make(map[string][]Record)}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("invalid record in line %d: %w", line, err)
+		}
+		key := replayKey(rec.Op, rec.Circuit, rec.Name, rec.MaxAge, rec.Value)
+		t.records[key] = append(t.records[key], rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func replayKey(op, circuit, name string, maxAge int, value string) string {
+	return fmt.Sprintf("%s|%s|%s|%d|%s", op, circuit, name, maxAge, value)
+}
+
+// next returns the next recorded reply for the command
+func (t *replayTransport) next(op, circuit, name string, maxAge int, value string) (Record, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	key := replayKey(op, circuit, name, maxAge, value)
+	recs := t.records[key]
+	if len(recs) == 0 {
+		return Record{}, &EbusdError{Command: op + " " + name, Element: name, Circuit: circuit, Reply: EBUSD_ERROR_ELEMENTNOTFOUND}
+	}
+	rec := recs[0]
+	if len(recs) > 1 {
+		t.records[key] = recs[1:]
+	}
+	if rec.EbusdReply != "" {
+		return rec, &EbusdError{Command: op + " " + name, Element: name, Circuit: circuit, Reply: rec.EbusdReply}
+	}
+	if rec.Err != "" {
+		return rec, errors.New(rec.Err)
+	}
+	return rec, nil
+}
+
+func (t *replayTransport) Read(ctx context.Context, circuit, name string, maxAge int) (string, error) {
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	rec, err := t.next(RECORD_OP_READ, circuit, name, maxAge, "")
+	return rec.Reply, err
+}
+
+func (t *replayTransport) Write(ctx context.Context, circuit, name, value string) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	_, err := t.next(RECORD_OP_WRITE, circuit, name, 0, value)
+	return err
+}
+
+func (t *replayTransport) Find(ctx context.Context, name string) ([]string, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	rec, err := t.next(RECORD_OP_FIND, "", name, 0, "")
+	return rec.Circuits, err
+}
+
+func (t *replayTransport) FindDefinitions(ctx context.Context, circuit string) ([]string, error) {
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	rec, err := t.next(RECORD_OP_DEFS, circuit, "", 0, "")
+	return rec.Circuits, err
+}
+
+func (t *replayTransport) Hex(ctx context.Context, telegram string) (string, error) {
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	rec, err := t.next(RECORD_OP_HEX, "", "", 0, telegram)
+	return rec.Reply, err
+}
+
+func (t *replayTransport) Scan(ctx context.Context) (string, error) {
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	rec, err := t.next(RECORD_OP_SCAN, "", "", 0, "")
+	return rec.Reply, err
+}
+
+func (t *replayTransport) Info(ctx context.Context) (string, error) {
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	rec, err := t.next(RECORD_OP_INFO, "", "", 0, "")
+	return rec.Reply, err
+}
+
+func (t *replayTransport) State(ctx context.Context) (string, error) {
+	if ctx.Err() != nil {
+		return "", ctx.Err()
+	}
+	rec, err := t.next(RECORD_OP_STATE, "", "", 0, "")
+	return rec.Reply, err
+}
+
+func (t *replayTransport) Close() error {
+	return nil
+}
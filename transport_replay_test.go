@@ -0,0 +1,71 @@
+package sensonetEbus
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	var recording bytes.Buffer
+	server, conn := newTestSystem(t, WithRecorder(&recording))
+	recorded, err := conn.GetSystem(true)
+	if err != nil {
+		t.Fatalf("GetSystem() failed: %s", err)
+	}
+	conn.Close()
+	server.Close()
+	if !strings.Contains(recording.String(), `"op":"read","name":"HwcTempDesired"`) {
+		t.Fatalf("HwcTempDesired not recorded: %s", recording.String())
+	}
+
+	transport, err := NewReplayTransport(bytes.NewReader(recording.Bytes()))
+	if err != nil {
+		t.Fatalf("NewReplayTransport() failed: %s", err)
+	}
+	replayConn, err := NewConnection("", WithTransport(transport))
+	if err != nil {
+		t.Fatalf("NewConnection() with replay failed: %s", err)
+	}
+	defer replayConn.Close()
+	replayed, err := replayConn.GetSystem(true)
+	if err != nil {
+		t.Fatalf("GetSystem() with replay failed: %s", err)
+	}
+	if len(replayed.Zones) != 2 || replayed.Hotwater.HwcTempDesired != 50.0 {
+		t.Errorf("replayed system data incomplete: %+v", replayed)
+	}
+	recorded.LastGetSystem, replayed.LastGetSystem = time.Time{}, time.Time{}
+	if !reflect.DeepEqual(replayed, recorded) {
+		t.Errorf("replayed system data\n%+v\ndiffer from the recorded ones\n%+v", replayed, recorded)
+	}
+}
+
+func TestReplayWithoutRecording(t *testing.T) {
+	transport, err := NewReplayTransport(strings.NewReader(""))
+	if err != nil {
+		t.Fatalf("NewReplayTransport() failed: %s", err)
+	}
+	ctx := context.Background()
+	if value, err := transport.Read(ctx, "", EBUSDREAD_HOTWATER_TEMPDESIRED, 0); !errors.Is(err, ErrElementNotFound) {
+		t.Errorf("Read() = %q, %v, expected %v", value, err, ErrElementNotFound)
+	}
+	if err := transport.Write(ctx, "ctlv2", EBUSDREAD_HOTWATER_SFMODE, HWC_SFMODE_BOOST); !errors.Is(err, ErrElementNotFound) {
+		t.Errorf("Write() = %v, expected %v", err, ErrElementNotFound)
+	}
+	if circuits, err := transport.Find(ctx, EBUSDREAD_HOTWATER_SFMODE); !errors.Is(err, ErrElementNotFound) {
+		t.Errorf("Find() = %v, %v, expected %v", circuits, err, ErrElementNotFound)
+	}
+	// the connection cannot find the controller without recording
+	if _, err := NewConnection("", WithTransport(transport)); err == nil || !strings.Contains(err.Error(), EBUSD_ERROR_ELEMENTNOTFOUND) {
+		t.Errorf("NewConnection() = %v, expected %q", err, EBUSD_ERROR_ELEMENTNOTFOUND)
+	}
+
+	if _, err := NewReplayTransport(strings.NewReader("{\"op\":\"read\"\n")); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("NewReplayTransport() of an invalid recording = %v, expected an error for line 1", err)
+	}
+}
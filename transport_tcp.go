@@ -0,0 +1,478 @@
+package sensonetEbus
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	EBUSD_KEEPALIVE_INTERVAL = 30
+	EBUSD_PIPELINE_DEPTH     = 16 // maximum number of commands sent by ReadMany() before reading the answers
+	EBUSD_DEFINITION_COLUMNS = "type,circuit,name,zz,pbsb,id,fields"
+)
+
+// tcpTransport talks the line protocol of ebusd over one long-lived TCP session
+type tcpTransport struct {
+	mu                ctxMutex // serializes all commands sent over the ebusd session
+	logger            Logger
+	ebusdAddress      string
+	ebusdConn         net.Conn
+	ebusdReadBuffer   *bufio.Reader
+	keepAliveInterval time.Duration
+	closed            bool
+}
+
+// NewTCPTransport returns a transport using the command port of ebusd, e.g. "192.168.1.10:8888"
+func NewTCPTransport(ebusdAddress string) Transport {
+	return newTCPTransport(ebusdAddress, nil)
+}
+
+func newTCPTransport(ebusdAddress string, logger Logger) *tcpTransport {
+	t := &tcpTransport{}
+	t.mu = newCtxMutex()
+	t.logger = logger
+	t.ebusdAddress = ebusdAddress
+	t.keepAliveInterval = EBUSD_KEEPALIVE_INTERVAL * time.Second
+	return t
+}
+
+func (t *tcpTransport) debug(fmt string, arg ...any) {
+	if t.logger != nil {
+		t.logger.Printf(fmt, arg...)
+	}
+}
+
+// readCommand returns the ebusd command reading the element name
+func readCommand(circuit, name string, maxAge int) string {
+	ebusCommand := "read "
+	if maxAge >= 0 {
+		ebusCommand = fmt.Sprintf("read -m %0d ", maxAge)
+	}
+	if circuit != "" {
+		ebusCommand = ebusCommand + "-c " + circuit + " "
+	}
+	return ebusCommand + name
+}
+
+func (t *tcpTransport) Read(ctx context.Context, circuit, name string, maxAge int) (string, error) {
+	ebusCommand := readCommand(circuit, name, maxAge)
+	err := t.mu.Lock(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer t.mu.Unlock()
+	lines, err := t.exchange(ctx, ebusCommand)
+	if err != nil {
+		return "", err
+	}
+	return t.readResult(ebusCommand, ElementRef{Circuit: circuit, Name: name, MaxAge: maxAge}, lines)
+}
+
+// ReadMany pipelines the read commands over the session: up to EBUSD_PIPELINE_DEPTH commands are sent
+// at once before the answers are read, as ebusd answers the commands of a session in order.
+func (t *tcpTransport) ReadMany(ctx context.Context, refs []ElementRef) ([]ReadResult, error) {
+	results := make([]ReadResult, len(refs))
+	for i, ref := range refs {
+		results[i].Ref = ref
+	}
+	setSessionErr := func(from int, err error) {
+		for i := from; i < len(results); i++ {
+			results[i].Err = err
+		}
+	}
+	err := t.mu.Lock(ctx)
+	if err != nil {
+		setSessionErr(0, err)
+		return results, err
+	}
+	defer t.mu.Unlock()
+	for start := 0; start < len(refs); start += EBUSD_PIPELINE_DEPTH {
+		end := min(start+EBUSD_PIPELINE_DEPTH, len(refs))
+		ebusCommands := make([]string, 0, end-start)
+		for _, ref := range refs[start:end] {
+			ebusCommands = append(ebusCommands, readCommand(ref.Circuit, ref.Name, ref.MaxAge))
+		}
+		answers, err := t.exchangeMany(ctx, ebusCommands)
+		if err != nil {
+			setSessionErr(start, err)
+			return results, err
+		}
+		for j, lines := range answers {
+			results[start+j].Value, results[start+j].Err = t.readResult(ebusCommands[j], refs[start+j], lines)
+		}
+	}
+	return results, nil
+}
+
+// readResult returns the value or the ERR: reply in the answer to a read command
+func (t *tcpTransport) readResult(ebusCommand string, ref ElementRef, lines []string) (string, error) {
+	message := lastLine(lines)
+	if isEbusdErrorReply(message) {
+		t.debug(fmt.Sprintf("Command: %s, ebusd answered: %s", ebusCommand, message))
+		return "", &EbusdError{Command: ebusCommand, Element: ref.Name, Circuit: ref.Circuit, Reply: message}
+	}
+	return message, nil
+}
+
+func (t *tcpTransport) Write(ctx context.Context, circuit, name, value string) error {
+	ebusCommand := "write -c " + circuit + " " + name + " " + value
+	err := t.mu.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer t.mu.Unlock()
+	lines, err := t.exchange(ctx, ebusCommand)
+	if err != nil {
+		t.debug(fmt.Sprintf("Error writing to ebusd: %s", err))
+		return err
+	}
+	ebusAnswer := lastLine(lines)
+	t.debug(fmt.Sprintf("Command sent to ebusd: %s", ebusCommand))
+	t.debug(fmt.Sprintf("ebusd answered: %s", ebusAnswer))
+	if isEbusdErrorReply(ebusAnswer) {
+		return &EbusdError{Command: ebusCommand, Element: name, Circuit: circuit, Reply: ebusAnswer}
+	}
+	return nil
+}
+
+func (t *tcpTransport) Find(ctx context.Context, name string) ([]string, error) {
+	ebusCommand := "find " + name
+	message, err := t.command(ctx, ebusCommand)
+	if err != nil {
+		return nil, err
+	}
+	var circuits []string
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if isEbusdErrorReply(line) {
+			t.debug(fmt.Sprintf("Command: %s, ebusd answered: %s", ebusCommand, line))
+			return nil, &EbusdError{Command: ebusCommand, Element: name, Reply: line}
+		}
+		// a find result looks like "ctlv2 HwcSFMode = auto"
+		circuits = append(circuits, strings.Fields(line)[0])
+	}
+	return circuits, nil
+}
+
+// FindDefinitions lists the message definitions of circuit. If ebusd knows no message of circuit,
+// the EbusdError "element not found" is returned.
+func (t *tcpTransport) FindDefinitions(ctx context.Context, circuit string) ([]string, error) {
+	ebusCommand := "find -F " + EBUSD_DEFINITION_COLUMNS + " -c " + circuit
+	message, err := t.command(ctx, ebusCommand)
+	if err != nil {
+		return nil, err
+	}
+	var definitions []string
+	for _, line := range strings.Split(message, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if isEbusdErrorReply(line) {
+			t.debug(fmt.Sprintf("Command: %s, ebusd answered: %s", ebusCommand, line))
+			return nil, &EbusdError{Command: ebusCommand, Circuit: circuit, Reply: line}
+		}
+		definitions = append(definitions, line)
+	}
+	return definitions, nil
+}
+
+func (t *tcpTransport) Hex(ctx context.Context, telegram string) (string, error) {
+	ebusCommand := "hex " + telegram
+	message, err := t.command(ctx, ebusCommand)
+	if err != nil {
+		return "", err
+	}
+	message = strings.TrimSpace(message)
+	if isEbusdErrorReply(message) {
+		t.debug(fmt.Sprintf("Command: %s, ebusd answered: %s", ebusCommand, message))
+		return "", &EbusdError{Command: ebusCommand, Reply: message}
+	}
+	return message, nil
+}
+
+func (t *tcpTransport) Scan(ctx context.Context) (string, error) {
+	return t.command(ctx, "scan result")
+}
+
+func (t *tcpTransport) Info(ctx context.Context) (string, error) {
+	return t.command(ctx, "info")
+}
+
+func (t *tcpTransport) State(ctx context.Context) (string, error) {
+	message, err := t.command(ctx, "state")
+	if err != nil {
+		return "", err
+	}
+	message = strings.TrimSpace(message)
+	if isEbusdErrorReply(message) {
+		return "", &EbusdError{Command: "state", Reply: message}
+	}
+	return message, nil
+}
+
+// command sends ebusCommand and returns all lines of the answer, each terminated by "\n"
+func (t *tcpTransport) command(ctx context.Context, ebusCommand string) (string, error) {
+	err := t.mu.Lock(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer t.mu.Unlock()
+	lines, err := t.exchange(ctx, ebusCommand)
+	if err != nil {
+		return "", err
+	}
+	var message strings.Builder
+	for _, line := range lines {
+		message.WriteString(line + "\n")
+	}
+	return message.String(), nil
+}
+
+// exchange sends ebusCommand over the session and returns the lines of the answer. The caller must hold t.mu.
+func (t *tcpTransport) exchange(ctx context.Context, ebusCommand string) ([]string, error) {
+	answers, err := t.exchangeMany(ctx, []string{ebusCommand})
+	if err != nil {
+		return nil, err
+	}
+	return answers[0], nil
+}
+
+// exchangeMany sends all ebusCommands over the session at once and returns the lines of the answer to each
+// of them. After a failed write or read the state of the session is unknown, so it is dropped and reopened
+// by the next command. Retrying is left to the retry policy of the connection. The caller must hold t.mu.
+func (t *tcpTransport) exchangeMany(ctx context.Context, ebusCommands []string) ([][]string, error) {
+	err := t.ensureEbusdConnection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer t.watchContext(ctx)()
+	t.ebusdDiscardBuffered()
+	request := strings.Join(ebusCommands, "\n") + "\n"
+	_, err = fmt.Fprint(t.ebusdConn, request)
+	if err != nil {
+		t.debug(fmt.Sprintf("Error sending command %q to ebusd: %s", ebusCommands[0], err))
+		t.dropEbusdConnection()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	answers := make([][]string, 0, len(ebusCommands))
+	for _, ebusCommand := range ebusCommands {
+		lines, err := readEbusdAnswer(t.ebusdReadBuffer)
+		if err != nil {
+			t.debug(fmt.Sprintf("Error when reading answer to %q from ebusd: %s", ebusCommand, err))
+			t.dropEbusdConnection()
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+		answers = append(answers, lines)
+	}
+	return answers, nil
+}
+
+// Listen opens a dedicated session in listen mode, so that the commands on the main session are not disturbed
+// by the updates pushed by ebusd
+func (t *tcpTransport) Listen(ctx context.Context, fn func(circuit, name, value string)) error {
+	err := t.mu.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	closed := t.closed
+	t.mu.Unlock()
+	if closed {
+		return errors.New("ebusd connection already closed")
+	}
+	dialer := net.Dialer{KeepAlive: t.keepAliveInterval}
+	conn, err := dialer.DialContext(ctx, "tcp", t.ebusdAddress)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// ending the listen mode and closing the session unblocks the pending read when ctx is done
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetWriteDeadline(time.Now().Add(time.Second))
+		_, _ = fmt.Fprint(conn, "listen stop\n")
+		conn.Close()
+	})
+	defer stop()
+	reader := bufio.NewReader(conn)
+	_, err = fmt.Fprint(conn, "listen\n")
+	if err == nil {
+		var lines []string
+		lines, err = readEbusdAnswer(reader)
+		if err == nil && isEbusdErrorReply(lastLine(lines)) {
+			return &EbusdError{Command: "listen", Reply: lastLine(lines)}
+		}
+	}
+	for err == nil {
+		var line string
+		line, err = reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+		circuit, name, value, ok := parseListenLine(line)
+		if !ok {
+			continue
+		}
+		fn(circuit, name, value)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	t.debug(fmt.Sprintf("Error in listen session to ebusd: %s", err))
+	return err
+}
+
+// parseListenLine splits an update sent by ebusd in listen mode, e.g. "ctlv2 HwcSFMode = auto"
+func parseListenLine(line string) (circuit, name, value string, ok bool) {
+	line = strings.TrimRight(line, "\r\n")
+	element, value, found := strings.Cut(line, " = ")
+	if !found {
+		return "", "", "", false
+	}
+	fields := strings.Fields(element)
+	if len(fields) != 2 {
+		return "", "", "", false
+	}
+	return fields[0], fields[1], value, true
+}
+
+// readEbusdAnswer reads one answer of ebusd. Every answer consists of one or more lines and is terminated
+// by an empty line. The first line belongs to the answer even if it is empty (e.g. the value of an empty
+// string field). The lines are returned without line endings and without the terminating empty line.
+func readEbusdAnswer(r *bufio.Reader) ([]string, error) {
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && (line != "" || len(lines) > 0) {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" && len(lines) > 0 {
+			return lines, nil
+		}
+		lines = append(lines, line)
+	}
+}
+
+// lastLine returns the last line of an answer. Single line answers (read, write) are the last line
+// of their frame, so that lines sent in front of them do not shift the stream.
+func lastLine(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// ebusdDiscardBuffered drops bytes still buffered in front of the answer to the next command,
+// e.g. unsolicited lines sent by ebusd after the last answer
+func (t *tcpTransport) ebusdDiscardBuffered() {
+	if t.ebusdReadBuffer != nil && t.ebusdReadBuffer.Buffered() > 0 {
+		_, _ = t.ebusdReadBuffer.Discard(t.ebusdReadBuffer.Buffered())
+	}
+}
+
+// refreshEbusdConnection (re)opens the session to ebusd. The caller must hold t.mu.
+func (t *tcpTransport) refreshEbusdConnection(ctx context.Context) error {
+	if t.closed {
+		return errors.New("ebusd connection already closed")
+	}
+	if t.ebusdConn != nil {
+		t.ebusdConn.Close()
+		t.ebusdConn = nil
+	}
+	dialer := net.Dialer{KeepAlive: t.keepAliveInterval}
+	conn, err := dialer.DialContext(ctx, "tcp", t.ebusdAddress)
+	if err != nil {
+		return err
+	}
+	t.ebusdConn = conn
+	t.ebusdReadBuffer = bufio.NewReader(t.ebusdConn)
+	return nil
+}
+
+// ensureEbusdConnection opens the session to ebusd if it is not open yet. The caller must hold t.mu.
+func (t *tcpTransport) ensureEbusdConnection(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if t.ebusdConn != nil && !t.closed {
+		return nil
+	}
+	return t.refreshEbusdConnection(ctx)
+}
+
+// dropEbusdConnection closes the session after an error that leaves it in an unknown state.
+// The next command reopens it. The caller must hold t.mu.
+func (t *tcpTransport) dropEbusdConnection() {
+	if t.ebusdConn != nil {
+		t.ebusdConn.Close()
+		t.ebusdConn = nil
+	}
+}
+
+// applyContextDeadline sets the deadline of ctx (or none) on the current session. The caller must hold t.mu.
+func (t *tcpTransport) applyContextDeadline(ctx context.Context) {
+	if t.ebusdConn == nil {
+		return
+	}
+	deadline, _ := ctx.Deadline()
+	_ = t.ebusdConn.SetDeadline(deadline)
+}
+
+// watchContext propagates the deadline and the cancellation of ctx to the socket reads and writes
+// of the session. The returned function stops watching and must be called before t.mu is released.
+func (t *tcpTransport) watchContext(ctx context.Context) func() {
+	t.applyContextDeadline(ctx)
+	conn := t.ebusdConn
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		select {
+		case <-ctx.Done():
+			if conn != nil {
+				// a deadline in the past unblocks pending reads and writes immediately
+				_ = conn.SetDeadline(time.Unix(1, 0))
+			}
+		case <-stop:
+		}
+	}()
+	return func() {
+		close(stop)
+		<-done
+		if t.ebusdConn != nil {
+			_ = t.ebusdConn.SetDeadline(time.Time{})
+		}
+	}
+}
+
+// Close tears down the session to ebusd. Further calls return an error.
+func (t *tcpTransport) Close() error {
+	_ = t.mu.Lock(context.Background())
+	defer t.mu.Unlock()
+	t.closed = true
+	if t.ebusdConn == nil {
+		return nil
+	}
+	err := t.ebusdConn.Close()
+	t.ebusdConn = nil
+	t.ebusdReadBuffer = nil
+	return err
+}
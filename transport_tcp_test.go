@@ -0,0 +1,173 @@
+package sensonetEbus
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/WulfgarW/sensonetEbus/ebusdtest"
+	"golang.org/x/exp/slices"
+)
+
+func TestReadEbusdAnswer(t *testing.T) {
+	tests := []struct {
+		name    string
+		stream  string
+		answers [][]string
+		err     error // returned after the answers
+	}{
+		{
+			name:    "single line",
+			stream:  "auto\n\n",
+			answers: [][]string{{"auto"}},
+			err:     io.EOF,
+		},
+		{
+			name:    "empty first line",
+			stream:  "\n\n",
+			answers: [][]string{{""}},
+			err:     io.EOF,
+		},
+		{
+			name:    "carriage returns",
+			stream:  "auto\r\n\r\n",
+			answers: [][]string{{"auto"}},
+			err:     io.EOF,
+		},
+		{
+			name:    "multi line",
+			stream:  "ctlv2 HwcSFMode = auto\nctlv2 HwcTempDesired = 50.0\n\n",
+			answers: [][]string{{"ctlv2 HwcSFMode = auto", "ctlv2 HwcTempDesired = 50.0"}},
+			err:     io.EOF,
+		},
+		{
+			name:   "consecutive answers",
+			stream: "auto\n\nctlv2 Z1OpMode = auto\nctlv2 Z2OpMode = off\n\ndone\n\n",
+			answers: [][]string{
+				{"auto"},
+				{"ctlv2 Z1OpMode = auto", "ctlv2 Z2OpMode = off"},
+				{"done"},
+			},
+			err: io.EOF,
+		},
+		{
+			name:    "garbage lines before answer",
+			stream:  "ctlv2 HwcSFMode = auto\nunexpected line\n50.0\n\n",
+			answers: [][]string{{"ctlv2 HwcSFMode = auto", "unexpected line", "50.0"}},
+			err:     io.EOF,
+		},
+		{
+			name:   "no answer",
+			stream: "",
+			err:    io.EOF,
+		},
+		{
+			name:   "cut short in first line",
+			stream: "aut",
+			err:    io.ErrUnexpectedEOF,
+		},
+		{
+			name:   "cut short before terminating line",
+			stream: "ctlv2 Z1OpMode = auto\nctlv2 Z2OpMode = off\n",
+			err:    io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "second answer cut short",
+			stream:  "auto\n\n50.0\n",
+			answers: [][]string{{"auto"}},
+			err:     io.ErrUnexpectedEOF,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := bufio.NewReader(strings.NewReader(tc.stream))
+			for i, want := range tc.answers {
+				lines, err := readEbusdAnswer(r)
+				if err != nil {
+					t.Fatalf("answer %d: readEbusdAnswer() failed: %s", i, err)
+				}
+				if !slices.Equal(lines, want) {
+					t.Errorf("answer %d: readEbusdAnswer() = %q, want %q", i, lines, want)
+				}
+			}
+			lines, err := readEbusdAnswer(r)
+			if !errors.Is(err, tc.err) {
+				t.Errorf("readEbusdAnswer() after the answers = %q, %v, want error %v", lines, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestTCPTransportGarbage(t *testing.T) {
+	server, err := ebusdtest.NewServer()
+	if err != nil {
+		t.Fatalf("NewServer() failed: %s", err)
+	}
+	defer server.Close()
+	server.Set(ebusdtest.DEFAULT_CONTROLLER_NAME, EBUSDREAD_HOTWATER_SFMODE, "auto")
+	server.Set(ebusdtest.DEFAULT_CONTROLLER_NAME, EBUSDREAD_HOTWATER_TEMPDESIRED, "50.0")
+
+	transport := newTCPTransport(server.Addr(), nil)
+	defer transport.Close()
+	ctx := context.Background()
+
+	server.InjectGarbage("ctlv2 Z1OpMode = off", "unexpected line")
+	value, err := transport.Read(ctx, ebusdtest.DEFAULT_CONTROLLER_NAME, EBUSDREAD_HOTWATER_SFMODE, -1)
+	if err != nil {
+		t.Fatalf("Read() with garbage failed: %s", err)
+	}
+	if value != "auto" {
+		t.Errorf("Read() with garbage = %q, want %q", value, "auto")
+	}
+
+	// the garbage must not shift the answers to the following commands
+	value, err = transport.Read(ctx, ebusdtest.DEFAULT_CONTROLLER_NAME, EBUSDREAD_HOTWATER_TEMPDESIRED, -1)
+	if err != nil {
+		t.Fatalf("Read() after garbage failed: %s", err)
+	}
+	if value != "50.0" {
+		t.Errorf("Read() after garbage = %q, want %q", value, "50.0")
+	}
+	circuits, err := transport.Find(ctx, EBUSDREAD_HOTWATER_TEMPDESIRED)
+	if err != nil {
+		t.Fatalf("Find() after garbage failed: %s", err)
+	}
+	if !slices.Equal(circuits, []string{ebusdtest.DEFAULT_CONTROLLER_NAME}) {
+		t.Errorf("Find() after garbage = %q, want %q", circuits, []string{ebusdtest.DEFAULT_CONTROLLER_NAME})
+	}
+}
+
+func TestTCPTransportAnswerCutShort(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() failed: %s", err)
+	}
+	defer listener.Close()
+	// a server answering the first command of every connection with a multi line answer missing
+	// the terminating empty line, then closing the connection
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_, _ = bufio.NewReader(conn).ReadString('\n')
+			_, _ = io.WriteString(conn, "ctlv2 Z1OpMode = auto\nctlv2 Z2Op")
+			conn.Close()
+		}
+	}()
+
+	transport := newTCPTransport(listener.Addr().String(), nil)
+	defer transport.Close()
+	_, err = transport.Find(context.Background(), "OpMode")
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Find() with an answer cut short returned %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if transport.ebusdConn != nil {
+		t.Error("session not dropped after an answer cut short")
+	}
+}
@@ -8,7 +8,9 @@ const (
 	STRATEGY_HEATING               = 2
 	STRATEGY_HOTWATER_THEN_HEATING = 3
 
+	OPERATIONMODE_OFF         string = "off"
 	OPERATIONMODE_AUTO        string = "auto"
+	OPERATIONMODE_DAY         string = "day"
 	QUICKMODE_HOTWATER        string = "Hotwater Boost"
 	QUICKMODE_HEATING         string = "Heating Quick Veto"
 	QUICKMODE_NOTHING         string = "Charger running idle"
@@ -26,6 +28,8 @@ const (
 	EBUSDREAD_HOTWATER_TEMPDESIRED        = "HwcTempDesired"
 	EBUSDREAD_HOTWATER_STORAGETEMP        = "HwcStorageTemp"
 	EBUSDREAD_HOTWATER_SFMODE             = "HwcSFMode"
+	EBUSDREAD_HOTWATER_HOLIDAYSTARTPERIOD = "HwcHolidayStartPeriod"
+	EBUSDREAD_HOTWATER_HOLIDAYENDPERIOD   = "HwcHolidayEndPeriod"
 	EBUSDREAD_ZONE_SHORTNAME              = "Shortname"             //To be added by the zone prefix
 	EBUSDREAD_ZONE_NAME1                  = "Name1"                 //To be added by the zone prefix
 	EBUSDREAD_ZONE_NAME2                  = "Name2"                 //To be added by the zone prefix
@@ -37,17 +41,44 @@ const (
 	EBUSDREAD_ZONE_QUICKVETOENDDATE       = "QuickVetoEndDate"      //To be added by the zone prefix
 	EBUSDREAD_ZONE_QUICKVETOENDTIME       = "QuickVetoEndTime"      //To be added by the zone prefix
 	EBUSDREAD_ZONE_QUICKVETODURATION      = "QuickVetoDuration"     //To be added by the zone prefix
+	EBUSDREAD_ZONE_DAYTEMP                = "DayTemp"               //To be added by the zone prefix
+	EBUSDREAD_ZONE_NIGHTTEMP              = "NightTemp"             //To be added by the zone prefix
+	EBUSDREAD_ZONE_HOLIDAYSTARTPERIOD     = "HolidayStartPeriod"    //To be added by the zone prefix
+	EBUSDREAD_ZONE_HOLIDAYENDPERIOD       = "HolidayEndPeriod"      //To be added by the zone prefix
+	EBUSDREAD_ZONE_HOLIDAYTEMP            = "HolidayTemp"           //To be added by the zone prefix
+	EBUSDREAD_HC_CIRCUITTYPE              = "CircuitType"           //To be added by the heat circuit prefix
+	EBUSDREAD_HC_ACTUALFLOWTEMPDESIRED    = "ActualFlowTempDesired" //To be added by the heat circuit prefix
+	EBUSDREAD_HC_FLOWTEMP                 = "FlowTemp"              //To be added by the heat circuit prefix
+	EBUSDREAD_HC_STATUS                   = "Status"                //To be added by the heat circuit prefix
+	EBUSDREAD_HC_PUMPSTATUS               = "PumpStatus"            //To be added by the heat circuit prefix
+	EBUSDREAD_HC_HEATCURVE                = "HeatCurve"             //To be added by the heat circuit prefix
+	EBUSDREAD_HC_SUMMERTEMPLIMIT          = "SummerTempLimit"       //To be added by the heat circuit prefix
+	EBUSDREAD_HC_MINFLOWTEMPDESIRED       = "MinFlowTempDesired"    //To be added by the heat circuit prefix
+	EBUSDREAD_HC_MAXFLOWTEMPDESIRED       = "MaxFlowTempDesired"    //To be added by the heat circuit prefix
+
+	HC_CIRCUITTYPE_INACTIVE = "inactive"
+
+	HOLIDAY_DATE_LAYOUT  = "02.01.2006" // layout of the dates of the holiday periods returned by ebusd
+	HOLIDAY_CLEARED_DATE = "01.01.2015" // start and end date of a holiday period cleared in the VRC720
 
 	HWC_SFMODE_BOOST   = "load"
 	HWC_SFMODE_NORMAL  = "auto"
 	ZONE_SFMODE_BOOST  = "veto"
 	ZONE_SFMODE_NORMAL = "auto"
 	//HOTWATERINDEX_DEFAULT                = 255
-	ZONEINDEX_DEFAULT        = 0
+	ZONEINDEX_DEFAULT        = 0 // selects the first configured zone, like a negative zone index
 	ZONEVETOSETPOINT_DEFAULT = 20.0
 	ZONEVETODURATION_DEFAULT = 0.5
 
+	// The limits of the zone setpoints are the defaults of the VRC720. They are not read from the controller, so a
+	// controller configured with narrower limits may still reject values within them with an ERR: reply.
+	ZONE_MIN_TEMP = 5.0  // default lowest day and night setpoint of the zones of the VRC720
+	ZONE_MAX_TEMP = 30.0 // default highest day and night setpoint of the zones of the VRC720
+	HWC_MIN_TEMP  = 0.0  // lowest hotwater setpoint, the range of HwcTempDesired checked by GetSystem()
+	HWC_MAX_TEMP  = 75.0 // highest hotwater setpoint, the range of HwcTempDesired checked by GetSystem()
+
 	//eBusd errors
+	//Deprecated: ERR: replies of ebusd are returned as *EbusdError. Use errors.Is() with ErrElementNotFound, ErrNoSignal etc. instead
 	EBUSD_ERROR_ELEMENTNOTFOUND      = "ERR: element not found"
 	EBUSD_ERROR_NOSIGNAL             = "ERR: no signal"
 	EBUSD_ERROR_INVALIDPOSITION      = "ERR: invalid position in decode"
@@ -61,8 +92,15 @@ type ParsStruct struct {
 
 // Types fpr Vaillant data
 
+// Deprecated: The configured zones are detected when connecting to ebusd, see Connection.Zones()
 const NUMBER_OF_ZONES_TO_READ = 3
 
+// MAX_NUMBER_OF_ZONES is the highest zone index probed when detecting the configured zones
+const MAX_NUMBER_OF_ZONES = 9
+
+// MAX_NUMBER_OF_HEATCIRCUITS is the highest heat circuit index probed when detecting the configured heat circuits
+const MAX_NUMBER_OF_HEATCIRCUITS = 3
+
 type VaillantRelDataZones struct {
 	Index                 int
 	Name1                 string
@@ -78,13 +116,28 @@ type VaillantRelDataZones struct {
 	RoomTemp              float64
 }
 
-/* not used yet
 type VaillantRelDataHeatCircuits struct {
 	Index                 int
+	CircuitType           string
 	ActualFlowTempDesired float64
 	FlowTemp              float64
 	Status                string
-}*/
+	PumpStatus            string
+	HeatCurve             float64
+	SummerTempLimit       float64
+	MinFlowTempDesired    float64
+	MaxFlowTempDesired    float64
+}
+
+// VaillantRelDataHoliday is the holiday period of the hotwater or of a zone. From StartDate to EndDate inclusively,
+// the hotwater is off and the zone is kept at Temp.
+type VaillantRelDataHoliday struct {
+	Index     int    // zone index, 0 for the hotwater
+	StartDate string // "dd.mm.yyyy", HOLIDAY_CLEARED_DATE if no holiday period is set
+	EndDate   string
+	Temp      float64 // setpoint of the zone during the holiday period, 0 for the hotwater
+	Active    bool    // today is within the holiday period
+}
 
 type VaillantRelData struct {
 	//SerialNumber string
@@ -118,7 +171,13 @@ type VaillantRelData struct {
 
 	Zones []VaillantRelDataZones
 
-	//	HeatCircuits []VaillantRelDataHeatCircuits
+	HeatCircuits []VaillantRelDataHeatCircuits
+
+	Holiday struct {
+		Active   bool // the holiday period of the hotwater or of a zone contains today
+		Hotwater VaillantRelDataHoliday
+		Zones    []VaillantRelDataHoliday
+	}
 }
 
 /*